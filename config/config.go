@@ -19,6 +19,9 @@ type Config struct {
 	Name                   string              `mapstructure:"name" yaml:"name"`
 	Telegram               TelegramConfig      `mapstructure:"telegram" yaml:"telegram"`
 	Pushover               PushoverConfig      `mapstructure:"pushover" yaml:"pushover"`
+	Ntfy                   NtfyConfig          `mapstructure:"ntfy" yaml:"ntfy"`
+	Gotify                 GotifyConfig        `mapstructure:"gotify" yaml:"gotify"`
+	Matrix                 MatrixConfig        `mapstructure:"matrix" yaml:"matrix"`
 	Database               DatabaseConfig      `mapstructure:"database" yaml:"database"`
 	Jwt                    JwtConfig           `mapstructure:"jwt" yaml:"jwt"`
 	Server                 ServerConfig        `mapstructure:"server" yaml:"server"`
@@ -27,6 +30,10 @@ type Config struct {
 	StripeConfig           StripeConfig        `mapstructure:"stripe" yaml:"stripe"`
 	OAuth2Config           OAuth2Config        `mapstructure:"oauth2" yaml:"oauth2"`
 	WebhookConfig          WebhookConfig       `mapstructure:"webhook" yaml:"webhook"`
+	MQTT                   MQTTConfig          `mapstructure:"mqtt" yaml:"mqtt"`
+	ThingHistory           ThingHistoryConfig  `mapstructure:"thing_history" yaml:"thing_history"`
+	Sync                   SyncConfig          `mapstructure:"sync" yaml:"sync"`
+	Trash                  TrashConfig         `mapstructure:"trash" yaml:"trash"`
 	MFAConfig              MFAConfig           `mapstructure:"mfa" yaml:"mfa"`
 	IsDoneTickDotCom       bool                `mapstructure:"is_done_tick_dot_com" yaml:"is_done_tick_dot_com"`
 	IsUserCreationDisabled bool                `mapstructure:"is_user_creation_disabled" yaml:"is_user_creation_disabled"`
@@ -68,6 +75,21 @@ type PushoverConfig struct {
 	Token string `mapstructure:"token" yaml:"token"`
 }
 
+type NtfyConfig struct {
+	ServerURL string `mapstructure:"server_url" yaml:"server_url"` // defaults to https://ntfy.sh
+	Token     string `mapstructure:"token" yaml:"token"`           // access token for servers that require auth
+}
+
+type GotifyConfig struct {
+	ServerURL string `mapstructure:"server_url" yaml:"server_url"`
+	Token     string `mapstructure:"token" yaml:"token"` // application token used when the user has no target set
+}
+
+type MatrixConfig struct {
+	HomeserverURL string `mapstructure:"homeserver_url" yaml:"homeserver_url"`
+	AccessToken   string `mapstructure:"access_token" yaml:"access_token"` // token of the bot user, it has to be joined to the rooms it posts to
+}
+
 type DatabaseConfig struct {
 	Type      string `mapstructure:"type" yaml:"type"`
 	Host      string `mapstructure:"host" yaml:"host"`
@@ -93,6 +115,7 @@ type ServerConfig struct {
 	WriteTimeout     time.Duration `mapstructure:"write_timeout" yaml:"write_timeout"`
 	CorsAllowOrigins []string      `mapstructure:"cors_allow_origins" yaml:"cors_allow_origins"`
 	ServeFrontend    bool          `mapstructure:"serve_frontend" yaml:"serve_frontend"`
+	PublicURL        string        `mapstructure:"public_url" yaml:"public_url"` // URL the frontend is reachable at, used for links in notifications
 }
 
 type SchedulerConfig struct {
@@ -120,6 +143,9 @@ type EmailConfig struct {
 	Host    string `mapstructure:"host"`
 	Port    int    `mapstructure:"port"`
 	AppHost string `mapstructure:"appHost"`
+	// LinkSecret signs the unsubscribe and confirmation links in emails, it is derived from the JWT secret
+	// when empty so rotating the JWT secret also breaks the links already sent
+	LinkSecret string `mapstructure:"link_secret"`
 }
 
 type OAuth2Config struct {
@@ -136,6 +162,49 @@ type OAuth2Config struct {
 type WebhookConfig struct {
 	Timeout   time.Duration `mapstructure:"timeout" yaml:"timeout" default:"5s"`
 	QueueSize int           `mapstructure:"queue_size" yaml:"queue_size" default:"100"`
+	// AllowedPrivateNetworks are the loopback and private networks, in CIDR notation, that webhooks and
+	// notifications can be sent to. Public addresses are always allowed. When it is not set self-hosted
+	// servers allow DefaultAllowedPrivateNetworks and donetick.com allows none.
+	AllowedPrivateNetworks []string `mapstructure:"allowed_private_networks" yaml:"allowed_private_networks"`
+}
+
+// DefaultAllowedPrivateNetworks are the networks self-hosted servers send webhooks to when none are
+// configured, services like Home Assistant usually run on the same network. Link-local addresses are left
+// out, they include the metadata service of cloud servers.
+var DefaultAllowedPrivateNetworks = []string{"127.0.0.0/8", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "::1/128", "fc00::/7"}
+
+// MQTTConfig connects things to an MQTT broker. Anyone allowed to publish to the broker can change the
+// state of every thing, so access has to be limited by the broker.
+type MQTTConfig struct {
+	Enabled     bool   `mapstructure:"enabled" yaml:"enabled"`
+	Broker      string `mapstructure:"broker" yaml:"broker"` // e.g. tcp://localhost:1883
+	ClientID    string `mapstructure:"client_id" yaml:"client_id" default:"donetick"`
+	Username    string `mapstructure:"username" yaml:"username"`
+	Password    string `mapstructure:"password" yaml:"password"`
+	TopicPrefix string `mapstructure:"topic_prefix" yaml:"topic_prefix" default:"donetick"`
+	QoS         byte   `mapstructure:"qos" yaml:"qos" default:"1"`
+}
+
+// ThingHistoryConfig sets how long the history of things is kept, zero keeps it forever. States of number
+// things older than the raw retention are kept as hourly min/max/avg, hourly ones older than the hourly
+// retention as daily. Rule dry runs only see the raw history.
+type ThingHistoryConfig struct {
+	RawRetention    time.Duration `mapstructure:"raw_retention" yaml:"raw_retention"`
+	HourlyRetention time.Duration `mapstructure:"hourly_retention" yaml:"hourly_retention"`
+	DailyRetention  time.Duration `mapstructure:"daily_retention" yaml:"daily_retention"`
+	Interval        time.Duration `mapstructure:"interval" yaml:"interval" default:"1h"` // how often old history is downsampled
+}
+
+// SyncConfig sets how long deletions are kept for the delta sync of offline clients. A client that has not
+// synced for longer gets everything again.
+type SyncConfig struct {
+	TombstoneRetention time.Duration `mapstructure:"tombstone_retention" yaml:"tombstone_retention" default:"720h"`
+}
+
+// TrashConfig sets how long deleted chores stay in the trash, where they can be restored, before they and
+// their history are deleted for good.
+type TrashConfig struct {
+	Retention time.Duration `mapstructure:"retention" yaml:"retention" default:"720h"`
 }
 
 type MFAConfig struct {
@@ -169,6 +238,18 @@ func configEnvironmentOverrides(Config *Config) {
 	if os.Getenv("DONETICK_PUSHOVER_TOKEN") != "" {
 		Config.Pushover.Token = os.Getenv("DONETICK_PUSHOVER_TOKEN")
 	}
+	if os.Getenv("DONETICK_NTFY_TOKEN") != "" {
+		Config.Ntfy.Token = os.Getenv("DONETICK_NTFY_TOKEN")
+	}
+	if os.Getenv("DONETICK_GOTIFY_TOKEN") != "" {
+		Config.Gotify.Token = os.Getenv("DONETICK_GOTIFY_TOKEN")
+	}
+	if os.Getenv("DONETICK_MATRIX_ACCESS_TOKEN") != "" {
+		Config.Matrix.AccessToken = os.Getenv("DONETICK_MATRIX_ACCESS_TOKEN")
+	}
+	if os.Getenv("DONETICK_MQTT_PASSWORD") != "" {
+		Config.MQTT.Password = os.Getenv("DONETICK_MQTT_PASSWORD")
+	}
 	if os.Getenv("DONETICK_DISABLE_SIGNUP") == "true" {
 		Config.IsUserCreationDisabled = true
 	}
@@ -209,6 +290,9 @@ func LoadConfig() *Config {
 	fmt.Printf("--ConfigLoad name : %s ", config.Name)
 
 	configEnvironmentOverrides(&config)
+	if config.WebhookConfig.AllowedPrivateNetworks == nil && !config.IsDoneTickDotCom {
+		config.WebhookConfig.AllowedPrivateNetworks = DefaultAllowedPrivateNetworks
+	}
 	config.Info.Version = Version
 	config.Info.Commit = Commit
 	config.Info.BuildDate = BuildDate
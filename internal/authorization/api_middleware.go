@@ -1,7 +1,9 @@
 package auth
 
 import (
+	"fmt"
 	"net/http"
+	"time"
 
 	"donetick.com/core/internal/mfa"
 	uModel "donetick.com/core/internal/user/model"
@@ -10,6 +12,8 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+const apiTokenKey = "api_token"
+
 // APITokenMiddleware provides authentication via API tokens
 func APITokenMiddleware(userRepo *uRepo.UserRepository) gin.HandlerFunc {
 	return gin.HandlerFunc(func(c *gin.Context) {
@@ -20,6 +24,18 @@ func APITokenMiddleware(userRepo *uRepo.UserRepository) gin.HandlerFunc {
 			return
 		}
 
+		token, err := userRepo.GetAPIToken(c, apiToken)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API token"})
+			c.Abort()
+			return
+		}
+		if token.IsExpired(time.Now().UTC()) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "API token has expired"})
+			c.Abort()
+			return
+		}
+
 		user, err := userRepo.GetUserByToken(c, apiToken)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API token"})
@@ -33,12 +49,42 @@ func APITokenMiddleware(userRepo *uRepo.UserRepository) gin.HandlerFunc {
 			return
 		}
 
-		// Set the user in context
+		// Set the user and the token in context
 		c.Set(identityKey, user)
+		c.Set(apiTokenKey, token)
 		c.Next()
 	})
 }
 
+// RequireAPIScope allows requests authenticated by an API token only when the token has the scope, it has
+// to come after APITokenMiddleware.
+func RequireAPIScope(scope uModel.APITokenScope) gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		token, ok := CurrentAPIToken(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "API token required"})
+			c.Abort()
+			return
+		}
+		if !token.Scopes.Allows(scope) {
+			c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("API token does not have the %s scope", scope)})
+			c.Abort()
+			return
+		}
+		c.Next()
+	})
+}
+
+// CurrentAPIToken returns the API token the request was authenticated with.
+func CurrentAPIToken(c *gin.Context) (*uModel.APIToken, bool) {
+	data, ok := c.Get(apiTokenKey)
+	if !ok {
+		return nil, false
+	}
+	token, ok := data.(*uModel.APIToken)
+	return token, ok
+}
+
 // OptionalMFAMiddleware provides optional MFA verification for API endpoints
 // This middleware checks for an optional MFA code in headers for enhanced security
 func OptionalMFAMiddleware(userRepo *uRepo.UserRepository) gin.HandlerFunc {
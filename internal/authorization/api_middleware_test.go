@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"donetick.com/core/config"
+	cModel "donetick.com/core/internal/circle/model"
+	"donetick.com/core/internal/database/dbtest"
+	uModel "donetick.com/core/internal/user/model"
+	uRepo "donetick.com/core/internal/user/repo"
+	"github.com/gin-gonic/gin"
+)
+
+func TestAPITokenScopes(t *testing.T) {
+	db := dbtest.Open(t)
+	userRepo := uRepo.NewUserRepository(db, &config.Config{})
+	db.Create(&cModel.Circle{ID: 1, Name: "Home"})
+	db.Create(&uModel.User{ID: 1, Username: "alex", Email: "alex@example.com", CircleID: 1})
+	ctx := context.Background()
+	expired := time.Now().UTC().Add(-time.Minute)
+	for name, scopes := range map[string]uModel.APITokenScopes{
+		"legacy":  nil,
+		"reader":  {uModel.APITokenScopeRead},
+		"chores":  {uModel.APITokenScopeChoresWrite},
+		"expired": {uModel.APITokenScopeRead},
+	} {
+		var expiresAt *time.Time
+		if name == "expired" {
+			expiresAt = &expired
+		}
+		if _, err := userRepo.StoreAPIToken(ctx, 1, name, name+"-token", scopes, expiresAt); err != nil {
+			t.Fatalf("StoreAPIToken() error = %v", err)
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	api := r.Group("", APITokenMiddleware(userRepo))
+	api.GET("/chores", RequireAPIScope(uModel.APITokenScopeRead), func(c *gin.Context) { c.Status(http.StatusOK) })
+	api.POST("/chores", RequireAPIScope(uModel.APITokenScopeChoresWrite), func(c *gin.Context) { c.Status(http.StatusOK) })
+	api.POST("/things", RequireAPIScope(uModel.APITokenScopeThingsWrite), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		token  string
+		method string
+		path   string
+		want   int
+	}{
+		{"legacy-token", http.MethodPost, "/things", http.StatusOK},
+		{"reader-token", http.MethodGet, "/chores", http.StatusOK},
+		{"reader-token", http.MethodPost, "/chores", http.StatusForbidden},
+		{"chores-token", http.MethodGet, "/chores", http.StatusOK},
+		{"chores-token", http.MethodPost, "/chores", http.StatusOK},
+		{"chores-token", http.MethodPost, "/things", http.StatusForbidden},
+		{"expired-token", http.MethodGet, "/chores", http.StatusUnauthorized},
+		{"unknown-token", http.MethodGet, "/chores", http.StatusUnauthorized},
+		{"", http.MethodGet, "/chores", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		if tt.token != "" {
+			req.Header.Set("secretkey", tt.token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("%s %s with %q = %d, want %d", tt.method, tt.path, tt.token, w.Code, tt.want)
+		}
+	}
+
+	// expired tokens are not accepted where the token is looked up directly either
+	if _, err := userRepo.GetUserByToken(ctx, "expired-token"); err == nil {
+		t.Errorf("GetUserByToken() accepted an expired token")
+	}
+}
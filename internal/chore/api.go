@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"donetick.com/core/config"
+	auth "donetick.com/core/internal/authorization"
 	chRepo "donetick.com/core/internal/chore/repo"
 	"donetick.com/core/internal/events"
 	nps "donetick.com/core/internal/notifier/service"
@@ -17,6 +18,7 @@ import (
 	chModel "donetick.com/core/internal/chore/model"
 	cRepo "donetick.com/core/internal/circle/repo"
 	stRepo "donetick.com/core/internal/subtask/repo"
+	uModel "donetick.com/core/internal/user/model"
 	uRepo "donetick.com/core/internal/user/repo"
 )
 
@@ -41,14 +43,8 @@ func NewAPI(cr *chRepo.ChoreRepository, userRepo *uRepo.UserRepository, circleRe
 }
 
 func (h *API) GetAllChores(c *gin.Context) {
-
-	apiToken := c.GetHeader("secretkey")
-	if apiToken == "" {
-		c.JSON(401, gin.H{"error": "Unauthorized"})
-		return
-	}
-	user, err := h.userRepo.GetUserByToken(c, apiToken)
-	if err != nil {
+	user, ok := auth.CurrentUser(c)
+	if !ok {
 		c.JSON(401, gin.H{"error": "Unauthorized"})
 		return
 	}
@@ -63,13 +59,8 @@ func (h *API) GetAllChores(c *gin.Context) {
 func (h *API) CreateChore(c *gin.Context) {
 	var choreRequest chModel.ChoreReq
 
-	apiToken := c.GetHeader("secretkey")
-	if apiToken == "" {
-		c.JSON(401, gin.H{"error": "Unauthorized"})
-		return
-	}
-	user, err := h.userRepo.GetUserByToken(c, apiToken)
-	if err != nil {
+	user, ok := auth.CurrentUser(c)
+	if !ok {
 		c.JSON(401, gin.H{"error": "Unauthorized"})
 		return
 	}
@@ -91,7 +82,7 @@ func (h *API) CreateChore(c *gin.Context) {
 		Description:    choreRequest.Description,
 	}
 
-	_, err = h.choreRepo.CreateChore(c, chore)
+	_, err := h.choreRepo.CreateChore(c, chore)
 	if err != nil {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
@@ -112,13 +103,8 @@ func (h *API) CompleteChore(c *gin.Context) {
 		return
 	}
 
-	apiToken := c.GetHeader("secretkey")
-	if apiToken == "" {
-		c.JSON(401, gin.H{"error": "No secret key provided"})
-		return
-	}
-	currentUser, err := h.userRepo.GetUserByToken(c, apiToken)
-	if err != nil {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
 		c.JSON(401, gin.H{"error": "Unauthorized"})
 		return
 	}
@@ -194,7 +180,7 @@ func (h *API) CompleteChore(c *gin.Context) {
 		return
 	}
 
-	if err := h.choreRepo.CompleteChore(c, chore, nil, currentUser.ID, nextDueDate, &completedDate, nextAssignedTo, true); err != nil {
+	if err := h.choreRepo.CompleteChore(c, chore, nil, currentUser.ID, nextDueDate, &completedDate, nextAssignedTo, true, nil); err != nil {
 		c.JSON(500, gin.H{
 			"error": "Error completing chore",
 		})
@@ -218,14 +204,33 @@ func (h *API) CompleteChore(c *gin.Context) {
 	)
 }
 
-func APIs(cfg *config.Config, api *API, r *gin.Engine, auth *jwt.GinJWTMiddleware, limiter *limiter.Limiter) {
-
-	thingsAPI := r.Group("eapi/v1/chore")
+// APIs exposes chores to integrations authenticated with an API token. Beyond listing, creating and
+// completing, the endpoints are the ones of the app and answer the same way.
+func APIs(cfg *config.Config, api *API, h *Handler, r *gin.Engine, jwtAuth *jwt.GinJWTMiddleware, limiter *limiter.Limiter) {
 
-	// thingsAPI.Use(utils.TimeoutMiddleware(cfg.Server.WriteTimeout), utils.RateLimitMiddleware(limiter))
+	choresAPI := r.Group("eapi/v1/chore")
+	choresAPI.Use(auth.APITokenMiddleware(api.userRepo))
+	// choresAPI.Use(utils.TimeoutMiddleware(cfg.Server.WriteTimeout), utils.RateLimitMiddleware(limiter))
+	{
+		read := auth.RequireAPIScope(uModel.APITokenScopeRead)
+		write := auth.RequireAPIScope(uModel.APITokenScopeChoresWrite)
+
+		choresAPI.GET("", read, api.GetAllChores)
+		choresAPI.POST("", write, api.CreateChore)
+		choresAPI.PUT("", write, h.editChore)
+		choresAPI.GET("/:id", read, h.getChore)
+		choresAPI.GET("/:id/history", read, h.GetChoreHistory)
+		choresAPI.POST("/:id/complete", write, api.CompleteChore)
+		choresAPI.POST("/:id/skip", write, h.skipChore)
+		choresAPI.PUT("/:id/archive", write, h.archiveChore)
+		choresAPI.PUT("/:id/unarchive", write, h.UnarchiveChore)
+		choresAPI.PUT("/:id/subtask", write, h.UpdateSubtaskCompletedAt)
+	}
+
+	calendarAPI := r.Group("eapi/v1/calendar")
 	{
-		thingsAPI.GET("", api.GetAllChores)
-		thingsAPI.POST("/:id/complete", api.CompleteChore)
+		calendarAPI.GET("/user.ics", api.GetUserCalendar)
+		calendarAPI.GET("/circle.ics", api.GetCircleCalendar)
 	}
 
 }
@@ -0,0 +1,350 @@
+package chore
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	chModel "donetick.com/core/internal/chore/model"
+	"donetick.com/core/logging"
+	"github.com/gin-gonic/gin"
+)
+
+const icsDateTimeFormat = "20060102T150405"
+
+var icsWeekdays = map[string]string{
+	"monday":    "MO",
+	"tuesday":   "TU",
+	"wednesday": "WE",
+	"thursday":  "TH",
+	"friday":    "FR",
+	"saturday":  "SA",
+	"sunday":    "SU",
+}
+
+// GetUserCalendar renders the chores the token owner created or is assigned to as an iCalendar feed.
+func (h *API) GetUserCalendar(c *gin.Context) {
+	h.renderCalendar(c, false)
+}
+
+// GetCircleCalendar renders every active chore in the token owner's circle as an iCalendar feed, the token
+// owner has to be an admin of the circle.
+func (h *API) GetCircleCalendar(c *gin.Context) {
+	h.renderCalendar(c, true)
+}
+
+func (h *API) renderCalendar(c *gin.Context, wholeCircle bool) {
+	log := logging.FromContext(c)
+
+	// calendar apps subscribe with a plain URL and can't send headers, so the token is accepted as a query parameter too
+	apiToken := c.GetHeader("secretkey")
+	if apiToken == "" {
+		apiToken = c.Query("token")
+	}
+	if apiToken == "" {
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+	user, err := h.userRepo.GetUserByToken(c, apiToken)
+	if err != nil {
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	onlyMine := c.Query("mine") == "true"
+	var labelID int
+	if rawLabel := c.Query("label"); rawLabel != "" {
+		if labelID, err = strconv.Atoi(rawLabel); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid label"})
+			return
+		}
+	}
+	var until *time.Time
+	if rawDays := c.Query("days"); rawDays != "" {
+		days, err := strconv.Atoi(rawDays)
+		if err != nil || days <= 0 {
+			c.JSON(400, gin.H{"error": "Invalid days"})
+			return
+		}
+		windowEnd := time.Now().UTC().AddDate(0, 0, days)
+		until = &windowEnd
+	}
+
+	members, err := h.circleRepo.GetCircleUsers(c, user.CircleID)
+	if err != nil {
+		log.Errorw("failed to get circle members for calendar", "error", err)
+		c.JSON(500, gin.H{"error": "Error getting circle members"})
+		return
+	}
+	names := make(map[int]string)
+	isAdmin := false
+	for _, member := range members {
+		names[member.UserID] = member.DisplayName
+		if member.UserID == user.ID && member.Role == "admin" {
+			isAdmin = true
+		}
+	}
+	// members only see the chores they created or are assigned to, the feed of every chore is for admins
+	if wholeCircle && !isAdmin {
+		c.JSON(403, gin.H{"error": "Only circle admins can subscribe to the calendar of the circle"})
+		return
+	}
+
+	var chores []*chModel.Chore
+	if wholeCircle {
+		chores, err = h.choreRepo.GetActiveChoresByCircleID(c, user.CircleID)
+	} else {
+		chores, err = h.choreRepo.GetChores(c, user.CircleID, user.ID, false)
+	}
+	if err != nil {
+		log.Errorw("failed to get chores for calendar", "error", err)
+		c.JSON(500, gin.H{"error": "Error getting chores"})
+		return
+	}
+
+	var filtered []*chModel.Chore
+	for _, chore := range chores {
+		if chore.NextDueDate == nil {
+			continue
+		}
+		if onlyMine && chore.AssignedTo != user.ID {
+			continue
+		}
+		if labelID != 0 && !choreHasLabel(chore, labelID) {
+			continue
+		}
+		// the window limits which chores are included, overdue chores are always kept
+		if until != nil && chore.NextDueDate.After(*until) {
+			continue
+		}
+		filtered = append(filtered, chore)
+	}
+
+	c.Header("Content-Disposition", `inline; filename="donetick.ics"`)
+	c.Data(200, "text/calendar; charset=utf-8", []byte(buildCalendar(c, filtered, names, time.Now().UTC())))
+}
+
+func choreHasLabel(chore *chModel.Chore, labelID int) bool {
+	if chore.LabelsV2 == nil {
+		return false
+	}
+	for _, label := range *chore.LabelsV2 {
+		if label.ID == labelID {
+			return true
+		}
+	}
+	return false
+}
+
+func buildCalendar(ctx context.Context, chores []*chModel.Chore, names map[int]string, now time.Time) string {
+	var b strings.Builder
+	writeICSLine(&b, "BEGIN:VCALENDAR")
+	writeICSLine(&b, "VERSION:2.0")
+	writeICSLine(&b, "PRODID:-//Donetick//Chores//EN")
+	writeICSLine(&b, "CALSCALE:GREGORIAN")
+	writeICSLine(&b, "X-WR-CALNAME:Donetick")
+	for _, chore := range chores {
+		writeChoreEvent(&b, chore, choreLocation(ctx, chore), names, now)
+	}
+	writeICSLine(&b, "END:VCALENDAR")
+	return b.String()
+}
+
+func writeChoreEvent(b *strings.Builder, chore *chModel.Chore, loc *time.Location, names map[int]string, now time.Time) {
+	writeICSLine(b, "BEGIN:VEVENT")
+	writeICSLine(b, fmt.Sprintf("UID:chore-%d@donetick", chore.ID))
+	writeICSLine(b, "DTSTAMP:"+now.UTC().Format(icsDateTimeFormat)+"Z")
+	writeICSLine(b, "LAST-MODIFIED:"+chore.UpdatedAt.UTC().Format(icsDateTimeFormat)+"Z")
+
+	// recurrence is expanded by the calendar app on the wall clock of the chore timezone, the same
+	// way scheduleNextDueDate does it, so DTSTART carries the TZID when the chore has one
+	writeICSLine(b, icsDateLine("DTSTART", *chore.NextDueDate, loc))
+	for _, line := range choreRecurrence(chore, loc) {
+		writeICSLine(b, line)
+	}
+
+	writeICSLine(b, "SUMMARY:"+escapeICSText(chore.Name))
+	var description []string
+	if name, ok := names[chore.AssignedTo]; ok && name != "" {
+		description = append(description, "Assignee: "+name)
+	}
+	var labels []string
+	if chore.LabelsV2 != nil {
+		for _, label := range *chore.LabelsV2 {
+			labels = append(labels, label.Name)
+		}
+	}
+	if len(labels) > 0 {
+		description = append(description, "Labels: "+strings.Join(labels, ", "))
+		escaped := make([]string, len(labels))
+		for i, label := range labels {
+			escaped[i] = escapeICSText(label)
+		}
+		writeICSLine(b, "CATEGORIES:"+strings.Join(escaped, ","))
+	}
+	if chore.Description != nil && *chore.Description != "" {
+		description = append(description, *chore.Description)
+	}
+	if len(description) > 0 {
+		writeICSLine(b, "DESCRIPTION:"+escapeICSText(strings.Join(description, "\n")))
+	}
+	writeICSLine(b, "END:VEVENT")
+}
+
+// choreRecurrence returns the RRULE (and EXDATE/RDATE) lines for chores whose schedule does not
+// depend on when they get completed. Rolling, adaptive and one-off chores, and day of the month
+// schedules past the 28th that scheduleNextDueDate clamps differently than RRULE, get none.
+func choreRecurrence(chore *chModel.Chore, loc *time.Location) []string {
+	if chore.IsRolling || chore.NextDueDate == nil {
+		return nil
+	}
+	metadata := chore.FrequencyMetadataV2
+	// go's AddDate overflows the 29th-31st into the next month, which RRULE can't express
+	monthEndSafe := chore.NextDueDate.In(loc).Day() <= 28
+
+	switch chore.FrequencyType {
+	case chModel.FrequencyTypeDaily:
+		return []string{"RRULE:FREQ=DAILY"}
+	case chModel.FrequencyTypeWeekly:
+		return []string{"RRULE:FREQ=WEEKLY"}
+	case chModel.FrequencyTypeMonthly:
+		if monthEndSafe {
+			return []string{"RRULE:FREQ=MONTHLY"}
+		}
+	case chModel.FrequencyTypeYearly:
+		if monthEndSafe {
+			return []string{"RRULE:FREQ=YEARLY"}
+		}
+	case chModel.FrequencyTypeInterval:
+		if metadata == nil || metadata.Unit == nil || chore.Frequency <= 0 {
+			return nil
+		}
+		switch *metadata.Unit {
+		case "hours":
+			return []string{fmt.Sprintf("RRULE:FREQ=HOURLY;INTERVAL=%d", chore.Frequency)}
+		case "days":
+			return []string{fmt.Sprintf("RRULE:FREQ=DAILY;INTERVAL=%d", chore.Frequency)}
+		case "weeks":
+			return []string{fmt.Sprintf("RRULE:FREQ=WEEKLY;INTERVAL=%d", chore.Frequency)}
+		case "months":
+			if monthEndSafe {
+				return []string{fmt.Sprintf("RRULE:FREQ=MONTHLY;INTERVAL=%d", chore.Frequency)}
+			}
+		case "years":
+			if monthEndSafe {
+				return []string{fmt.Sprintf("RRULE:FREQ=YEARLY;INTERVAL=%d", chore.Frequency)}
+			}
+		}
+	case chModel.FrequencyTypeDayOfTheWeek:
+		if metadata == nil || len(metadata.Days) == 0 {
+			return nil
+		}
+		var days []string
+		for _, day := range metadata.Days {
+			if day == nil {
+				continue
+			}
+			if code, ok := icsWeekdays[strings.ToLower(*day)]; ok {
+				days = append(days, code)
+			}
+		}
+		if len(days) > 0 {
+			return []string{"RRULE:FREQ=WEEKLY;BYDAY=" + strings.Join(days, ",")}
+		}
+	case chModel.FrequencyTypeDayOfTheMonth:
+		if metadata == nil || len(metadata.Months) == 0 || chore.Frequency <= 0 || chore.Frequency > 28 {
+			return nil
+		}
+		var months []string
+		for _, month := range metadata.Months {
+			if month == nil {
+				continue
+			}
+			for m := time.January; m <= time.December; m++ {
+				if strings.EqualFold(*month, m.String()) {
+					months = append(months, strconv.Itoa(int(m)))
+				}
+			}
+		}
+		if len(months) > 0 {
+			return []string{fmt.Sprintf("RRULE:FREQ=MONTHLY;BYMONTH=%s;BYMONTHDAY=%d", strings.Join(months, ","), chore.Frequency)}
+		}
+	case chModel.FrequencyTypeRRule:
+		return rruleRecurrence(chore, loc)
+	}
+	return nil
+}
+
+// rruleRecurrence re-anchors the stored rule on the next due date. COUNT is counted from the
+// rule's own DTSTART, so it is turned into the matching UNTIL before it is handed out.
+func rruleRecurrence(chore *chModel.Chore, loc *time.Location) []string {
+	if chore.FrequencyMetadataV2 == nil || chore.FrequencyMetadataV2.RRule == "" {
+		return nil
+	}
+	dueDate := chore.NextDueDate.In(loc)
+	set, err := parseRRuleSet(chore.FrequencyMetadataV2.RRule, dueDate)
+	if err != nil {
+		return nil
+	}
+	options := set.GetRRule().OrigOptions
+	if options.Count > 0 {
+		occurrences := set.All()
+		if len(occurrences) == 0 {
+			return nil
+		}
+		options.Count = 0
+		options.Until = occurrences[len(occurrences)-1].UTC()
+	}
+
+	lines := []string{"RRULE:" + options.RRuleString()}
+	for _, rdate := range set.GetRDate() {
+		if rdate.After(dueDate) {
+			lines = append(lines, icsDateLine("RDATE", rdate, loc))
+		}
+	}
+	for _, exdate := range set.GetExDate() {
+		if exdate.After(dueDate) {
+			lines = append(lines, icsDateLine("EXDATE", exdate, loc))
+		}
+	}
+	return lines
+}
+
+func icsDateLine(name string, t time.Time, loc *time.Location) string {
+	if loc == time.UTC {
+		return name + ":" + t.UTC().Format(icsDateTimeFormat) + "Z"
+	}
+	return fmt.Sprintf("%s;TZID=%s:%s", name, loc.String(), t.In(loc).Format(icsDateTimeFormat))
+}
+
+func escapeICSText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(s)
+}
+
+// writeICSLine writes a content line folded at 75 octets as required by RFC 5545,
+// without splitting a multi-byte character.
+func writeICSLine(b *strings.Builder, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// the leading space counts towards the length of the continuation line
+		limit = 74
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
@@ -0,0 +1,196 @@
+package chore
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"donetick.com/core/config"
+	chModel "donetick.com/core/internal/chore/model"
+	chRepo "donetick.com/core/internal/chore/repo"
+	cModel "donetick.com/core/internal/circle/model"
+	cRepo "donetick.com/core/internal/circle/repo"
+	"donetick.com/core/internal/database/dbtest"
+	uModel "donetick.com/core/internal/user/model"
+	uRepo "donetick.com/core/internal/user/repo"
+	"github.com/gin-gonic/gin"
+)
+
+func TestChoreRecurrence(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("error loading location: %v", err)
+	}
+	due := time.Date(2025, 1, 14, 14, 30, 0, 0, time.UTC)
+	endOfMonth := time.Date(2025, 1, 31, 14, 30, 0, 0, time.UTC)
+	unit := func(u string) *string { return &u }
+
+	tests := []struct {
+		name  string
+		chore chModel.Chore
+		loc   *time.Location
+		want  []string
+	}{
+		{
+			name:  "Daily",
+			chore: chModel.Chore{FrequencyType: chModel.FrequencyTypeDaily, NextDueDate: &due},
+			want:  []string{"RRULE:FREQ=DAILY"},
+		},
+		{
+			name:  "Rolling chores depend on completion",
+			chore: chModel.Chore{FrequencyType: chModel.FrequencyTypeDaily, NextDueDate: &due, IsRolling: true},
+		},
+		{
+			name:  "Monthly on the 31st does not map",
+			chore: chModel.Chore{FrequencyType: chModel.FrequencyTypeMonthly, NextDueDate: &endOfMonth},
+		},
+		{
+			name: "Interval weeks",
+			chore: chModel.Chore{
+				FrequencyType:       chModel.FrequencyTypeInterval,
+				Frequency:           2,
+				NextDueDate:         &due,
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{Unit: unit("weeks")},
+			},
+			want: []string{"RRULE:FREQ=WEEKLY;INTERVAL=2"},
+		},
+		{
+			name: "Days of the week",
+			chore: chModel.Chore{
+				FrequencyType:       chModel.FrequencyTypeDayOfTheWeek,
+				NextDueDate:         &due,
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{Days: []*string{unit("tuesday"), unit("Friday")}},
+			},
+			want: []string{"RRULE:FREQ=WEEKLY;BYDAY=TU,FR"},
+		},
+		{
+			name: "Day of the month",
+			chore: chModel.Chore{
+				FrequencyType:       chModel.FrequencyTypeDayOfTheMonth,
+				Frequency:           14,
+				NextDueDate:         &due,
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{Months: []*string{unit("january"), unit("july")}},
+			},
+			want: []string{"RRULE:FREQ=MONTHLY;BYMONTH=1,7;BYMONTHDAY=14"},
+		},
+		{
+			name: "RRule COUNT becomes UNTIL",
+			chore: chModel.Chore{
+				FrequencyType: chModel.FrequencyTypeRRule,
+				NextDueDate:   &due,
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{
+					Timezone: "America/New_York",
+					RRule:    "DTSTART;TZID=America/New_York:20250101T093000\nRRULE:FREQ=WEEKLY;COUNT=4;BYDAY=TU\nEXDATE;TZID=America/New_York:20250121T093000",
+				},
+			},
+			loc: newYork,
+			want: []string{
+				"RRULE:FREQ=WEEKLY;UNTIL=20250128T143000Z;BYDAY=TU",
+				"EXDATE;TZID=America/New_York:20250121T093000",
+			},
+		},
+		{
+			name:  "Adaptive",
+			chore: chModel.Chore{FrequencyType: chModel.FrequencyTypeAdaptive, NextDueDate: &due},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc := tt.loc
+			if loc == nil {
+				loc = time.UTC
+			}
+			got := choreRecurrence(&tt.chore, loc)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("choreRecurrence() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildCalendar(t *testing.T) {
+	due := time.Date(2025, 1, 14, 14, 30, 0, 0, time.UTC)
+	description := "Bins go out; recycling, compost\nand trash"
+	chore := &chModel.Chore{
+		ID:            7,
+		Name:          "Take out the bins",
+		FrequencyType: chModel.FrequencyTypeWeekly,
+		NextDueDate:   &due,
+		AssignedTo:    2,
+		Description:   &description,
+		LabelsV2:      &[]chModel.Label{{ID: 1, Name: "Kitchen"}, {ID: 2, Name: "Outside"}},
+		FrequencyMetadataV2: &chModel.FrequencyMetadata{
+			Timezone: "Europe/Berlin",
+		},
+	}
+
+	got := buildCalendar(context.Background(), []*chModel.Chore{chore}, map[int]string{2: "Alex"}, due)
+	for _, want := range []string{
+		"UID:chore-7@donetick\r\n",
+		"DTSTART;TZID=Europe/Berlin:20250114T153000\r\n",
+		"RRULE:FREQ=WEEKLY\r\n",
+		"CATEGORIES:Kitchen,Outside\r\n",
+		`DESCRIPTION:Assignee: Alex\nLabels: Kitchen\, Outside\nBins go out\; recycl` + "\r\n" + ` ing\, compost\nand trash` + "\r\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("calendar is missing %q:\n%s", want, got)
+		}
+	}
+	for _, line := range strings.Split(got, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line is longer than 75 octets: %q", line)
+		}
+	}
+}
+
+func TestCircleCalendarIsForAdmins(t *testing.T) {
+	db := dbtest.Open(t)
+	cfg := &config.Config{}
+	for _, record := range []interface{}{
+		&cModel.Circle{ID: 1, Name: "Home"},
+		&uModel.User{ID: 1, Username: "alex", Email: "alex@example.com", CircleID: 1},
+		&uModel.User{ID: 2, Username: "sam", Email: "sam@example.com", CircleID: 1},
+		&cModel.UserCircle{UserID: 1, CircleID: 1, Role: "admin", IsActive: true},
+		&cModel.UserCircle{UserID: 2, CircleID: 1, Role: "member", IsActive: true},
+		&uModel.APIToken{Name: "alex", UserID: 1, Token: "admin-token"},
+		&uModel.APIToken{Name: "sam", UserID: 2, Token: "member-token"},
+	} {
+		if err := db.Create(record).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	due := time.Now().UTC().Add(time.Hour)
+	if err := db.Create(&chModel.Chore{Name: "Clean the attic", CircleID: 1, CreatedBy: 1, AssignedTo: 1, IsActive: true, NextDueDate: &due}).Error; err != nil {
+		t.Fatal(err)
+	}
+	api := NewAPI(chRepo.NewChoreRepository(db, cfg), uRepo.NewUserRepository(db, cfg), cRepo.NewCircleRepository(db), nil, nil, nil)
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/circle.ics", api.GetCircleCalendar)
+	r.GET("/user.ics", api.GetUserCalendar)
+
+	for _, tc := range []struct {
+		path  string
+		token string
+		code  int
+		chore bool
+	}{
+		{"/circle.ics", "member-token", 403, false},
+		{"/circle.ics", "admin-token", 200, true},
+		{"/user.ics", "member-token", 200, false},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path+"?token="+tc.token, nil))
+		if w.Code != tc.code {
+			t.Errorf("GET %s with %s = %d, want %d", tc.path, tc.token, w.Code, tc.code)
+		}
+		if strings.Contains(w.Body.String(), "Clean the attic") != tc.chore {
+			t.Errorf("GET %s with %s has the chore %v, want %v", tc.path, tc.token, !tc.chore, tc.chore)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package chore
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	auth "donetick.com/core/internal/authorization"
+	chModel "donetick.com/core/internal/chore/model"
+	"donetick.com/core/logging"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultForecastDays = 30
+	maxForecastDays     = 90
+	// guards against chores that would otherwise produce thousands of occurrences, e.g. hourly intervals
+	maxForecastOccurrences = 500
+)
+
+type ForecastOccurrence struct {
+	ChoreID    int       `json:"choreId"`
+	Name       string    `json:"name"`
+	DueDate    time.Time `json:"dueDate"`
+	AssignedTo int       `json:"assignedTo"`
+	Points     *int      `json:"points,omitempty"`
+	IsOverdue  bool      `json:"isOverdue"`
+	// random assignment strategies can't be predicted, the assignee is one possible pick
+	IsEstimate bool `json:"isEstimate"`
+}
+
+type ForecastLoad struct {
+	UserID      int `json:"userId"`
+	Occurrences int `json:"occurrences"`
+	Points      int `json:"points"`
+}
+
+type Forecast struct {
+	From        time.Time             `json:"from"`
+	Until       time.Time             `json:"until"`
+	Occurrences []*ForecastOccurrence `json:"occurrences"`
+	Load        []*ForecastLoad       `json:"load"`
+}
+
+func (h *Handler) getForecast(c *gin.Context) {
+	log := logging.FromContext(c)
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+
+	days := defaultForecastDays
+	if rawDays := c.Query("days"); rawDays != "" {
+		var err error
+		days, err = strconv.Atoi(rawDays)
+		if err != nil || days <= 0 || days > maxForecastDays {
+			c.JSON(400, gin.H{
+				"error": "Invalid days, must be between 1 and " + strconv.Itoa(maxForecastDays),
+			})
+			return
+		}
+	}
+
+	chores, err := h.choreRepo.GetActiveChoresByCircleID(c, currentUser.CircleID)
+	if err != nil {
+		c.JSON(500, gin.H{
+			"error": "Error getting chores",
+		})
+		return
+	}
+
+	from := time.Now().UTC()
+	until := from.AddDate(0, 0, days)
+	forecast := &Forecast{From: from, Until: until, Occurrences: []*ForecastOccurrence{}}
+	for _, chore := range chores {
+		if chore.NextDueDate == nil || chore.NextDueDate.After(until) {
+			continue
+		}
+		var history []*chModel.ChoreHistory
+		if needsHistoryForForecast(chore) {
+			history, err = h.choreRepo.GetChoreHistory(c, chore.ID)
+			if err != nil {
+				c.JSON(500, gin.H{
+					"error": "Error getting chore history",
+				})
+				return
+			}
+		}
+		occurrences, err := projectChore(c, chore, history, from, until)
+		if err != nil {
+			// one misconfigured chore shouldn't hide the rest of the forecast
+			log.Warnw("failed to project chore", "chore_id", chore.ID, "error", err)
+		}
+		forecast.Occurrences = append(forecast.Occurrences, occurrences...)
+	}
+
+	sort.SliceStable(forecast.Occurrences, func(i, j int) bool {
+		return forecast.Occurrences[i].DueDate.Before(forecast.Occurrences[j].DueDate)
+	})
+	limitForecastToUser(forecast, chores, currentUser.ID)
+
+	c.JSON(200, gin.H{
+		"res": forecast,
+	})
+}
+
+func needsHistoryForForecast(chore *chModel.Chore) bool {
+	return chore.FrequencyType == chModel.FrequencyTypeAdaptive ||
+		chore.AssignStrategy == chModel.AssignmentStrategyLeastAssigned ||
+		chore.AssignStrategy == chModel.AssignmentStrategyLeastCompleted
+}
+
+// projectChore lists the occurrences of the chore up to until, assuming every occurrence is
+// completed on its due date by its assignee. history must be ordered newest first, the same
+// way GetChoreHistory returns it. An overdue chore is reported once at its due date and then
+// projected as if it was completed at from.
+func projectChore(ctx context.Context, chore *chModel.Chore, history []*chModel.ChoreHistory, from time.Time, until time.Time) ([]*ForecastOccurrence, error) {
+	if chore.NextDueDate == nil {
+		return nil, nil
+	}
+	// work on a copy, the scheduler reads NextDueDate and AssignedTo from the chore
+	projected := *chore
+	dueDate := chore.NextDueDate.UTC()
+	firstDue := dueDate
+	projected.NextDueDate = &firstDue
+	history = append([]*chModel.ChoreHistory{}, history...)
+	isEstimate := false
+
+	var occurrences []*ForecastOccurrence
+	for !dueDate.After(until) && len(occurrences) < maxForecastOccurrences {
+		isOverdue := dueDate.Before(from)
+		// non rolling chores that are long overdue keep producing past due dates, only the first is reported
+		if !isOverdue || len(occurrences) == 0 {
+			occurrences = append(occurrences, &ForecastOccurrence{
+				ChoreID:    chore.ID,
+				Name:       chore.Name,
+				DueDate:    dueDate,
+				AssignedTo: projected.AssignedTo,
+				Points:     chore.Points,
+				IsOverdue:  isOverdue,
+				IsEstimate: isEstimate,
+			})
+		}
+
+		completedDate := dueDate
+		if isOverdue {
+			completedDate = from
+		}
+		var nextDueDate *time.Time
+		var err error
+		if projected.FrequencyType == chModel.FrequencyTypeAdaptive {
+			nextDueDate, err = scheduleAdaptiveNextDueDate(&projected, completedDate, history)
+		} else {
+			nextDueDate, err = scheduleNextDueDate(ctx, &projected, completedDate)
+		}
+		if err != nil {
+			return occurrences, err
+		}
+		if nextDueDate == nil || !nextDueDate.After(dueDate) {
+			break
+		}
+
+		completedBy := projected.AssignedTo
+		if len(projected.Assignees) > 0 {
+			nextAssignedTo, err := checkNextAssignee(&projected, history, completedBy)
+			if err != nil {
+				return occurrences, err
+			}
+			if projected.AssignStrategy == chModel.AssignmentStrategyRandom || projected.AssignStrategy == chModel.AssignmentStrategyRandomExceptLastAssigned {
+				isEstimate = true
+			}
+			projected.AssignedTo = nextAssignedTo
+		}
+		performedAt, previousDueDate := completedDate, dueDate
+		history = append([]*chModel.ChoreHistory{{
+			ChoreID:     chore.ID,
+			PerformedAt: &performedAt,
+			CompletedBy: completedBy,
+			AssignedTo:  completedBy,
+			DueDate:     &previousDueDate,
+			Status:      chModel.ChoreHistoryStatusCompleted,
+		}}, history...)
+
+		dueDate = nextDueDate.UTC()
+		nextDue := dueDate
+		projected.NextDueDate = &nextDue
+	}
+	return occurrences, nil
+}
+
+// limitForecastToUser sets the load of every member from all the occurrences, then keeps only the occurrences of
+// the chores the user can view so the forecast doesn't show chores the user can't open.
+func limitForecastToUser(forecast *Forecast, chores []*chModel.Chore, userID int) {
+	forecast.Load = forecastLoad(forecast.Occurrences)
+	visible := map[int]bool{}
+	for _, chore := range chores {
+		visible[chore.ID] = chore.CanView(userID)
+	}
+	occurrences := []*ForecastOccurrence{}
+	for _, occurrence := range forecast.Occurrences {
+		if visible[occurrence.ChoreID] {
+			occurrences = append(occurrences, occurrence)
+		}
+	}
+	forecast.Occurrences = occurrences
+}
+
+func forecastLoad(occurrences []*ForecastOccurrence) []*ForecastLoad {
+	loadByUser := map[int]*ForecastLoad{}
+	var load []*ForecastLoad
+	for _, occurrence := range occurrences {
+		userLoad, ok := loadByUser[occurrence.AssignedTo]
+		if !ok {
+			userLoad = &ForecastLoad{UserID: occurrence.AssignedTo}
+			loadByUser[occurrence.AssignedTo] = userLoad
+			load = append(load, userLoad)
+		}
+		userLoad.Occurrences++
+		if occurrence.Points != nil {
+			userLoad.Points += *occurrence.Points
+		}
+	}
+	sort.Slice(load, func(i, j int) bool {
+		return load[i].UserID < load[j].UserID
+	})
+	return load
+}
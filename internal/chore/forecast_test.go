@@ -0,0 +1,113 @@
+package chore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	chModel "donetick.com/core/internal/chore/model"
+)
+
+func TestProjectChore(t *testing.T) {
+	from := time.Date(2025, 1, 6, 12, 0, 0, 0, time.UTC)
+	until := from.AddDate(0, 0, 7)
+	points := 5
+
+	t.Run("Round robin rotates through assignees", func(t *testing.T) {
+		due := time.Date(2025, 1, 7, 9, 0, 0, 0, time.UTC)
+		chore := &chModel.Chore{
+			ID:             1,
+			FrequencyType:  chModel.FrequencyTypeInterval,
+			Frequency:      2,
+			NextDueDate:    &due,
+			AssignedTo:     1,
+			AssignStrategy: chModel.AssignmentStrategyRoundRobin,
+			Assignees:      []chModel.ChoreAssignees{{UserID: 1}, {UserID: 2}, {UserID: 3}},
+			Points:         &points,
+			FrequencyMetadataV2: &chModel.FrequencyMetadata{
+				Unit: stringPtr("days"),
+				Time: "2025-01-07T09:00:00Z",
+			},
+		}
+		occurrences, err := projectChore(context.Background(), chore, nil, from, until)
+		if err != nil {
+			t.Fatalf("projectChore() error = %v", err)
+		}
+		wantDays := []int{7, 9, 11, 13}
+		wantAssignees := []int{1, 2, 3, 1}
+		if len(occurrences) != len(wantDays) {
+			t.Fatalf("projectChore() returned %d occurrences, want %d", len(occurrences), len(wantDays))
+		}
+		for i, occurrence := range occurrences {
+			if occurrence.DueDate.Day() != wantDays[i] || occurrence.AssignedTo != wantAssignees[i] {
+				t.Errorf("occurrence %d = %s assigned to %d, want day %d assigned to %d", i, occurrence.DueDate, occurrence.AssignedTo, wantDays[i], wantAssignees[i])
+			}
+		}
+		if chore.NextDueDate.Day() != 7 || chore.AssignedTo != 1 {
+			t.Errorf("projectChore() modified the chore")
+		}
+
+		load := forecastLoad(occurrences)
+		if len(load) != 3 || load[0].Occurrences != 2 || load[0].Points != 10 {
+			t.Errorf("forecastLoad() = %+v", load[0])
+		}
+	})
+
+	t.Run("Overdue chore is reported once", func(t *testing.T) {
+		due := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+		chore := &chModel.Chore{
+			ID:            2,
+			FrequencyType: chModel.FrequencyTypeDaily,
+			NextDueDate:   &due,
+			AssignedTo:    1,
+		}
+		occurrences, err := projectChore(context.Background(), chore, nil, from, until)
+		if err != nil {
+			t.Fatalf("projectChore() error = %v", err)
+		}
+		if len(occurrences) == 0 || !occurrences[0].IsOverdue || !occurrences[0].DueDate.Equal(due) {
+			t.Fatalf("first occurrence should be the overdue one, got %+v", occurrences)
+		}
+		// the next projected occurrences are the upcoming ones: 7th through the 13th
+		if len(occurrences) != 8 || occurrences[1].IsOverdue || occurrences[1].DueDate.Day() != 7 {
+			t.Errorf("projectChore() = %d occurrences, second %+v", len(occurrences), occurrences[1])
+		}
+	})
+
+	t.Run("One time chore", func(t *testing.T) {
+		due := time.Date(2025, 1, 8, 9, 0, 0, 0, time.UTC)
+		chore := &chModel.Chore{FrequencyType: chModel.FrequencyTypeOnce, NextDueDate: &due}
+		occurrences, err := projectChore(context.Background(), chore, nil, from, until)
+		if err != nil || len(occurrences) != 1 {
+			t.Errorf("projectChore() = %d occurrences, err %v, want 1", len(occurrences), err)
+		}
+	})
+}
+
+func TestLimitForecastToUser(t *testing.T) {
+	points := 5
+	due := time.Date(2025, 1, 8, 9, 0, 0, 0, time.UTC)
+	chores := []*chModel.Chore{
+		{ID: 1, Name: "Dishes", CreatedBy: 1, AssignedTo: 1},
+		{ID: 2, Name: "Birthday present", CreatedBy: 2, AssignedTo: 2, Assignees: []chModel.ChoreAssignees{{UserID: 2}}},
+		{ID: 3, Name: "Laundry", CreatedBy: 2, AssignedTo: 1, Assignees: []chModel.ChoreAssignees{{UserID: 1}, {UserID: 2}}},
+	}
+	forecast := &Forecast{}
+	for _, chore := range chores {
+		forecast.Occurrences = append(forecast.Occurrences, &ForecastOccurrence{
+			ChoreID: chore.ID, Name: chore.Name, DueDate: due, AssignedTo: chore.AssignedTo, Points: &points,
+		})
+	}
+
+	limitForecastToUser(forecast, chores, 1)
+	if len(forecast.Occurrences) != 2 || forecast.Occurrences[0].ChoreID != 1 || forecast.Occurrences[1].ChoreID != 3 {
+		t.Errorf("occurrences = %+v, want only the chores user 1 created or is assigned to", forecast.Occurrences)
+	}
+	if len(forecast.Load) != 2 || forecast.Load[1].UserID != 2 || forecast.Load[1].Occurrences != 1 || forecast.Load[1].Points != 5 {
+		t.Errorf("load = %+v, want the load of every chore", forecast.Load)
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"math"
 	"math/rand"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -23,6 +24,8 @@ import (
 	storageRepo "donetick.com/core/internal/storage/repo"
 	stModel "donetick.com/core/internal/subtask/model"
 	stRepo "donetick.com/core/internal/subtask/repo"
+	"donetick.com/core/internal/thing"
+	tModel "donetick.com/core/internal/thing/model"
 	tRepo "donetick.com/core/internal/thing/repo"
 	uModel "donetick.com/core/internal/user/model"
 	"donetick.com/core/internal/utils"
@@ -222,6 +225,31 @@ func (h *Handler) createChore(c *gin.Context) {
 
 	}
 
+	if choreReq.FrequencyMetadata != nil {
+		// recurrence is calculated in the chore timezone, default to the creator's timezone
+		if choreReq.FrequencyMetadata.Timezone == "" {
+			choreReq.FrequencyMetadata.Timezone = currentUser.Timezone
+		} else if !utils.IsValidTimezone(choreReq.FrequencyMetadata.Timezone) {
+			c.JSON(400, gin.H{
+				"error": "Invalid timezone",
+			})
+			return
+		}
+	}
+
+	if choreReq.FrequencyType == chModel.FrequencyTypeRRule {
+		dtStart := time.Now().UTC()
+		if dueDate != nil {
+			dtStart = *dueDate
+		}
+		if err := NormalizeRRule(choreReq.FrequencyMetadata, dtStart); err != nil {
+			c.JSON(400, gin.H{
+				"error": fmt.Sprintf("Invalid recurrence rule: %s", err.Error()),
+			})
+			return
+		}
+	}
+
 	createdChore := &chModel.Chore{
 
 		Name:                   choreReq.Name,
@@ -296,9 +324,14 @@ func (h *Handler) createChore(c *gin.Context) {
 		})
 		return
 	}
+	if savedChore, err := h.choreRepo.GetChore(c, id); err == nil {
+		h.eventProducer.ChoreCreated(c, currentUser.WebhookURL, savedChore, &currentUser.User)
+		h.recordRevision(c, chModel.RevisionActionCreated, nil, savedChore, currentUser.ID, nil)
+	}
 	go func() {
 		h.nPlanner.GenerateNotifications(c, createdChore)
 	}()
+	choreReq.ID = id
 	shouldReturn := HandleThingAssociation(choreReq, h, c, &currentUser.User)
 	if shouldReturn {
 		return
@@ -326,7 +359,12 @@ func (h *Handler) editChore(c *gin.Context) {
 		})
 		return
 	}
+	h.updateChore(c, currentUser, choreReq, nil)
+}
 
+// updateChore edits the chore into choreReq and answers the request. restoredFrom is the revision the chore is
+// restored to when it is not an edit of the user.
+func (h *Handler) updateChore(c *gin.Context, currentUser *uModel.UserDetails, choreReq chModel.ChoreReq, restoredFrom *int) {
 	circleUsers, err := h.circleRepo.GetCircleUsers(c, currentUser.CircleID)
 	if err != nil {
 		c.JSON(500, gin.H{
@@ -335,6 +373,57 @@ func (h *Handler) editChore(c *gin.Context) {
 		return
 	}
 
+	oldChore, err := h.choreRepo.GetChore(c, choreReq.ID)
+
+	if err != nil {
+		c.JSON(500, gin.H{
+			"error": "Error getting chore",
+		})
+		return
+	}
+	if err := oldChore.CanEdit(currentUser.ID, circleUsers); err != nil {
+		c.JSON(403, gin.H{
+			"error": fmt.Sprintf("You cannot edit this chore: %s", err.Error()),
+		})
+		return
+	}
+
+	if choreReq.FrequencyMetadata != nil {
+		// keep the timezone the chore was created with unless the client sends a new one
+		if choreReq.FrequencyMetadata.Timezone == "" {
+			if oldChore.FrequencyMetadataV2 != nil && oldChore.FrequencyMetadataV2.Timezone != "" {
+				choreReq.FrequencyMetadata.Timezone = oldChore.FrequencyMetadataV2.Timezone
+			} else {
+				choreReq.FrequencyMetadata.Timezone = currentUser.Timezone
+			}
+		} else if !utils.IsValidTimezone(choreReq.FrequencyMetadata.Timezone) {
+			c.JSON(400, gin.H{
+				"error": "Invalid timezone",
+			})
+			return
+		}
+	}
+
+	if choreReq.UpdatedAt != nil && oldChore.UpdatedAt.After(*choreReq.UpdatedAt) {
+		// the chore was changed after the user read it, keep both changes unless they are to the same fields
+		merged, conflicts, err := h.mergeEdit(c, oldChore, &choreReq)
+		if err != nil {
+			c.JSON(500, gin.H{
+				"error": "Error merging chore changes",
+			})
+			return
+		}
+		if len(conflicts) > 0 {
+			c.JSON(409, gin.H{
+				"error":     "Chore was changed by someone else, resolve the conflicts and send it again",
+				"conflicts": conflicts,
+				"updatedAt": oldChore.UpdatedAt,
+			})
+			return
+		}
+		choreReq = merged
+	}
+
 	existedChoreAssignees, err := h.choreRepo.GetChoreAssignees(c, choreReq.ID)
 	if err != nil {
 		c.JSON(500, gin.H{
@@ -424,19 +513,17 @@ func (h *Handler) editChore(c *gin.Context) {
 		// if the assigned to field is not set, randomly assign the chore to one of the assignees
 		choreReq.AssignedTo = choreReq.Assignees[rand.Intn(len(choreReq.Assignees))].UserID
 	}
-	oldChore, err := h.choreRepo.GetChore(c, choreReq.ID)
-
-	if err != nil {
-		c.JSON(500, gin.H{
-			"error": "Error getting chore",
-		})
-		return
-	}
-	if err := oldChore.CanEdit(currentUser.ID, circleUsers, choreReq.UpdatedAt); err != nil {
-		c.JSON(403, gin.H{
-			"error": fmt.Sprintf("You cannot edit this chore: %s", err.Error()),
-		})
-		return
+	if choreReq.FrequencyType == chModel.FrequencyTypeRRule {
+		dtStart := time.Now().UTC()
+		if dueDate != nil {
+			dtStart = *dueDate
+		}
+		if err := NormalizeRRule(choreReq.FrequencyMetadata, dtStart); err != nil {
+			c.JSON(400, gin.H{
+				"error": fmt.Sprintf("Invalid recurrence rule: %s", err.Error()),
+			})
+			return
+		}
 	}
 
 	// Create a map to store the existing labels for quick lookup
@@ -469,10 +556,10 @@ func (h *Handler) editChore(c *gin.Context) {
 		})
 		return
 	}
-	description := *choreReq.Description
-	if choreReq.Description == nil && oldChore.Description != nil {
-		description = ""
-
+	// a merged edit can clear the description
+	description := ""
+	if choreReq.Description != nil {
+		description = *choreReq.Description
 	}
 	if err := h.cleanUpUnreferencedFiles(c, currentUser.ID, storageModel.EntityTypeChoreDescription, choreReq.ID, description); err != nil {
 		c.JSON(500, gin.H{
@@ -580,12 +667,21 @@ func (h *Handler) editChore(c *gin.Context) {
 			return
 		}
 	}
+	if savedChore, err := h.choreRepo.GetChore(c, updatedChore.ID); err == nil {
+		h.eventProducer.ChoreUpdated(c, currentUser.WebhookURL, oldChore, savedChore, &currentUser.User)
+		if restoredFrom != nil {
+			h.recordRevision(c, chModel.RevisionActionRestored, oldChore, savedChore, currentUser.ID, restoredFrom)
+		} else {
+			h.recordRevision(c, chModel.RevisionActionEdited, oldChore, savedChore, currentUser.ID, nil)
+		}
+	}
 	go func() {
 		h.nPlanner.GenerateNotifications(c, updatedChore)
 	}()
 	if oldChore.ThingChore != nil {
 		// TODO: Add check to see if dissociation is necessary
-		h.tRepo.DissociateThingWithChore(c, oldChore.ThingChore.ThingID, oldChore.ID)
+		// a rule links the chore to more than one thing
+		h.tRepo.DissociateChoreWithThing(c, oldChore.ID)
 
 	}
 	shouldReturn := HandleThingAssociation(choreReq, h, c, &currentUser.User)
@@ -598,6 +694,39 @@ func (h *Handler) editChore(c *gin.Context) {
 	})
 }
 
+// mergeEdit merges an edit made on an older version of the chore with what changed since that version. Without
+// the revision of that exact version every field the edit and the chore disagree on is a conflict.
+func (h *Handler) mergeEdit(c *gin.Context, chore *chModel.Chore, choreReq *chModel.ChoreReq) (chModel.ChoreReq, []chModel.FieldConflict, error) {
+	var base *chModel.ChoreReq
+	revision, err := h.choreRepo.GetChoreRevisionAt(c, chore.ID, *choreReq.UpdatedAt)
+	if err != nil {
+		return chModel.ChoreReq{}, nil, err
+	}
+	if revision != nil {
+		if base, err = revision.Req(); err != nil {
+			return chModel.ChoreReq{}, nil, err
+		}
+	}
+	merged, conflicts := chModel.MergeChoreReq(base, chModel.NewChoreReq(chore), choreReq)
+	return merged, conflicts, nil
+}
+
+// recordRevision stores the revision of a change the user made to the chore before into after. After has to
+// be read after the change so the revision has the updatedAt clients get. A failure is only logged, the change
+// is made already.
+func (h *Handler) recordRevision(c *gin.Context, action chModel.RevisionAction, before *chModel.Chore, after *chModel.Chore, userID int, restoredFrom *int) {
+	logger := logging.FromContext(c)
+	revision, err := chModel.NewChoreRevision(action, before, after, userID)
+	if err != nil {
+		logger.Errorw("Failed to build chore revision", "error", err)
+		return
+	}
+	revision.RestoredFrom = restoredFrom
+	if err := h.choreRepo.CreateChoreRevision(c, revision); err != nil {
+		logger.Errorw("Failed to save chore revision", "error", err)
+	}
+}
+
 func (h *Handler) cleanUpUnreferencedFiles(ctx *gin.Context, userID int, entityType storageModel.EntityType, entityID int, text string) error {
 	existedFiles, err := h.storageRepo.GetFilesByUser(ctx, userID, entityType, entityID)
 	if err != nil {
@@ -628,20 +757,57 @@ func (h *Handler) cleanUpUnreferencedFiles(ctx *gin.Context, userID int, entityT
 
 func HandleThingAssociation(choreReq chModel.ChoreReq, h *Handler, c *gin.Context, currentUser *uModel.User) bool {
 	if choreReq.ThingTrigger != nil {
-		thing, err := h.tRepo.GetThingByID(c, choreReq.ThingTrigger.ID)
-		if err != nil {
-			c.JSON(500, gin.H{
-				"error": "Error getting thing",
+		if !tModel.IsValidThingAction(choreReq.ThingTrigger.Action) {
+			c.JSON(400, gin.H{
+				"error": "Invalid thing action",
 			})
 			return true
 		}
-		if thing.UserID != currentUser.ID {
-			c.JSON(403, gin.H{
-				"error": "You are not allowed to trigger this thing",
-			})
-			return true
+		thingIDs := []int{choreReq.ThingTrigger.ID}
+		if rule := choreReq.ThingTrigger.Rule; rule != nil {
+			if err := thing.ValidateRule(rule); err != nil {
+				c.JSON(400, gin.H{
+					"error": fmt.Sprintf("Invalid thing rule: %s", err),
+				})
+				return true
+			}
+			if !slices.Contains(rule.ThingIDs(), choreReq.ThingTrigger.ID) {
+				c.JSON(400, gin.H{
+					"error": "Invalid thing rule: the rule does not use the trigger thing",
+				})
+				return true
+			}
+			thingIDs = rule.ThingIDs()
 		}
-		if err := h.tRepo.AssociateThingWithChore(c, choreReq.ThingTrigger.ID, choreReq.ID, choreReq.ThingTrigger.TriggerState, choreReq.ThingTrigger.Condition); err != nil {
+		for _, thingID := range thingIDs {
+			t, err := h.tRepo.GetThingByID(c, thingID)
+			if err != nil {
+				c.JSON(500, gin.H{
+					"error": "Error getting thing",
+				})
+				return true
+			}
+			role, err := thing.UserThingRole(c, h.tRepo, t, currentUser.ID, currentUser.CircleID)
+			if err != nil {
+				c.JSON(500, gin.H{
+					"error": "Error checking access to thing",
+				})
+				return true
+			}
+			if !role.Allows(tModel.ThingRoleEditor) {
+				c.JSON(403, gin.H{
+					"error": "You are not allowed to trigger this thing",
+				})
+				return true
+			}
+		}
+		var err error
+		if choreReq.ThingTrigger.Rule != nil {
+			err = h.tRepo.AssociateThingRuleWithChore(c, choreReq.ThingTrigger.ID, choreReq.ID, choreReq.ThingTrigger.Rule, choreReq.ThingTrigger.Action)
+		} else {
+			err = h.tRepo.AssociateThingWithChore(c, choreReq.ThingTrigger.ID, choreReq.ID, choreReq.ThingTrigger.TriggerState, choreReq.ThingTrigger.Condition, choreReq.ThingTrigger.Action)
+		}
+		if err != nil {
 			c.JSON(500, gin.H{
 				"error": "Error associating thing with chore",
 			})
@@ -678,14 +844,24 @@ func (h *Handler) deleteChore(c *gin.Context) {
 		return
 	}
 
-	if err := h.choreRepo.DeleteChore(c, id); err != nil {
+	// loaded before deleting so the event can describe the chore
+	deletedChore, err := h.choreRepo.GetChore(c, id)
+	if err != nil {
+		c.JSON(500, gin.H{
+			"error": "Error getting chore",
+		})
+		return
+	}
+	// the chore goes to the trash with its history and things, it is deleted for good once it is purged
+	if err := h.choreRepo.SoftDelete(c, id, currentUser.ID); err != nil {
 		c.JSON(500, gin.H{
 			"error": "Error deleting chore",
 		})
 		return
 	}
 	h.nRepo.DeleteAllChoreNotifications(id)
-	h.tRepo.DissociateChoreWithThing(c, id)
+	h.eventProducer.ChoreDeleted(c, currentUser.WebhookURL, deletedChore, &currentUser.User)
+	h.recordRevision(c, chModel.RevisionActionDeleted, deletedChore, nil, currentUser.ID, nil)
 
 	c.JSON(200, gin.H{
 		"message": "Chore deleted successfully",
@@ -716,6 +892,110 @@ func (h *Handler) deleteChore(c *gin.Context) {
 // 	})
 // }
 
+func (h *Handler) getTrashedChores(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+
+	chores, err := h.choreRepo.GetTrashedChores(c, currentUser.CircleID, currentUser.ID)
+	if err != nil {
+		c.JSON(500, gin.H{
+			"error": "Error getting deleted chores",
+		})
+		return
+	}
+	c.JSON(200, gin.H{
+		"res": chores,
+	})
+}
+
+// restoreChore takes a chore out of the trash, as it was when it was deleted.
+func (h *Handler) restoreChore(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{
+			"error": "Invalid ID",
+		})
+		return
+	}
+
+	deletedChore, err := h.choreRepo.GetTrashedChore(c, id)
+	if err != nil || deletedChore.CreatedBy != currentUser.ID {
+		c.JSON(404, gin.H{
+			"error": "Chore not found in the trash",
+		})
+		return
+	}
+	if err := h.choreRepo.RestoreChore(c, id, currentUser.ID); err != nil {
+		c.JSON(500, gin.H{
+			"error": "Error restoring chore",
+		})
+		return
+	}
+	chore, err := h.choreRepo.GetChore(c, id)
+	if err != nil {
+		c.JSON(500, gin.H{
+			"error": "Error getting chore",
+		})
+		return
+	}
+	h.eventProducer.ChoreRestored(c, currentUser.WebhookURL, chore, &currentUser.User)
+	h.recordRevision(c, chModel.RevisionActionUndeleted, deletedChore, chore, currentUser.ID, nil)
+	go func() {
+		h.nPlanner.GenerateNotifications(c, chore)
+	}()
+
+	c.JSON(200, gin.H{
+		"res": chore,
+	})
+}
+
+// purgeChore deletes a chore in the trash for good, without waiting for the retention.
+func (h *Handler) purgeChore(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{
+			"error": "Invalid ID",
+		})
+		return
+	}
+
+	chore, err := h.choreRepo.GetTrashedChore(c, id)
+	if err != nil || chore.CreatedBy != currentUser.ID {
+		c.JSON(404, gin.H{
+			"error": "Chore not found in the trash",
+		})
+		return
+	}
+	if err := purgeChore(c, h.choreRepo, h.tRepo, id); err != nil {
+		c.JSON(500, gin.H{
+			"error": "Error deleting chore",
+		})
+		return
+	}
+	c.JSON(200, gin.H{
+		"message": "Chore deleted successfully",
+	})
+}
+
 func (h *Handler) updateAssignee(c *gin.Context) {
 	currentUser, ok := auth.CurrentUser(c)
 	if !ok {
@@ -767,6 +1047,8 @@ func (h *Handler) updateAssignee(c *gin.Context) {
 		return
 	}
 
+	before := *chore
+	previousAssignee := chore.AssignedTo
 	chore.UpdatedBy = currentUser.ID
 	chore.AssignedTo = assigneeReq.Assignee
 	if err := h.choreRepo.UpsertChore(c, chore); err != nil {
@@ -775,6 +1057,12 @@ func (h *Handler) updateAssignee(c *gin.Context) {
 		})
 		return
 	}
+	if previousAssignee != chore.AssignedTo {
+		h.eventProducer.ChoreReassigned(c, currentUser.WebhookURL, chore, previousAssignee, &currentUser.User)
+	}
+	if savedChore, err := h.choreRepo.GetChore(c, id); err == nil {
+		h.recordRevision(c, chModel.RevisionActionAssigned, &before, savedChore, currentUser.ID, nil)
+	}
 
 	c.JSON(200, gin.H{
 		"res": chore,
@@ -870,7 +1158,7 @@ func (h *Handler) skipChore(c *gin.Context) {
 	}
 
 	nextAssigedTo := chore.AssignedTo
-	if err := h.choreRepo.SkipChore(c, chore, currentUser.ID, nextDueDate, nextAssigedTo); err != nil {
+	if err := h.choreRepo.SkipChore(c, chore, currentUser.ID, nextDueDate, nextAssigedTo, nil); err != nil {
 		c.JSON(500, gin.H{
 			"error": "Error completing chore",
 		})
@@ -935,6 +1223,8 @@ func (h *Handler) updateDueDate(c *gin.Context) {
 		})
 		return
 	}
+	before := *chore
+	previousDueDate := chore.NextDueDate
 	chore.NextDueDate = &dueDate
 	chore.UpdatedBy = currentUser.ID
 	if err := h.choreRepo.UpsertChore(c, chore); err != nil {
@@ -943,6 +1233,10 @@ func (h *Handler) updateDueDate(c *gin.Context) {
 		})
 		return
 	}
+	h.eventProducer.ChoreDueDateChanged(c, currentUser.WebhookURL, chore, previousDueDate, &currentUser.User)
+	if savedChore, err := h.choreRepo.GetChore(c, id); err == nil {
+		h.recordRevision(c, chModel.RevisionActionDueDateChanged, &before, savedChore, currentUser.ID, nil)
+	}
 
 	c.JSON(200, gin.H{
 		"res": chore,
@@ -966,6 +1260,14 @@ func (h *Handler) archiveChore(c *gin.Context) {
 		return
 	}
 
+	// read before archiving for the revision
+	before, err := h.choreRepo.GetChore(c, id)
+	if err != nil {
+		c.JSON(500, gin.H{
+			"error": "Error getting chore",
+		})
+		return
+	}
 	err = h.choreRepo.ArchiveChore(c, id, currentUser.ID)
 
 	if err != nil {
@@ -974,6 +1276,11 @@ func (h *Handler) archiveChore(c *gin.Context) {
 		})
 		return
 	}
+	// archiving is a no-op for chores the user doesn't own
+	if chore, err := h.choreRepo.GetChore(c, id); err == nil && chore.CreatedBy == currentUser.ID {
+		h.eventProducer.ChoreArchived(c, currentUser.WebhookURL, chore, &currentUser.User)
+		h.recordRevision(c, chModel.RevisionActionArchived, before, chore, currentUser.ID, nil)
+	}
 
 	c.JSON(200, gin.H{
 		"message": "Chore archived successfully",
@@ -998,6 +1305,13 @@ func (h *Handler) UnarchiveChore(c *gin.Context) {
 		return
 	}
 
+	before, err := h.choreRepo.GetChore(c, id)
+	if err != nil {
+		c.JSON(500, gin.H{
+			"error": "Error getting chore",
+		})
+		return
+	}
 	err = h.choreRepo.UnarchiveChore(c, id, currentUser.ID)
 
 	if err != nil {
@@ -1006,6 +1320,10 @@ func (h *Handler) UnarchiveChore(c *gin.Context) {
 		})
 		return
 	}
+	if chore, err := h.choreRepo.GetChore(c, id); err == nil && chore.CreatedBy == currentUser.ID {
+		h.eventProducer.ChoreUnarchived(c, currentUser.WebhookURL, chore, &currentUser.User)
+		h.recordRevision(c, chModel.RevisionActionUnarchived, before, chore, currentUser.ID, nil)
+	}
 
 	c.JSON(200, gin.H{
 		"message": "Chore unarchived successfully",
@@ -1136,7 +1454,7 @@ func (h *Handler) completeChore(c *gin.Context) {
 		return
 	}
 
-	if err := h.choreRepo.CompleteChore(c, chore, additionalNotes, completedBy, nextDueDate, &completedDate, nextAssignedTo, true); err != nil {
+	if err := h.choreRepo.CompleteChore(c, chore, additionalNotes, completedBy, nextDueDate, &completedDate, nextAssignedTo, true, nil); err != nil {
 		c.JSON(500, gin.H{
 			"error": "Error completing chore",
 		})
@@ -1218,6 +1536,110 @@ func (h *Handler) GetChoreHistory(c *gin.Context) {
 	})
 }
 
+// GetChoreRevisions returns the timeline of a chore, its revisions with what each changed, the latest first.
+func (h *Handler) GetChoreRevisions(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{
+			"error": "Invalid ID",
+		})
+		return
+	}
+
+	chore, err := h.choreRepo.GetChore(c, id)
+	if err != nil {
+		c.JSON(500, gin.H{
+			"error": "Error getting chore",
+		})
+		return
+	}
+	if chore.CreatedBy != currentUser.ID && !chore.CanComplete(currentUser.ID) {
+		c.JSON(403, gin.H{
+			"error": "You are not allowed to view this chore",
+		})
+		return
+	}
+
+	revisions, err := h.choreRepo.GetChoreRevisions(c, id)
+	if err != nil {
+		c.JSON(500, gin.H{
+			"error": "Error getting chore revisions",
+		})
+		return
+	}
+	c.JSON(200, gin.H{
+		"res": revisions,
+	})
+}
+
+// RestoreChoreRevision edits the chore back into what it was at a revision. The things it is associated with are
+// kept as they are.
+func (h *Handler) RestoreChoreRevision(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{
+			"error": "Invalid ID",
+		})
+		return
+	}
+	revisionID, err := strconv.Atoi(c.Param("revision_id"))
+	if err != nil {
+		c.JSON(400, gin.H{
+			"error": "Invalid revision ID",
+		})
+		return
+	}
+
+	chore, err := h.choreRepo.GetChore(c, id)
+	if err != nil || chore.CircleID != currentUser.CircleID {
+		c.JSON(404, gin.H{
+			"error": "Chore not found",
+		})
+		return
+	}
+	revision, err := h.choreRepo.GetChoreRevision(c, id, revisionID)
+	if err != nil {
+		c.JSON(404, gin.H{
+			"error": "Revision not found",
+		})
+		return
+	}
+	choreReq, err := revision.Req()
+	if err != nil {
+		c.JSON(500, gin.H{
+			"error": "Error reading revision",
+		})
+		return
+	}
+	choreReq.ID = chore.ID
+	// restoring is not an edit of an older version, nothing is merged
+	choreReq.UpdatedAt = &chore.UpdatedAt
+	if chore.ThingChore != nil {
+		choreReq.ThingTrigger = &tModel.ThingTrigger{
+			ID:           chore.ThingChore.ThingID,
+			TriggerState: chore.ThingChore.TriggerState,
+			Condition:    chore.ThingChore.Condition,
+			Rule:         chore.ThingChore.Rule,
+			Action:       chore.ThingChore.Action,
+		}
+	}
+	h.updateChore(c, currentUser, *choreReq, &revision.ID)
+}
+
 func (h *Handler) GetChoreDetail(c *gin.Context) {
 
 	currentUser, ok := auth.CurrentUser(c)
@@ -1357,12 +1779,27 @@ func (h *Handler) updatePriority(c *gin.Context) {
 		return
 	}
 
+	chore, err := h.choreRepo.GetChore(c, id)
+	if err != nil {
+		c.JSON(500, gin.H{
+			"error": "Error getting chore",
+		})
+		return
+	}
 	if err := h.choreRepo.UpdateChorePriority(c, currentUser.ID, id, *priorityReq.Priority); err != nil {
 		c.JSON(500, gin.H{
 			"error": "Error updating priority",
 		})
 		return
 	}
+	// only the owner can change the priority, the updatedAt changes even when the priority is the same
+	if savedChore, err := h.choreRepo.GetChore(c, id); err == nil && !savedChore.UpdatedAt.Equal(chore.UpdatedAt) {
+		h.recordRevision(c, chModel.RevisionActionPriorityChanged, chore, savedChore, currentUser.ID, nil)
+	}
+	if previousPriority := chore.Priority; previousPriority != *priorityReq.Priority {
+		chore.Priority = *priorityReq.Priority
+		h.eventProducer.ChorePriorityChanged(c, currentUser.WebhookURL, chore, previousPriority, &currentUser.User)
+	}
 
 	c.JSON(200, gin.H{
 		"message": "Priority updated successfully",
@@ -1521,7 +1958,7 @@ func (h *Handler) UpdateSubtaskCompletedAt(c *gin.Context) {
 	}
 	// h.choreRepo.setStatus(c, choreID, chModel.ChoreStatusInProgress, currentUser.ID)
 
-	h.eventProducer.SubtaskUpdated(c, currentUser.WebhookURL,
+	h.eventProducer.SubtaskUpdated(c, currentUser.CircleID, req.ChoreID, currentUser.WebhookURL,
 		&stModel.SubTask{
 			ID:          req.ID,
 			ChoreID:     req.ChoreID,
@@ -1663,7 +2100,10 @@ func Routes(router *gin.Engine, h *Handler, auth *jwt.GinJWTMiddleware) {
 	{
 		choresRoutes.GET("/", h.getChores)
 		choresRoutes.GET("/archived", h.getArchivedChores)
+		choresRoutes.GET("/trash", h.getTrashedChores)
+		choresRoutes.DELETE("/trash/:id", h.purgeChore)
 		choresRoutes.GET("/history", h.getChoresHistory)
+		choresRoutes.GET("/forecast", h.getForecast)
 		choresRoutes.PUT("/", h.editChore)
 		choresRoutes.PUT("/:id/priority", h.updatePriority)
 		choresRoutes.POST("/", h.createChore)
@@ -1671,6 +2111,8 @@ func Routes(router *gin.Engine, h *Handler, auth *jwt.GinJWTMiddleware) {
 		choresRoutes.PUT("/:id/subtask", h.UpdateSubtaskCompletedAt)
 		choresRoutes.GET("/:id/details", h.GetChoreDetail)
 		choresRoutes.GET("/:id/history", h.GetChoreHistory)
+		choresRoutes.GET("/:id/revisions", h.GetChoreRevisions)
+		choresRoutes.POST("/:id/revisions/:revision_id/restore", h.RestoreChoreRevision)
 		choresRoutes.PUT("/:id/history/:history_id", h.ModifyHistory)
 		choresRoutes.DELETE("/:id/history/:history_id", h.DeleteHistory)
 		choresRoutes.POST("/:id/do", h.completeChore)
@@ -1681,6 +2123,7 @@ func Routes(router *gin.Engine, h *Handler, auth *jwt.GinJWTMiddleware) {
 		choresRoutes.PUT("/:id/archive", h.archiveChore)
 		choresRoutes.PUT("/:id/unarchive", h.UnarchiveChore)
 		choresRoutes.DELETE("/:id", h.deleteChore)
+		choresRoutes.POST("/:id/restore", h.restoreChore)
 	}
 
 }
@@ -10,6 +10,7 @@ import (
 	lModel "donetick.com/core/internal/label/model"
 	stModel "donetick.com/core/internal/subtask/model"
 	tModel "donetick.com/core/internal/thing/model"
+	"gorm.io/gorm"
 )
 
 type FrequencyType string
@@ -26,6 +27,7 @@ const (
 	FrequencyTypeDayOfTheMonth FrequencyType = "day_of_the_month"
 	FrequencyTypeTrigger       FrequencyType = "trigger"
 	FrequencyTypeNoRepeat      FrequencyType = "no_repeat"
+	FrequencyTypeRRule         FrequencyType = "rrule"
 )
 
 type AssignmentStrategy string
@@ -69,6 +71,8 @@ type Chore struct {
 	Points                 *int                  `json:"points,omitempty" gorm:"column:points"`                      // Points for completing the chore
 	Description            *string               `json:"description,omitempty" gorm:"type:text;column:description"`  // Description of the chore
 	SubTasks               *[]stModel.SubTask    `json:"subTasks,omitempty" gorm:"foreignkey:ChoreID;references:ID"` // Subtasks for the chore
+	DeletedAt              gorm.DeletedAt        `json:"deletedAt,omitempty" gorm:"column:deleted_at;index"`         // When the chore was moved to the trash
+	CreatorTimezone        string                `json:"-" gorm:"-"`                                                 // Timezone of the creator, loaded with the chore
 
 }
 
@@ -96,6 +100,8 @@ type ChoreHistory struct {
 	UpdatedAt   *time.Time         `json:"updatedAt" gorm:"column:updated_at"`     // When the record was last updated
 	Status      ChoreHistoryStatus `json:"status" gorm:"column:status"`            // Status of the chore (1=completed, 2=skipped)
 	Points      *int               `json:"points,omitempty" gorm:"column:points"`  // Points for completing the chore
+	// the thing whose trigger completed or skipped the chore, nil when a user did
+	SourceThingID *int `json:"sourceThingId,omitempty" gorm:"column:source_thing_id"`
 }
 type ChoreHistoryStatus int8
 
@@ -111,6 +117,7 @@ type FrequencyMetadata struct {
 	Unit     *string   `json:"unit,omitempty"`
 	Time     string    `json:"time,omitempty"`
 	Timezone string    `json:"timezone,omitempty"`
+	RRule    string    `json:"rrule,omitempty"` // RFC 5545 recurrence rule, only used by the rrule frequency type
 }
 
 type NotificationMetadata struct {
@@ -120,6 +127,8 @@ type NotificationMetadata struct {
 	PreDue        bool   `json:"predue,omitempty"`
 	CircleGroup   bool   `json:"circleGroup,omitempty"`
 	CircleGroupID *int64 `json:"circleGroupID,omitempty"`
+	// Matrix room IDs are not numeric so they can't share CircleGroupID with Telegram chats
+	CircleGroupRoomID string `json:"circleGroupRoomID,omitempty"`
 }
 
 type Tag struct {
@@ -184,9 +193,10 @@ type ChoreReq struct {
 	UpdatedAt            *time.Time            `json:"updatedAt,omitempty"` // For internal use only when syncing a chore updated offline
 }
 
-func (c *Chore) CanEdit(userID int, circleUsers []*cModel.UserCircleDetail, updatedAt *time.Time) error {
+// CanEdit checks the user is allowed to edit the chore. An edit made on an older version of the chore is
+// merged with MergeChoreReq.
+func (c *Chore) CanEdit(userID int, circleUsers []*cModel.UserCircleDetail) error {
 	userHasPermission := false
-	choreCanModified := true
 	if c.CreatedBy == userID {
 		userHasPermission = true
 	}
@@ -196,23 +206,26 @@ func (c *Chore) CanEdit(userID int, circleUsers []*cModel.UserCircleDetail, upda
 			break
 		}
 	}
-	if updatedAt != nil {
-		// if the chore was updated after the user fetched it for editing, then do not allow editing
-		if c.UpdatedAt.After(*updatedAt) {
-			// this means the chore was modified by someone
-			choreCanModified = false
-		}
-	}
 	if !userHasPermission {
 		return errors.New("user does not have permission to edit this chore")
 	}
-	if !choreCanModified {
-		return errors.New("chore has been modified by another user, please refresh and try again")
-	}
 	return nil
 
 }
 
+// CanView tells if the user can see the chore, only its creator and its assignees can.
+func (c *Chore) CanView(userID int) bool {
+	if c.CreatedBy == userID {
+		return true
+	}
+	for _, a := range c.Assignees {
+		if a.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Chore) CanComplete(userID int) bool {
 	if c.AssignedTo == userID {
 		return true
@@ -0,0 +1,284 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	lModel "donetick.com/core/internal/label/model"
+	stModel "donetick.com/core/internal/subtask/model"
+)
+
+type RevisionAction string
+
+const (
+	RevisionActionCreated         RevisionAction = "created"
+	RevisionActionEdited          RevisionAction = "edited"
+	RevisionActionAssigned        RevisionAction = "assigned"
+	RevisionActionDueDateChanged  RevisionAction = "due_date_changed"
+	RevisionActionPriorityChanged RevisionAction = "priority_changed"
+	RevisionActionArchived        RevisionAction = "archived"
+	RevisionActionUnarchived      RevisionAction = "unarchived"
+	RevisionActionDeleted         RevisionAction = "deleted"   // moved to the trash
+	RevisionActionRestored        RevisionAction = "restored"  // to an older revision
+	RevisionActionUndeleted       RevisionAction = "undeleted" // restored from the trash
+	RevisionActionCompleted       RevisionAction = "completed"
+	RevisionActionSkipped         RevisionAction = "skipped"
+	RevisionActionStatusChanged   RevisionAction = "status_changed"
+)
+
+// ChoreRevision is the chore as it was at ChoreUpdatedAt, after a change made to it. One is stored every time
+// the updatedAt of a chore changes, they are the timeline of the chore and an edit made on an older version of
+// the chore is merged with what changed since that version.
+type ChoreRevision struct {
+	ID             int            `json:"id" gorm:"primary_key"`
+	ChoreID        int            `json:"choreId" gorm:"column:chore_id;index:idx_chore_revisions_chore"`
+	ChoreUpdatedAt time.Time      `json:"choreUpdatedAt" gorm:"column:chore_updated_at;index:idx_chore_revisions_chore"` // updatedAt of the chore in Snapshot
+	Action         RevisionAction `json:"action" gorm:"column:action"`
+	Changes        FieldChanges   `json:"changes" gorm:"column:changes;type:json"`            // from the revision before
+	Snapshot       string         `json:"-" gorm:"column:snapshot;type:text"`                 // the chore as a ChoreReq in JSON
+	RestoredFrom   *int           `json:"restoredFrom,omitempty" gorm:"column:restored_from"` // revision a restore went back to
+	CreatedBy      int            `json:"createdBy" gorm:"column:created_by"`
+	CreatedAt      time.Time      `json:"createdAt" gorm:"column:created_at"`
+}
+
+// FieldChange is a field of ChoreReq that a change of a chore set from one value to another.
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+type FieldChanges []FieldChange
+
+func (f FieldChanges) Value() (driver.Value, error) {
+	return json.Marshal(f)
+}
+
+func (f *FieldChanges) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(bytes, f)
+}
+
+// NewChoreRevision returns the revision of a change by the user of the chore before into after. Before is nil
+// when the chore was created and after when it was deleted.
+func NewChoreRevision(action RevisionAction, before *Chore, after *Chore, userID int) (*ChoreRevision, error) {
+	revision := &ChoreRevision{
+		Action:    action,
+		Changes:   FieldChanges{},
+		CreatedBy: userID,
+		CreatedAt: time.Now().UTC(),
+	}
+	chore := after
+	if after == nil {
+		chore = before
+	} else if before != nil {
+		revision.Changes = DiffChoreReq(NewChoreReq(before), NewChoreReq(after))
+	}
+	snapshot, err := json.Marshal(NewChoreReq(chore))
+	if err != nil {
+		return nil, err
+	}
+	revision.ChoreID = chore.ID
+	revision.ChoreUpdatedAt = chore.UpdatedAt
+	revision.Snapshot = string(snapshot)
+	return revision, nil
+}
+
+func (r *ChoreRevision) Req() (*ChoreReq, error) {
+	var req ChoreReq
+	if err := json.Unmarshal([]byte(r.Snapshot), &req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// NewChoreReq returns the request that would edit a chore into the chore as it is.
+func NewChoreReq(chore *Chore) *ChoreReq {
+	req := &ChoreReq{
+		ID:                   chore.ID,
+		Name:                 chore.Name,
+		FrequencyType:        chore.FrequencyType,
+		Assignees:            chore.Assignees,
+		AssignStrategy:       chore.AssignStrategy,
+		AssignedTo:           chore.AssignedTo,
+		IsRolling:            chore.IsRolling,
+		IsActive:             chore.IsActive,
+		Frequency:            chore.Frequency,
+		FrequencyMetadata:    chore.FrequencyMetadataV2,
+		Notification:         chore.Notification,
+		NotificationMetadata: chore.NotificationMetadataV2,
+		Points:               chore.Points,
+		CompletionWindow:     chore.CompletionWindow,
+		Description:          chore.Description,
+		Priority:             chore.Priority,
+		SubTasks:             chore.SubTasks,
+		UpdatedAt:            &chore.UpdatedAt,
+	}
+	if chore.NextDueDate != nil {
+		req.DueDate = chore.NextDueDate.UTC().Format(time.RFC3339)
+	}
+	labels := []lModel.LabelReq{}
+	if chore.LabelsV2 != nil {
+		for _, label := range *chore.LabelsV2 {
+			labels = append(labels, lModel.LabelReq{LabelID: label.ID})
+		}
+	}
+	req.LabelsV2 = &labels
+	return req
+}
+
+// FieldConflict is a field of a chore that an edit changed and that someone else changed to something else
+// after the version the edit was made on. Field is the name of the field in ChoreReq.
+type FieldConflict struct {
+	Field  string      `json:"field"`
+	Base   interface{} `json:"base"`
+	Yours  interface{} `json:"yours"`
+	Theirs interface{} `json:"theirs"`
+}
+
+// unmergedFields are the json names of the ChoreReq fields that are not merged, they are taken from the edit.
+// The thing trigger is left out because the association is replaced on every edit.
+var unmergedFields = map[string]bool{
+	"id":           true,
+	"labels":       true, // deprecated in favor of labelsV2
+	"thingTrigger": true,
+	"updatedAt":    true,
+}
+
+// MergeChoreReq merges the edit yours, made on the version base of a chore, with theirs, the chore as it is
+// now. A field changed only by the edit or only by someone else keeps that change, a field both changed to
+// different values is a conflict and keeps the value of the edit. Without a base every field yours and theirs
+// disagree on is a conflict.
+func MergeChoreReq(base *ChoreReq, theirs *ChoreReq, yours *ChoreReq) (ChoreReq, []FieldConflict) {
+	merged := *yours
+	conflicts := []FieldConflict{}
+
+	normalizedTheirs, normalizedYours := normalizeChoreReq(theirs), normalizeChoreReq(yours)
+	var normalizedBase ChoreReq
+	if base != nil {
+		normalizedBase = normalizeChoreReq(base)
+	}
+	for i, name := range mergedFields() {
+		if name == "" {
+			continue
+		}
+		theirsKey := fieldKey(normalizedTheirs, i)
+		yoursKey := fieldKey(normalizedYours, i)
+		if theirsKey == yoursKey {
+			continue
+		}
+		if base != nil {
+			baseKey := fieldKey(normalizedBase, i)
+			if yoursKey == baseKey {
+				reflect.ValueOf(&merged).Elem().Field(i).Set(reflect.ValueOf(theirs).Elem().Field(i))
+				continue
+			}
+			if theirsKey == baseKey {
+				continue
+			}
+		}
+		conflict := FieldConflict{
+			Field:  name,
+			Yours:  reflect.ValueOf(yours).Elem().Field(i).Interface(),
+			Theirs: reflect.ValueOf(theirs).Elem().Field(i).Interface(),
+		}
+		if base != nil {
+			conflict.Base = reflect.ValueOf(base).Elem().Field(i).Interface()
+		}
+		conflicts = append(conflicts, conflict)
+	}
+	return merged, conflicts
+}
+
+// DiffChoreReq returns the fields that are different in to than in from.
+func DiffChoreReq(from *ChoreReq, to *ChoreReq) FieldChanges {
+	changes := FieldChanges{}
+	normalizedFrom, normalizedTo := normalizeChoreReq(from), normalizeChoreReq(to)
+	for i, name := range mergedFields() {
+		if name == "" || fieldKey(normalizedFrom, i) == fieldKey(normalizedTo, i) {
+			continue
+		}
+		changes = append(changes, FieldChange{
+			Field: name,
+			From:  reflect.ValueOf(from).Elem().Field(i).Interface(),
+			To:    reflect.ValueOf(to).Elem().Field(i).Interface(),
+		})
+	}
+	return changes
+}
+
+// mergedFields returns the json names of the fields of ChoreReq by index, empty for the fields that are not
+// merged.
+func mergedFields() []string {
+	reqType := reflect.TypeOf(ChoreReq{})
+	names := make([]string, reqType.NumField())
+	for i := range names {
+		name := strings.Split(reqType.Field(i).Tag.Get("json"), ",")[0]
+		if !unmergedFields[name] {
+			names[i] = name
+		}
+	}
+	return names
+}
+
+func fieldKey(req ChoreReq, i int) string {
+	key, _ := json.Marshal(reflect.ValueOf(req).Field(i).Interface())
+	return string(key)
+}
+
+// normalizeChoreReq returns a copy of the request with the fields that can be sent in more than one way for
+// the same chore written the same way, so they can be compared.
+func normalizeChoreReq(req *ChoreReq) ChoreReq {
+	normalized := *req
+	if dueDate, err := time.Parse(time.RFC3339, req.DueDate); err == nil {
+		normalized.DueDate = dueDate.UTC().Format(time.RFC3339)
+	}
+
+	assignees := make([]ChoreAssignees, 0, len(req.Assignees))
+	for _, assignee := range req.Assignees {
+		assignees = append(assignees, ChoreAssignees{UserID: assignee.UserID})
+	}
+	sort.Slice(assignees, func(i, j int) bool { return assignees[i].UserID < assignees[j].UserID })
+	normalized.Assignees = assignees
+
+	labels := []lModel.LabelReq{}
+	if req.LabelsV2 != nil {
+		labels = append(labels, *req.LabelsV2...)
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i].LabelID < labels[j].LabelID })
+	normalized.LabelsV2 = &labels
+
+	if req.Description == nil {
+		empty := ""
+		normalized.Description = &empty
+	}
+
+	// only what an edit can change of a subtask
+	subtasks := []stModel.SubTask{}
+	if req.SubTasks != nil {
+		for _, subtask := range *req.SubTasks {
+			subtasks = append(subtasks, stModel.SubTask{
+				ID:       subtask.ID,
+				OrderID:  subtask.OrderID,
+				Name:     subtask.Name,
+				ParentId: subtask.ParentId,
+			})
+		}
+	}
+	sort.Slice(subtasks, func(i, j int) bool { return subtasks[i].ID < subtasks[j].ID })
+	normalized.SubTasks = &subtasks
+	return normalized
+}
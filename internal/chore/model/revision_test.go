@@ -0,0 +1,107 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	lModel "donetick.com/core/internal/label/model"
+)
+
+func TestMergeChoreReq(t *testing.T) {
+	description := "Take out the trash"
+	updatedAt := time.Date(2026, 10, 17, 8, 0, 0, 0, time.UTC)
+	chore := &Chore{
+		ID:             7,
+		Name:           "Trash",
+		FrequencyType:  FrequencyTypeWeekly,
+		Frequency:      1,
+		NextDueDate:    &updatedAt,
+		AssignedTo:     1,
+		Assignees:      []ChoreAssignees{{ID: 3, ChoreID: 7, UserID: 2}, {ID: 2, ChoreID: 7, UserID: 1}},
+		AssignStrategy: AssignmentStrategyRoundRobin,
+		IsActive:       true,
+		LabelsV2:       &[]Label{{ID: 4, Name: "home"}},
+		Description:    &description,
+		UpdatedAt:      updatedAt,
+	}
+	base := NewChoreReq(chore)
+
+	theirs := NewChoreReq(chore)
+	theirs.Priority = 2
+	theirs.Frequency = 2
+
+	yours := NewChoreReq(chore)
+	yours.Name = "Take out the trash"
+	yours.DueDate = "2026-10-17T10:00:00+02:00" // the same due date in another zone
+	yours.Assignees = []ChoreAssignees{{UserID: 1}, {UserID: 2}}
+	yours.LabelsV2 = &[]lModel.LabelReq{{LabelID: 4}}
+
+	merged, conflicts := MergeChoreReq(base, theirs, yours)
+	if len(conflicts) != 0 {
+		t.Fatalf("conflicts = %+v, want none", conflicts)
+	}
+	if merged.Name != "Take out the trash" || merged.Priority != 2 || merged.Frequency != 2 {
+		t.Errorf("merged = %+v, want the name of the edit and the priority and frequency changed on the server", merged)
+	}
+
+	yours.Frequency = 3
+	yours.Description = nil
+	theirs.Description = nil
+	_, conflicts = MergeChoreReq(base, theirs, yours)
+	if len(conflicts) != 1 {
+		t.Fatalf("conflicts = %+v, want the frequency", conflicts)
+	}
+	if conflicts[0].Field != "frequency" || conflicts[0].Base != 1 || conflicts[0].Yours != 3 || conflicts[0].Theirs != 2 {
+		t.Errorf("conflict = %+v, want frequency 1, 3 and 2", conflicts[0])
+	}
+
+	// without the version the edit was made on, every difference is a conflict
+	_, conflicts = MergeChoreReq(nil, theirs, yours)
+	fields := map[string]bool{}
+	for _, conflict := range conflicts {
+		fields[conflict.Field] = true
+	}
+	if len(conflicts) != 3 || !fields["name"] || !fields["frequency"] || !fields["priority"] {
+		t.Errorf("conflicts without a base = %+v, want name, frequency and priority", conflicts)
+	}
+}
+
+func TestNewChoreRevision(t *testing.T) {
+	updatedAt := time.Date(2026, 10, 17, 8, 0, 0, 0, time.UTC)
+	before := &Chore{ID: 7, Name: "Trash", Frequency: 1, Priority: 1, AssignedTo: 1, IsActive: true, UpdatedAt: updatedAt}
+	after := *before
+	after.Priority = 3
+	after.AssignedTo = 2
+	after.UpdatedAt = updatedAt.Add(time.Minute)
+
+	revision, err := NewChoreRevision(RevisionActionEdited, before, &after, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revision.ChoreID != 7 || !revision.ChoreUpdatedAt.Equal(after.UpdatedAt) {
+		t.Errorf("revision = %+v, want the chore after the change", revision)
+	}
+	changes := map[string]FieldChange{}
+	for _, change := range revision.Changes {
+		changes[change.Field] = change
+	}
+	if len(changes) != 2 || changes["priority"].From != 1 || changes["priority"].To != 3 || changes["assignedTo"].To != 2 {
+		t.Errorf("changes = %+v, want the priority and the assignee", revision.Changes)
+	}
+	snapshot, err := revision.Req()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Priority != 3 || snapshot.Name != "Trash" {
+		t.Errorf("snapshot = %+v, want the chore after the change", snapshot)
+	}
+
+	// a deleted chore keeps the chore as it was
+	revision, err = NewChoreRevision(RevisionActionDeleted, &after, nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revision.Changes) != 0 || revision.ChoreID != 7 {
+		t.Errorf("deleted revision = %+v, want the chore without changes", revision)
+	}
+}
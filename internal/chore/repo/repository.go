@@ -9,8 +9,10 @@ import (
 	config "donetick.com/core/config"
 	chModel "donetick.com/core/internal/chore/model"
 	cModel "donetick.com/core/internal/circle/model"
+	oModel "donetick.com/core/internal/offline/model"
 	storageModel "donetick.com/core/internal/storage/model"
 	stModel "donetick.com/core/internal/subtask/model"
+	tModel "donetick.com/core/internal/thing/model"
 	"gorm.io/gorm"
 )
 
@@ -35,7 +37,17 @@ func (r *ChoreRepository) UpdateChorePriority(c context.Context, userID int, cho
 	return nil
 }
 func (r *ChoreRepository) UpdateChores(c context.Context, chores []*chModel.Chore) error {
-	return r.db.WithContext(c).Save(&chores).Error
+	return r.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&chores).Error; err != nil {
+			return err
+		}
+		for _, chore := range chores {
+			if err := saveRevision(tx, chore.ID, chModel.RevisionActionAssigned, chore.UpdatedBy); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 func (r *ChoreRepository) CreateChore(c context.Context, chore *chModel.Chore) (int, error) {
 	if err := r.db.WithContext(c).Create(chore).Error; err != nil {
@@ -46,7 +58,10 @@ func (r *ChoreRepository) CreateChore(c context.Context, chore *chModel.Chore) (
 
 func (r *ChoreRepository) GetChore(c context.Context, choreID int) (*chModel.Chore, error) {
 	var chore chModel.Chore
-	if err := r.db.Debug().WithContext(c).Model(&chModel.Chore{}).Preload("SubTasks").Preload("Assignees").Preload("ThingChore").Preload("LabelsV2").First(&chore, choreID).Error; err != nil {
+	if err := r.db.Debug().WithContext(c).Model(&chModel.Chore{}).Preload("SubTasks").Preload("Assignees").Preload("ThingChore", "condition IS NULL OR condition <> ?", tModel.ThingChoreConditionRuleRef).Preload("LabelsV2").First(&chore, choreID).Error; err != nil {
+		return nil, err
+	}
+	if err := r.loadCreatorTimezones(c, []*chModel.Chore{&chore}); err != nil {
 		return nil, err
 	}
 	return &chore, nil
@@ -61,9 +76,50 @@ func (r *ChoreRepository) GetChores(c context.Context, circleID int, userID int,
 	if err := query.Find(&chores, "circle_id = ?", circleID).Error; err != nil {
 		return nil, err
 	}
+	if err := r.loadCreatorTimezones(c, chores); err != nil {
+		return nil, err
+	}
 	return chores, nil
 }
 
+func (r *ChoreRepository) GetActiveChoresByCircleID(c context.Context, circleID int) ([]*chModel.Chore, error) {
+	var chores []*chModel.Chore
+	if err := r.db.WithContext(c).Preload("Assignees").Preload("LabelsV2").Where("circle_id = ? AND is_active = ?", circleID, true).Order("next_due_date asc").Find(&chores).Error; err != nil {
+		return nil, err
+	}
+	if err := r.loadCreatorTimezones(c, chores); err != nil {
+		return nil, err
+	}
+	return chores, nil
+}
+
+// loadCreatorTimezones sets the timezone of the creator on the chores, the recurrence of chores without a
+// timezone of their own is calculated in it.
+func (r *ChoreRepository) loadCreatorTimezones(c context.Context, chores []*chModel.Chore) error {
+	if len(chores) == 0 {
+		return nil
+	}
+	creatorIDs := make([]int, 0, len(chores))
+	for _, chore := range chores {
+		creatorIDs = append(creatorIDs, chore.CreatedBy)
+	}
+	var creators []struct {
+		ID       int
+		Timezone string
+	}
+	if err := r.db.WithContext(c).Table("users").Select("id, timezone").Where("id IN ?", creatorIDs).Scan(&creators).Error; err != nil {
+		return err
+	}
+	timezones := make(map[int]string, len(creators))
+	for _, creator := range creators {
+		timezones[creator.ID] = creator.Timezone
+	}
+	for _, chore := range chores {
+		chore.CreatorTimezone = timezones[chore.CreatedBy]
+	}
+	return nil
+}
+
 func (r *ChoreRepository) GetArchivedChores(c context.Context, circleID int, userID int) ([]*chModel.Chore, error) {
 	var chores []*chModel.Chore
 	if err := r.db.WithContext(c).Preload("Assignees").Preload("LabelsV2").Joins("left join chore_assignees on chores.id = chore_assignees.chore_id").Where("chores.circle_id = ? AND (chores.created_by = ? OR chore_assignees.user_id = ?)", circleID, userID, userID).Group("chores.id").Order("next_due_date asc").Find(&chores, "circle_id = ? AND is_active = ?", circleID, false).Error; err != nil {
@@ -71,15 +127,83 @@ func (r *ChoreRepository) GetArchivedChores(c context.Context, circleID int, use
 	}
 	return chores, nil
 }
+
+// GetVisibleChoreIDs returns the ids of the chores of the circle the user created or is assigned to, archived ones included.
+func (r *ChoreRepository) GetVisibleChoreIDs(c context.Context, circleID int, userID int) ([]int, error) {
+	ids := []int{}
+	if err := r.db.WithContext(c).Model(&chModel.Chore{}).
+		Joins("left join chore_assignees on chores.id = chore_assignees.chore_id").
+		Where("chores.circle_id = ? AND (chores.created_by = ? OR chore_assignees.user_id = ?)", circleID, userID, userID).
+		Distinct().Pluck("chores.id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// GetChoreViewers returns the ids of the users who can view the chore, its creator and its assignees, also when
+// the chore is in the trash.
+func (r *ChoreRepository) GetChoreViewers(c context.Context, choreID int) ([]int, error) {
+	var chore chModel.Chore
+	if err := r.db.WithContext(c).Unscoped().Preload("Assignees").Select("id", "created_by").First(&chore, choreID).Error; err != nil {
+		return nil, err
+	}
+	viewers := []int{chore.CreatedBy}
+	for _, assignee := range chore.Assignees {
+		viewers = append(viewers, assignee.UserID)
+	}
+	return viewers, nil
+}
+
+// GetChoresUpdatedSince returns the chores with the ids that changed after since, all of them when since is nil.
+func (r *ChoreRepository) GetChoresUpdatedSince(c context.Context, choreIDs []int, since *time.Time) ([]*chModel.Chore, error) {
+	chores := []*chModel.Chore{}
+	if len(choreIDs) == 0 {
+		return chores, nil
+	}
+	query := r.db.WithContext(c).Preload("Assignees").Preload("LabelsV2").Where("id IN (?)", choreIDs)
+	if since != nil {
+		query = query.Where("updated_at > ?", *since)
+	}
+	if err := query.Order("id asc").Find(&chores).Error; err != nil {
+		return nil, err
+	}
+	return chores, nil
+}
+
+// GetChoresHistoryUpdatedSince returns the history of the chores with the ids that changed after since, all of it
+// when since is nil.
+func (r *ChoreRepository) GetChoresHistoryUpdatedSince(c context.Context, choreIDs []int, since *time.Time) ([]*chModel.ChoreHistory, error) {
+	history := []*chModel.ChoreHistory{}
+	if len(choreIDs) == 0 {
+		return history, nil
+	}
+	query := r.db.WithContext(c).Where("chore_id IN (?)", choreIDs)
+	if since != nil {
+		query = query.Where("updated_at > ?", *since)
+	}
+	if err := query.Order("id asc").Find(&history).Error; err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// DeleteChore deletes the chore for good, with its history, also when it is in the trash.
 func (r *ChoreRepository) DeleteChore(c context.Context, id int) error {
 	return r.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		var chore chModel.Chore
+		if err := tx.Unscoped().Select("id", "circle_id").First(&chore, id).Error; err != nil {
+			return err
+		}
 		if err := tx.Where("chore_id = ?", id).Delete(&chModel.ChoreAssignees{}).Error; err != nil {
 			return err
 		}
 		if err := tx.Delete(&chModel.ChoreHistory{}, "chore_id = ?", id).Error; err != nil {
 			return err
 		}
-		if err := tx.Delete(&chModel.Chore{}, id).Error; err != nil {
+		if err := tx.Unscoped().Delete(&chModel.Chore{}, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("chore_id = ?", id).Delete(&chModel.ChoreRevision{}).Error; err != nil {
 			return err
 		}
 		// Delete all subtasks associated with the chore
@@ -91,13 +215,83 @@ func (r *ChoreRepository) DeleteChore(c context.Context, id int) error {
 			return err
 		}
 
-		return nil
+		return tx.Create(&oModel.Tombstone{
+			EntityType: oModel.EntityTypeChore,
+			EntityID:   id,
+			CircleID:   &chore.CircleID,
+			DeletedAt:  time.Now().UTC(),
+		}).Error
 	})
 }
 
+// SoftDelete moves a chore of the user to the trash, everything of it is kept until it is purged.
 func (r *ChoreRepository) SoftDelete(c context.Context, id int, userID int) error {
-	return r.db.WithContext(c).Model(&chModel.Chore{}).Where("id = ?", id).Where("created_by = ? ", userID).Update("is_active", false).Error
+	return r.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		var chore chModel.Chore
+		if err := tx.Select("id", "circle_id").Where("created_by = ?", userID).First(&chore, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&chModel.Chore{}, id).Error; err != nil {
+			return err
+		}
+		// offline clients drop it, RestoreChore removes the tombstone so they get it again
+		return tx.Create(&oModel.Tombstone{
+			EntityType: oModel.EntityTypeChore,
+			EntityID:   id,
+			CircleID:   &chore.CircleID,
+			DeletedAt:  time.Now().UTC(),
+		}).Error
+	})
+}
 
+// RestoreChore takes a chore of the user out of the trash. Its tombstone is deleted so a delta sync sends
+// the chore again instead of telling clients it is deleted.
+func (r *ChoreRepository) RestoreChore(c context.Context, id int, userID int) error {
+	return r.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Model(&chModel.Chore{}).
+			Where("id = ? AND created_by = ? AND deleted_at IS NOT NULL", id, userID).
+			Update("deleted_at", nil)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Where("entity_type = ? AND entity_id = ?", oModel.EntityTypeChore, id).Delete(&oModel.Tombstone{}).Error
+	})
+}
+
+// GetTrashedChores returns the chores of the user in the trash, the latest deleted first.
+func (r *ChoreRepository) GetTrashedChores(c context.Context, circleID int, userID int) ([]*chModel.Chore, error) {
+	var chores []*chModel.Chore
+	if err := r.db.WithContext(c).Unscoped().Preload("Assignees").Preload("LabelsV2").
+		Where("circle_id = ? AND created_by = ? AND deleted_at IS NOT NULL", circleID, userID).
+		Order("deleted_at desc").
+		Find(&chores).Error; err != nil {
+		return nil, err
+	}
+	return chores, nil
+}
+
+func (r *ChoreRepository) GetTrashedChore(c context.Context, choreID int) (*chModel.Chore, error) {
+	var chore chModel.Chore
+	if err := r.db.WithContext(c).Unscoped().Preload("SubTasks").Preload("Assignees").Preload("LabelsV2").
+		Where("deleted_at IS NOT NULL").
+		First(&chore, choreID).Error; err != nil {
+		return nil, err
+	}
+	return &chore, nil
+}
+
+// GetChoresTrashedBefore returns the ids of the chores that were moved to the trash before the time.
+func (r *ChoreRepository) GetChoresTrashedBefore(c context.Context, before time.Time) ([]int, error) {
+	var ids []int
+	if err := r.db.WithContext(c).Unscoped().Model(&chModel.Chore{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
 }
 
 func (r *ChoreRepository) IsChoreOwner(c context.Context, choreID int, userID int) error {
@@ -106,7 +300,7 @@ func (r *ChoreRepository) IsChoreOwner(c context.Context, choreID int, userID in
 	return err
 }
 
-func (r *ChoreRepository) CompleteChore(c context.Context, chore *chModel.Chore, note *string, userID int, dueDate *time.Time, completedDate *time.Time, nextAssignedTo int, applyPoints bool) error {
+func (r *ChoreRepository) CompleteChore(c context.Context, chore *chModel.Chore, note *string, userID int, dueDate *time.Time, completedDate *time.Time, nextAssignedTo int, applyPoints bool, sourceThingID *int) error {
 	err := r.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
 
 		choreUpdates := map[string]interface{}{}
@@ -121,13 +315,14 @@ func (r *ChoreRepository) CompleteChore(c context.Context, chore *chModel.Chore,
 		}
 		// Create a new chore history record.
 		ch := &chModel.ChoreHistory{
-			ChoreID:     chore.ID,
-			PerformedAt: completedDate,
-			CompletedBy: userID,
-			AssignedTo:  chore.AssignedTo,
-			DueDate:     chore.NextDueDate,
-			Note:        note,
-			Status:      chModel.ChoreHistoryStatusCompleted,
+			ChoreID:       chore.ID,
+			PerformedAt:   completedDate,
+			CompletedBy:   userID,
+			AssignedTo:    chore.AssignedTo,
+			DueDate:       chore.NextDueDate,
+			Note:          note,
+			Status:        chModel.ChoreHistoryStatusCompleted,
+			SourceThingID: sourceThingID,
 		}
 
 		// Update UserCirclee Points :
@@ -145,12 +340,12 @@ func (r *ChoreRepository) CompleteChore(c context.Context, chore *chModel.Chore,
 		if err := tx.Create(ch).Error; err != nil {
 			return err
 		}
-		return nil
+		return saveRevision(tx, chore.ID, chModel.RevisionActionCompleted, userID)
 	})
 	return err
 }
 
-func (r *ChoreRepository) SkipChore(c context.Context, chore *chModel.Chore, userID int, dueDate *time.Time, nextAssignedTo int) error {
+func (r *ChoreRepository) SkipChore(c context.Context, chore *chModel.Chore, userID int, dueDate *time.Time, nextAssignedTo int, sourceThingID *int) error {
 	err := r.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
 		choreUpdates := map[string]interface{}{}
 		choreUpdates["next_due_date"] = dueDate
@@ -166,13 +361,14 @@ func (r *ChoreRepository) SkipChore(c context.Context, chore *chModel.Chore, use
 		// Create a new chore history record for the skipped chore
 		skippedAt := time.Now().UTC()
 		ch := &chModel.ChoreHistory{
-			ChoreID:     chore.ID,
-			PerformedAt: &skippedAt,
-			CompletedBy: userID,
-			AssignedTo:  chore.AssignedTo,
-			DueDate:     chore.NextDueDate,
-			Note:        nil,
-			Status:      chModel.ChoreHistoryStatusSkipped,
+			ChoreID:       chore.ID,
+			PerformedAt:   &skippedAt,
+			CompletedBy:   userID,
+			AssignedTo:    chore.AssignedTo,
+			DueDate:       chore.NextDueDate,
+			Note:          nil,
+			Status:        chModel.ChoreHistoryStatusSkipped,
+			SourceThingID: sourceThingID,
 		}
 
 		// Perform the update operation once, using the prepared updates map.
@@ -183,7 +379,7 @@ func (r *ChoreRepository) SkipChore(c context.Context, chore *chModel.Chore, use
 		if err := tx.Create(ch).Error; err != nil {
 			return err
 		}
-		return nil
+		return saveRevision(tx, chore.ID, chModel.RevisionActionSkipped, userID)
 	})
 	return err
 }
@@ -195,6 +391,19 @@ func (r *ChoreRepository) GetChoreHistory(c context.Context, choreID int) ([]*ch
 	}
 	return histories, nil
 }
+
+// GetChoresHistoryBetween returns the history of chores performed in a period, oldest first.
+func (r *ChoreRepository) GetChoresHistoryBetween(c context.Context, choreIDs []int, from time.Time, to time.Time) ([]*chModel.ChoreHistory, error) {
+	histories := []*chModel.ChoreHistory{}
+	if len(choreIDs) == 0 {
+		return histories, nil
+	}
+	if err := r.db.WithContext(c).Where("chore_id IN ? AND performed_at >= ? AND performed_at < ?", choreIDs, from, to).Order("performed_at asc").Find(&histories).Error; err != nil {
+		return nil, err
+	}
+	return histories, nil
+}
+
 func (r *ChoreRepository) GetChoreHistoryWithLimit(c context.Context, choreID int, limit int) ([]*chModel.ChoreHistory, error) {
 	var histories []*chModel.ChoreHistory
 	if err := r.db.WithContext(c).Where("chore_id = ?", choreID).Order("performed_at desc").Limit(limit).Find(&histories).Error; err != nil {
@@ -216,7 +425,26 @@ func (r *ChoreRepository) UpdateChoreHistory(c context.Context, history *chModel
 }
 
 func (r *ChoreRepository) DeleteChoreHistory(c context.Context, historyID int) error {
-	return r.db.WithContext(c).Delete(&chModel.ChoreHistory{}, historyID).Error
+	return r.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		var history chModel.ChoreHistory
+		if err := tx.Select("id", "chore_id").First(&history, historyID).Error; err != nil {
+			return err
+		}
+		var circleID int
+		if err := tx.Model(&chModel.Chore{}).Where("id = ?", history.ChoreID).Pluck("circle_id", &circleID).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&chModel.ChoreHistory{}, historyID).Error; err != nil {
+			return err
+		}
+		return tx.Create(&oModel.Tombstone{
+			EntityType: oModel.EntityTypeChoreHistory,
+			EntityID:   historyID,
+			ChoreID:    &history.ChoreID,
+			CircleID:   &circleID,
+			DeletedAt:  time.Now().UTC(),
+		}).Error
+	})
 }
 func (r *ChoreRepository) DeleteChoreHistoryByChoreID(c context.Context, tx, choreID int) error {
 	return r.db.WithContext(c).Delete(&chModel.ChoreHistory{}, "chore_id = ?", choreID).Error
@@ -318,14 +546,25 @@ func readJSONBooleanField(dbType string, columnName string, fieldName string) st
 }
 
 func (r *ChoreRepository) SetDueDate(c context.Context, choreID int, dueDate time.Time) error {
-	return r.db.WithContext(c).Model(&chModel.Chore{}).Where("id = ?", choreID).Updates(map[string]interface{}{
-		"next_due_date": dueDate,
-		"is_active":     true,
-	}).Error
+	return r.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&chModel.Chore{}).Where("id = ?", choreID).Updates(map[string]interface{}{
+			"next_due_date": dueDate,
+			"is_active":     true,
+		}).Error; err != nil {
+			return err
+		}
+		return saveRevision(tx, choreID, chModel.RevisionActionDueDateChanged, 0)
+	})
 }
 
 func (r *ChoreRepository) SetDueDateIfNotExisted(c context.Context, choreID int, dueDate time.Time) error {
-	return r.db.WithContext(c).Model(&chModel.Chore{}).Where("id = ? and next_due_date is null and is_active = ?", choreID, true).Update("next_due_date", dueDate).Error
+	return r.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&chModel.Chore{}).Where("id = ? and next_due_date is null and is_active = ?", choreID, true).Update("next_due_date", dueDate)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return saveRevision(tx, choreID, chModel.RevisionActionDueDateChanged, 0)
+	})
 }
 
 func (r *ChoreRepository) GetChoreDetailByID(c context.Context, choreID int, circleID int) (*chModel.ChoreDetail, error) {
@@ -362,7 +601,7 @@ func (r *ChoreRepository) GetChoreDetailByID(c context.Context, choreID int, cir
             GROUP BY chore_id
         )
     ) AS recent_history ON chores.id = recent_history.chore_id`).
-		Where("chores.id = ? and chores.circle_id = ?", choreID, circleID).
+		Where("chores.id = ? and chores.circle_id = ? and chores.deleted_at IS NULL", choreID, circleID).
 		Group("chores.id, recent_history.last_completed_date, recent_history.last_assigned_to, recent_history.notes").
 		First(&choreDetail).Error; err != nil {
 		return nil, err
@@ -389,6 +628,7 @@ func (r *ChoreRepository) GetChoresHistoryByUserID(c context.Context, userID int
 		Joins("LEFT JOIN chores ON chore_histories.chore_id = chores.id").
 		Joins("LEFT JOIN circles ON chores.circle_id = circles.id").
 		Where("circles.id = ? AND chore_histories.performed_at > ?", circleID, since).
+		Where("chores.deleted_at IS NULL").
 		Order("chore_histories.performed_at desc")
 
 	if !includeCircle {
@@ -402,5 +642,76 @@ func (r *ChoreRepository) GetChoresHistoryByUserID(c context.Context, userID int
 }
 
 func (r *ChoreRepository) UpdateChoreStatus(c context.Context, choreID int, userId int, status chModel.Status) error {
-	return r.db.WithContext(c).Model(&chModel.Chore{}).Where("id = ?", choreID).Where("created_by = ? ", userId).Update("status", status).Error
+	return r.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&chModel.Chore{}).Where("id = ?", choreID).Where("created_by = ? ", userId).Update("status", status)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return saveRevision(tx, choreID, chModel.RevisionActionStatusChanged, userId)
+	})
+}
+
+// saveRevision stores the chore as it is after a change made in the transaction, with what changed since its
+// latest revision. Changes made outside of the handlers that record revisions store one with it, so every
+// updatedAt a client can read has its revision. userID is 0 when no user made the change.
+func saveRevision(tx *gorm.DB, choreID int, action chModel.RevisionAction, userID int) error {
+	var chore chModel.Chore
+	if err := tx.Preload("SubTasks").Preload("Assignees").Preload("LabelsV2").First(&chore, choreID).Error; err != nil {
+		return err
+	}
+	revision, err := chModel.NewChoreRevision(action, nil, &chore, userID)
+	if err != nil {
+		return err
+	}
+	var latest []*chModel.ChoreRevision
+	if err := tx.Where("chore_id = ?", choreID).Order("id desc").Limit(1).Find(&latest).Error; err != nil {
+		return err
+	}
+	if len(latest) > 0 {
+		if previous, err := latest[0].Req(); err == nil {
+			revision.Changes = chModel.DiffChoreReq(previous, chModel.NewChoreReq(&chore))
+		}
+	}
+	return tx.Create(revision).Error
+}
+
+func (r *ChoreRepository) CreateChoreRevision(c context.Context, revision *chModel.ChoreRevision) error {
+	return r.db.WithContext(c).Create(revision).Error
+}
+
+// GetChoreRevisions returns the revisions of the chore, the latest first.
+func (r *ChoreRepository) GetChoreRevisions(c context.Context, choreID int) ([]*chModel.ChoreRevision, error) {
+	var revisions []*chModel.ChoreRevision
+	if err := r.db.WithContext(c).Where("chore_id = ?", choreID).Order("id desc").Find(&revisions).Error; err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (r *ChoreRepository) GetChoreRevision(c context.Context, choreID int, revisionID int) (*chModel.ChoreRevision, error) {
+	var revision chModel.ChoreRevision
+	if err := r.db.WithContext(c).Where("id = ? AND chore_id = ?", revisionID, choreID).First(&revision).Error; err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// GetChoreRevisionAt returns the revision of the chore with the updatedAt, nil when there is none. A revision
+// from before the time is not returned, the chore could have been changed without one in between.
+func (r *ChoreRepository) GetChoreRevisionAt(c context.Context, choreID int, at time.Time) (*chModel.ChoreRevision, error) {
+	var revision chModel.ChoreRevision
+	err := r.db.WithContext(c).
+		Where("chore_id = ? AND chore_updated_at <= ?", choreID, at).
+		Order("chore_updated_at desc, id desc").
+		First(&revision).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !revision.ChoreUpdatedAt.Equal(at) {
+		return nil, nil
+	}
+	return &revision, nil
 }
@@ -0,0 +1,126 @@
+package chore
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	chModel "donetick.com/core/internal/chore/model"
+	"github.com/teambition/rrule-go"
+)
+
+// rrule properties we accept in the stored rule, anything else is rejected so
+// the user gets an error instead of a silently ignored line.
+var rruleProperties = map[string]bool{
+	"DTSTART": true,
+	"RRULE":   true,
+	"RDATE":   true,
+	"EXDATE":  true,
+}
+
+// splitRRuleLines breaks the stored rule into RFC 5545 content lines. A bare rule value
+// such as `FREQ=MONTHLY;BYDAY=2TU` is accepted and treated as an RRULE line.
+func splitRRuleLines(rule string) ([]string, error) {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(rule, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		upper := strings.ToUpper(line)
+		if strings.HasPrefix(upper, "FREQ=") {
+			line = "RRULE:" + line
+			upper = "RRULE:" + upper
+		}
+		nameLen := strings.IndexAny(upper, ";:")
+		if nameLen <= 0 || !rruleProperties[upper[:nameLen]] {
+			return nil, fmt.Errorf("unsupported line in recurrence rule: %s", line)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return nil, errors.New("recurrence rule is empty")
+	}
+	return lines, nil
+}
+
+// parseRRuleSet parses the rule and falls back to dtStart when the rule has no DTSTART.
+func parseRRuleSet(rule string, dtStart time.Time) (*rrule.Set, error) {
+	lines, err := splitRRuleLines(rule)
+	if err != nil {
+		return nil, err
+	}
+
+	hasDTStart := false
+	hasRRule := false
+	for i, line := range lines {
+		upper := strings.ToUpper(line)
+		if strings.HasPrefix(upper, "DTSTART") {
+			if i != 0 {
+				return nil, errors.New("DTSTART must be the first line of the recurrence rule")
+			}
+			hasDTStart = true
+		}
+		if strings.HasPrefix(upper, "RRULE") {
+			if hasRRule {
+				return nil, errors.New("only one RRULE is allowed")
+			}
+			hasRRule = true
+		}
+	}
+	if !hasRRule {
+		return nil, errors.New("recurrence rule must contain an RRULE")
+	}
+
+	set, err := rrule.StrSliceToRRuleSetInLoc(lines, dtStart.Location())
+	if err != nil {
+		return nil, err
+	}
+	if !hasDTStart {
+		set.DTStart(dtStart)
+	}
+	return set, nil
+}
+
+// NormalizeRRule validates the rule from the frequency metadata and pins its DTSTART to
+// dtStart when missing, so COUNT and INTERVAL keep counting from the same anchor every
+// time the next due date is calculated.
+func NormalizeRRule(metadata *chModel.FrequencyMetadata, dtStart time.Time) error {
+	if metadata == nil || strings.TrimSpace(metadata.RRule) == "" {
+		return errors.New("rrule frequency requires a recurrence rule")
+	}
+	if metadata.Timezone != "" {
+		// floating DTSTART/EXDATE values are interpreted in the chore timezone
+		if loc, err := time.LoadLocation(metadata.Timezone); err == nil {
+			dtStart = dtStart.In(loc)
+		}
+	}
+	set, err := parseRRuleSet(metadata.RRule, dtStart.Truncate(time.Second))
+	if err != nil {
+		return err
+	}
+	if set.GetRRule().OrigOptions.Freq > rrule.DAILY && set.GetRRule().OrigOptions.Count == 0 && set.GetRRule().OrigOptions.Until.IsZero() {
+		// HOURLY/MINUTELY/SECONDLY rules without an end would flood the scheduler
+		return errors.New("recurrence rules more frequent than daily must set COUNT or UNTIL")
+	}
+	metadata.RRule = strings.Join(set.Recurrence(), "\n")
+	return nil
+}
+
+func scheduleRRuleNextDueDate(chore *chModel.Chore, baseDate time.Time) (*time.Time, error) {
+	if chore.FrequencyMetadataV2 == nil || chore.FrequencyMetadataV2.RRule == "" {
+		return nil, errors.New("rrule frequency requires a recurrence rule")
+	}
+	set, err := parseRRuleSet(chore.FrequencyMetadataV2.RRule, baseDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recurrence rule: %w", err)
+	}
+	next := set.After(baseDate, false)
+	if next.IsZero() {
+		// the rule is exhausted by UNTIL or COUNT, treat it like a one time chore
+		return nil, nil
+	}
+	next = next.UTC()
+	return &next, nil
+}
@@ -17,14 +17,28 @@ func scheduleNextDueDate(ctx context.Context, chore *chModel.Chore, completedDat
 		return nil, nil
 	}
 
+	// All calendar math below is done on the wall clock of the chore's timezone, represented as a
+	// UTC time so AddDate never crosses a DST transition. fromWall converts the result back to a
+	// real instant in UTC for storage.
+	loc := choreLocation(ctx, chore)
+	toWall := func(t time.Time) time.Time {
+		t = t.In(loc)
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	}
+	fromWall := func(t time.Time) *time.Time {
+		next := dateInLocation(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc).UTC()
+		return &next
+	}
+	completedWall := toWall(completedDate)
+
 	var baseDate time.Time
 	if chore.NextDueDate != nil {
-		baseDate = chore.NextDueDate.UTC()
+		baseDate = toWall(*chore.NextDueDate)
 	} else {
-		baseDate = completedDate.UTC()
+		baseDate = completedWall
 	}
 	if chore.IsRolling {
-		baseDate = completedDate.UTC()
+		baseDate = completedWall
 	}
 
 	// Handle time-based frequencies, ensure time is in the future
@@ -43,10 +57,11 @@ func scheduleNextDueDate(ctx context.Context, chore *chModel.Chore, completedDat
 			}
 
 		}
-		t = t.UTC()
+		// the time of day is kept on the wall clock of the chore timezone, so 07:00 stays 07:00 across DST
+		t = t.In(loc)
 		baseDate = time.Date(baseDate.Year(), baseDate.Month(), baseDate.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
 		// If the time is in the past today, move it to tomorrow
-		if baseDate.Before(completedDate) {
+		if baseDate.Before(completedWall) {
 			baseDate = baseDate.AddDate(0, 0, 1)
 		}
 	}
@@ -60,14 +75,19 @@ func scheduleNextDueDate(ctx context.Context, chore *chModel.Chore, completedDat
 		baseDate = baseDate.AddDate(0, 1, 0)
 	case "yearly":
 		baseDate = baseDate.AddDate(1, 0, 0)
+	case "rrule":
+		return scheduleRRuleNextDueDate(chore, fromWall(baseDate).In(loc))
 	case "adaptive":
 		// TODO: Implement a more sophisticated adaptive logic
 		diff := completedDate.UTC().Sub(chore.NextDueDate.UTC())
-		baseDate = completedDate.UTC().Add(diff)
+		nextDueDate := completedDate.UTC().Add(diff)
+		return &nextDueDate, nil
 	case "interval":
 		switch *chore.FrequencyMetadataV2.Unit {
 		case "hours":
-			baseDate = baseDate.Add(time.Duration(chore.Frequency) * time.Hour)
+			// hours are elapsed time rather than calendar time, so add them to the real instant
+			nextDueDate := fromWall(baseDate).Add(time.Duration(chore.Frequency) * time.Hour)
+			return &nextDueDate, nil
 		case "days":
 			baseDate = baseDate.AddDate(0, 0, chore.Frequency)
 		case "weeks":
@@ -89,7 +109,7 @@ func scheduleNextDueDate(ctx context.Context, chore *chModel.Chore, completedDat
 			nextDay := strings.ToLower(nextDueDate.Weekday().String())
 			for _, day := range chore.FrequencyMetadataV2.Days {
 				if strings.ToLower(*day) == nextDay {
-					return &nextDueDate, nil
+					return fromWall(nextDueDate), nil
 				}
 			}
 		}
@@ -100,8 +120,8 @@ func scheduleNextDueDate(ctx context.Context, chore *chModel.Chore, completedDat
 		// if task due every 15 of jan, and you completed it on the 13 of jan( before the due date ) if we schedule from due date
 		// we will go back to 15 of jan. so we need to pick the highest between the two dates specifically for day of the month
 		if chore.IsRolling && chore.NextDueDate != nil {
-			secondAfterDueDate := chore.NextDueDate.UTC().Add(time.Second)
-			if completedDate.Before(secondAfterDueDate) {
+			secondAfterDueDate := toWall(*chore.NextDueDate).Add(time.Second)
+			if completedWall.Before(secondAfterDueDate) {
 				baseDate = secondAfterDueDate
 			}
 		}
@@ -117,7 +137,7 @@ func scheduleNextDueDate(ctx context.Context, chore *chModel.Chore, completedDat
 		currentMonth := int(baseDate.Month())
 
 		var startFrom int
-		if chore.NextDueDate != nil && baseDate.Month() == chore.NextDueDate.Month() {
+		if chore.NextDueDate != nil && baseDate.Month() == toWall(*chore.NextDueDate).Month() {
 			startFrom = 1
 		}
 
@@ -139,7 +159,7 @@ func scheduleNextDueDate(ctx context.Context, chore *chModel.Chore, completedDat
 
 			for _, month := range chore.FrequencyMetadataV2.Months {
 				if strings.ToLower(*month) == strings.ToLower(time.Month(nextMonth).String()) {
-					return &nextDueDate, nil
+					return fromWall(nextDueDate), nil
 				}
 			}
 		}
@@ -148,8 +168,60 @@ func scheduleNextDueDate(ctx context.Context, chore *chModel.Chore, completedDat
 		return nil, fmt.Errorf("invalid frequency type: %s", chore.FrequencyType)
 	}
 
-	return &baseDate, nil
+	return fromWall(baseDate), nil
+}
+
+// choreLocation returns the timezone recurrence is calculated in. Chores without a
+// timezone in their frequency metadata use the timezone of their creator, and keep the
+// historical UTC behaviour when the creator has none either.
+func choreLocation(ctx context.Context, chore *chModel.Chore) *time.Location {
+	if chore.FrequencyMetadataV2 != nil && chore.FrequencyMetadataV2.Timezone != "" {
+		loc, err := time.LoadLocation(chore.FrequencyMetadataV2.Timezone)
+		if err == nil {
+			return loc
+		}
+		logging.FromContext(ctx).Warnw("invalid timezone in frequency metadata, falling back to the creator timezone", "timezone", chore.FrequencyMetadataV2.Timezone, "chore_id", chore.ID)
+	}
+	if chore.CreatorTimezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(chore.CreatorTimezone)
+	if err != nil {
+		logging.FromContext(ctx).Warnw("invalid timezone of the chore creator, falling back to UTC", "timezone", chore.CreatorTimezone, "chore_id", chore.ID)
+		return time.UTC
+	}
+	return loc
+}
+
+// dateInLocation is time.Date with deterministic DST handling: a wall clock time that
+// falls in a DST gap is moved forward by the length of the gap (02:30 becomes 03:30),
+// and a wall clock time that occurs twice during a DST overlap resolves to the first
+// occurrence. time.Date leaves both cases unspecified and resolves them differently
+// depending on the sign of the zone offset.
+func dateInLocation(year int, month time.Month, day, hour, min, sec, nsec int, loc *time.Location) time.Time {
+	wall := time.Date(year, month, day, hour, min, sec, nsec, time.UTC)
+	// no zone changes its offset twice within a day, so the offsets half a day
+	// before and after cover both sides of any transition on this date
+	_, offsetBefore := wall.Add(-12 * time.Hour).In(loc).Zone()
+	_, offsetAfter := wall.Add(12 * time.Hour).In(loc).Zone()
+
+	var resolved time.Time
+	for _, offset := range []int{offsetBefore, offsetAfter} {
+		candidate := wall.Add(-time.Duration(offset) * time.Second).In(loc)
+		if candidate.Hour() != hour || candidate.Minute() != min || candidate.Day() != day {
+			continue
+		}
+		if resolved.IsZero() || candidate.Before(resolved) {
+			resolved = candidate
+		}
+	}
+	if resolved.IsZero() {
+		// the wall clock time does not exist, interpret it with the offset in effect before the gap
+		resolved = wall.Add(-time.Duration(offsetBefore) * time.Second).In(loc)
+	}
+	return resolved
 }
+
 func scheduleAdaptiveNextDueDate(chore *chModel.Chore, completedDate time.Time, history []*chModel.ChoreHistory) (*time.Time, error) {
 
 	history = append([]*chModel.ChoreHistory{
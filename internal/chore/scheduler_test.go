@@ -295,6 +295,355 @@ func TestScheduleNextDueDateDayOfMonth(t *testing.T) {
 
 }
 
+func TestScheduleNextDueDateRRule(t *testing.T) {
+	location, err := time.LoadLocation("UTC")
+	if err != nil {
+		t.Fatalf("error loading location: %v", err)
+	}
+
+	now := time.Date(2025, 1, 2, 0, 15, 0, 0, location)
+	tests := []scheduleTest{
+		{
+			name: "RRule - second Tuesday of the month",
+			chore: chModel.Chore{
+				FrequencyType: chModel.FrequencyTypeRRule,
+				NextDueDate:   timePtr(time.Date(2025, 1, 14, 9, 0, 0, 0, location)),
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{
+					RRule: "DTSTART:20250114T090000Z\nRRULE:FREQ=MONTHLY;BYDAY=2TU",
+				},
+			},
+			completedDate: now.AddDate(0, 0, 12),
+			want:          timePtr(time.Date(2025, 2, 11, 9, 0, 0, 0, location)),
+		},
+		{
+			name: "RRule - last weekday of the quarter",
+			chore: chModel.Chore{
+				FrequencyType: chModel.FrequencyTypeRRule,
+				NextDueDate:   timePtr(time.Date(2024, 12, 31, 17, 0, 0, 0, location)),
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{
+					RRule: "DTSTART:20241231T170000Z\nRRULE:FREQ=MONTHLY;BYMONTH=3,6,9,12;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1",
+				},
+			},
+			completedDate: now,
+			want:          timePtr(time.Date(2025, 3, 31, 17, 0, 0, 0, location)),
+		},
+		{
+			name: "RRule - bare rule without DTSTART uses the due date",
+			chore: chModel.Chore{
+				FrequencyType: chModel.FrequencyTypeRRule,
+				NextDueDate:   timePtr(time.Date(2025, 1, 2, 8, 0, 0, 0, location)),
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{
+					RRule: "FREQ=WEEKLY;INTERVAL=2",
+				},
+			},
+			completedDate: now,
+			want:          timePtr(time.Date(2025, 1, 16, 8, 0, 0, 0, location)),
+		},
+		{
+			name: "RRule - EXDATE skips an occurrence",
+			chore: chModel.Chore{
+				FrequencyType: chModel.FrequencyTypeRRule,
+				NextDueDate:   timePtr(time.Date(2025, 1, 6, 8, 0, 0, 0, location)),
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{
+					RRule: "DTSTART:20250106T080000Z\nRRULE:FREQ=WEEKLY\nEXDATE:20250113T080000Z",
+				},
+			},
+			completedDate: now.AddDate(0, 0, 4),
+			want:          timePtr(time.Date(2025, 1, 20, 8, 0, 0, 0, location)),
+		},
+		{
+			name: "RRule - COUNT exhausted",
+			chore: chModel.Chore{
+				FrequencyType: chModel.FrequencyTypeRRule,
+				NextDueDate:   timePtr(time.Date(2025, 1, 3, 8, 0, 0, 0, location)),
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{
+					RRule: "DTSTART:20250101T080000Z\nRRULE:FREQ=DAILY;COUNT=3",
+				},
+			},
+			completedDate: now.AddDate(0, 0, 1),
+			want:          nil,
+		},
+		{
+			name: "RRule - UNTIL exhausted",
+			chore: chModel.Chore{
+				FrequencyType: chModel.FrequencyTypeRRule,
+				NextDueDate:   timePtr(time.Date(2025, 1, 3, 8, 0, 0, 0, location)),
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{
+					RRule: "DTSTART:20250101T080000Z\nRRULE:FREQ=DAILY;UNTIL=20250103T235959Z",
+				},
+			},
+			completedDate: now.AddDate(0, 0, 1),
+			want:          nil,
+		},
+		{
+			name: "RRule - invalid rule",
+			chore: chModel.Chore{
+				FrequencyType: chModel.FrequencyTypeRRule,
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{
+					RRule: "FREQ=SOMETIMES",
+				},
+			},
+			completedDate: now,
+			wantErr:       true,
+			wantErrMsg:    "invalid recurrence rule: StrToROption failed: undefined frequency: SOMETIMES",
+		},
+	}
+	executeTestTable(t, tests)
+}
+
+func TestNormalizeRRule(t *testing.T) {
+	dtStart := time.Date(2025, 1, 2, 9, 30, 0, 0, time.UTC)
+
+	metadata := &chModel.FrequencyMetadata{RRule: "FREQ=MONTHLY;BYDAY=2TU;COUNT=4"}
+	if err := NormalizeRRule(metadata, dtStart); err != nil {
+		t.Fatalf("NormalizeRRule() unexpected error = %v", err)
+	}
+	want := "DTSTART:20250102T093000Z\nRRULE:FREQ=MONTHLY;COUNT=4;BYDAY=+2TU"
+	if metadata.RRule != want {
+		t.Errorf("NormalizeRRule() = %q, want %q", metadata.RRule, want)
+	}
+
+	for _, rule := range []string{
+		"",
+		"FREQ=HOURLY",
+		"EXDATE:20250113T080000Z",
+		"RRULE:FREQ=DAILY\nRRULE:FREQ=WEEKLY",
+		"RRULE:FREQ=DAILY\nDTSTART:20250101T080000Z",
+		"X-CUSTOM:1",
+	} {
+		if err := NormalizeRRule(&chModel.FrequencyMetadata{RRule: rule}, dtStart); err == nil {
+			t.Errorf("NormalizeRRule(%q) expected an error", rule)
+		}
+	}
+}
+
+func TestScheduleNextDueDateTimezones(t *testing.T) {
+	utc := func(year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+	}
+	tests := []scheduleTest{
+		{
+			name: "New York - daily 07:00 across spring forward",
+			chore: chModel.Chore{
+				FrequencyType:       chModel.FrequencyTypeDaily,
+				NextDueDate:         timePtr(utc(2025, 3, 8, 12, 0)), // 07:00 EST
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{Timezone: "America/New_York"},
+			},
+			completedDate: utc(2025, 3, 8, 12, 0),
+			want:          timePtr(utc(2025, 3, 9, 11, 0)), // 07:00 EDT
+		},
+		{
+			name: "New York - daily 07:00 across fall back",
+			chore: chModel.Chore{
+				FrequencyType:       chModel.FrequencyTypeDaily,
+				NextDueDate:         timePtr(utc(2025, 11, 1, 11, 0)), // 07:00 EDT
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{Timezone: "America/New_York"},
+			},
+			completedDate: utc(2025, 11, 1, 11, 0),
+			want:          timePtr(utc(2025, 11, 2, 12, 0)), // 07:00 EST
+		},
+		{
+			name: "New York - DST gap moves 02:30 forward to 03:30",
+			chore: chModel.Chore{
+				FrequencyType:       chModel.FrequencyTypeDaily,
+				NextDueDate:         timePtr(utc(2025, 3, 8, 7, 30)), // 02:30 EST
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{Timezone: "America/New_York"},
+			},
+			completedDate: utc(2025, 3, 8, 7, 30),
+			want:          timePtr(utc(2025, 3, 9, 7, 30)), // 03:30 EDT
+		},
+		{
+			name: "New York - DST overlap picks the first 01:30",
+			chore: chModel.Chore{
+				FrequencyType:       chModel.FrequencyTypeDaily,
+				NextDueDate:         timePtr(utc(2025, 11, 1, 5, 30)), // 01:30 EDT
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{Timezone: "America/New_York"},
+			},
+			completedDate: utc(2025, 11, 1, 5, 30),
+			want:          timePtr(utc(2025, 11, 2, 5, 30)), // 01:30 EDT
+		},
+		{
+			name: "Berlin - DST gap moves 02:30 forward to 03:30",
+			chore: chModel.Chore{
+				FrequencyType:       chModel.FrequencyTypeDaily,
+				NextDueDate:         timePtr(utc(2025, 3, 29, 1, 30)), // 02:30 CET
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{Timezone: "Europe/Berlin"},
+			},
+			completedDate: utc(2025, 3, 29, 1, 30),
+			want:          timePtr(utc(2025, 3, 30, 1, 30)), // 03:30 CEST
+		},
+		{
+			name: "Berlin - DST overlap picks the first 02:30",
+			chore: chModel.Chore{
+				FrequencyType:       chModel.FrequencyTypeDaily,
+				NextDueDate:         timePtr(utc(2025, 10, 25, 0, 30)), // 02:30 CEST
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{Timezone: "Europe/Berlin"},
+			},
+			completedDate: utc(2025, 10, 25, 0, 30),
+			want:          timePtr(utc(2025, 10, 26, 0, 30)), // 02:30 CEST
+		},
+		{
+			name: "Berlin - interval keeps 07:00 wall clock from a winter time",
+			chore: chModel.Chore{
+				FrequencyType: chModel.FrequencyTypeInterval,
+				Frequency:     1,
+				NextDueDate:   timePtr(utc(2025, 3, 29, 6, 0)), // 07:00 CET
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{
+					Time:     "2025-01-10T07:00:00+01:00",
+					Unit:     jsonPtr("days"),
+					Timezone: "Europe/Berlin",
+				},
+			},
+			completedDate: utc(2025, 3, 29, 5, 0),
+			want:          timePtr(utc(2025, 3, 30, 5, 0)), // 07:00 CEST
+		},
+		{
+			name: "New York - interval in hours is elapsed time across DST",
+			chore: chModel.Chore{
+				FrequencyType: chModel.FrequencyTypeInterval,
+				Frequency:     24,
+				NextDueDate:   timePtr(utc(2025, 3, 8, 12, 0)), // 07:00 EST
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{
+					Time:     "2025-01-01T07:00:00-05:00",
+					Unit:     jsonPtr("hours"),
+					Timezone: "America/New_York",
+				},
+			},
+			completedDate: utc(2025, 3, 8, 11, 0),
+			want:          timePtr(utc(2025, 3, 9, 12, 0)), // 08:00 EDT
+		},
+		{
+			name: "Sydney - weekly 09:00 across the April DST end",
+			chore: chModel.Chore{
+				FrequencyType:       chModel.FrequencyTypeWeekly,
+				NextDueDate:         timePtr(utc(2025, 3, 31, 22, 0)), // 09:00 AEDT on April 1st
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{Timezone: "Australia/Sydney"},
+			},
+			completedDate: utc(2025, 3, 31, 22, 0),
+			want:          timePtr(utc(2025, 4, 7, 23, 0)), // 09:00 AEST on April 8th
+		},
+		{
+			name: "Auckland - monthly across the April DST end",
+			chore: chModel.Chore{
+				FrequencyType:       chModel.FrequencyTypeMonthly,
+				NextDueDate:         timePtr(utc(2025, 3, 14, 19, 0)), // 08:00 NZDT on March 15th
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{Timezone: "Pacific/Auckland"},
+			},
+			completedDate: utc(2025, 3, 14, 19, 0),
+			want:          timePtr(utc(2025, 4, 14, 20, 0)), // 08:00 NZST on April 15th
+		},
+		{
+			name: "Tokyo - days of the week uses the local weekday",
+			chore: chModel.Chore{
+				FrequencyType: chModel.FrequencyTypeDayOfTheWeek,
+				NextDueDate:   timePtr(utc(2025, 1, 5, 23, 0)), // Monday 08:00 JST, Sunday in UTC
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{
+					Days:     []*string{jsonPtr("monday")},
+					Time:     "2025-01-20T08:00:00+09:00",
+					Timezone: "Asia/Tokyo",
+				},
+			},
+			completedDate: utc(2025, 1, 5, 23, 30),
+			want:          timePtr(utc(2025, 1, 12, 23, 0)), // Monday 08:00 JST
+		},
+		{
+			name: "New York - day of the month keeps the wall clock across DST",
+			chore: chModel.Chore{
+				FrequencyType: chModel.FrequencyTypeDayOfTheMonth,
+				Frequency:     15,
+				NextDueDate:   timePtr(utc(2025, 2, 15, 12, 0)), // 07:00 EST
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{
+					Time:     "2025-01-15T07:00:00-05:00",
+					Months:   []*string{jsonPtr("february"), jsonPtr("march")},
+					Timezone: "America/New_York",
+				},
+			},
+			completedDate: utc(2025, 2, 15, 12, 0),
+			want:          timePtr(utc(2025, 3, 15, 11, 0)), // 07:00 EDT
+		},
+		{
+			name: "New York - rrule with TZID across spring forward",
+			chore: chModel.Chore{
+				FrequencyType: chModel.FrequencyTypeRRule,
+				NextDueDate:   timePtr(utc(2025, 3, 8, 12, 0)), // 07:00 EST
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{
+					RRule:    "DTSTART;TZID=America/New_York:20250301T070000\nRRULE:FREQ=DAILY",
+					Timezone: "America/New_York",
+				},
+			},
+			completedDate: utc(2025, 3, 8, 12, 0),
+			want:          timePtr(utc(2025, 3, 9, 11, 0)), // 07:00 EDT
+		},
+		{
+			name: "Invalid timezone falls back to UTC",
+			chore: chModel.Chore{
+				FrequencyType:       chModel.FrequencyTypeDaily,
+				NextDueDate:         timePtr(utc(2025, 3, 8, 12, 0)),
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{Timezone: "Mars/Olympus_Mons"},
+			},
+			completedDate: utc(2025, 3, 8, 12, 0),
+			want:          timePtr(utc(2025, 3, 9, 12, 0)),
+		},
+		{
+			name: "Without a timezone - daily 07:00 in the creator timezone across spring forward",
+			chore: chModel.Chore{
+				FrequencyType:       chModel.FrequencyTypeDaily,
+				NextDueDate:         timePtr(utc(2025, 3, 8, 12, 0)), // 07:00 EST
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{},
+				CreatorTimezone:     "America/New_York",
+			},
+			completedDate: utc(2025, 3, 8, 12, 0),
+			want:          timePtr(utc(2025, 3, 9, 11, 0)), // 07:00 EDT
+		},
+		{
+			name: "Invalid timezone falls back to the creator timezone",
+			chore: chModel.Chore{
+				FrequencyType:       chModel.FrequencyTypeDaily,
+				NextDueDate:         timePtr(utc(2025, 3, 8, 12, 0)), // 07:00 EST
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{Timezone: "Mars/Olympus_Mons"},
+				CreatorTimezone:     "America/New_York",
+			},
+			completedDate: utc(2025, 3, 8, 12, 0),
+			want:          timePtr(utc(2025, 3, 9, 11, 0)), // 07:00 EDT
+		},
+	}
+	executeTestTable(t, tests)
+}
+
+func TestDateInLocation(t *testing.T) {
+	tests := []struct {
+		name     string
+		zone     string
+		wall     time.Time // wall clock expressed in UTC
+		wantUTC  time.Time
+		wantHour int
+	}{
+		{"New York gap", "America/New_York", time.Date(2025, 3, 9, 2, 30, 0, 0, time.UTC), time.Date(2025, 3, 9, 7, 30, 0, 0, time.UTC), 3},
+		{"New York overlap", "America/New_York", time.Date(2025, 11, 2, 1, 30, 0, 0, time.UTC), time.Date(2025, 11, 2, 5, 30, 0, 0, time.UTC), 1},
+		{"Berlin gap", "Europe/Berlin", time.Date(2025, 3, 30, 2, 30, 0, 0, time.UTC), time.Date(2025, 3, 30, 1, 30, 0, 0, time.UTC), 3},
+		{"Berlin overlap", "Europe/Berlin", time.Date(2025, 10, 26, 2, 30, 0, 0, time.UTC), time.Date(2025, 10, 26, 0, 30, 0, 0, time.UTC), 2},
+		{"Sydney gap", "Australia/Sydney", time.Date(2025, 10, 5, 2, 30, 0, 0, time.UTC), time.Date(2025, 10, 4, 16, 30, 0, 0, time.UTC), 3},
+		{"Sydney overlap", "Australia/Sydney", time.Date(2025, 4, 6, 2, 30, 0, 0, time.UTC), time.Date(2025, 4, 5, 15, 30, 0, 0, time.UTC), 2},
+		{"Lord Howe half hour gap", "Australia/Lord_Howe", time.Date(2025, 10, 5, 2, 15, 0, 0, time.UTC), time.Date(2025, 10, 4, 15, 45, 0, 0, time.UTC), 2},
+		{"Kolkata no DST", "Asia/Kolkata", time.Date(2025, 3, 9, 2, 30, 0, 0, time.UTC), time.Date(2025, 3, 8, 21, 0, 0, 0, time.UTC), 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc, err := time.LoadLocation(tt.zone)
+			if err != nil {
+				t.Fatalf("error loading location: %v", err)
+			}
+			got := dateInLocation(tt.wall.Year(), tt.wall.Month(), tt.wall.Day(), tt.wall.Hour(), tt.wall.Minute(), 0, 0, loc)
+			if !got.Equal(tt.wantUTC) {
+				t.Errorf("dateInLocation() = %v, want %v", got.UTC(), tt.wantUTC)
+			}
+			if got.Hour() != tt.wantHour {
+				t.Errorf("dateInLocation() local hour = %d, want %d", got.Hour(), tt.wantHour)
+			}
+		})
+	}
+}
+
 func TestScheduleNextDueDateErrors(t *testing.T) {
 	// location, err := time.LoadLocation("America/New_York")
 	location, err := time.LoadLocation("UTC")
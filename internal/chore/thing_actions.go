@@ -0,0 +1,165 @@
+package chore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	chModel "donetick.com/core/internal/chore/model"
+	chRepo "donetick.com/core/internal/chore/repo"
+	cRepo "donetick.com/core/internal/circle/repo"
+	"donetick.com/core/internal/events"
+	nps "donetick.com/core/internal/notifier/service"
+	stRepo "donetick.com/core/internal/subtask/repo"
+	uModel "donetick.com/core/internal/user/model"
+	"donetick.com/core/logging"
+)
+
+// ThingActions completes, skips and resets the subtasks of chores when a thing trigger asks for it. The
+// chore is done by whoever it is assigned to and the history records the thing as its source.
+type ThingActions struct {
+	choreRepo     *chRepo.ChoreRepository
+	circleRepo    *cRepo.CircleRepository
+	stRepo        *stRepo.SubTasksRepository
+	nPlanner      *nps.NotificationPlanner
+	eventProducer *events.EventsProducer
+}
+
+func NewThingActions(cr *chRepo.ChoreRepository, circleRepo *cRepo.CircleRepository, stRepo *stRepo.SubTasksRepository,
+	np *nps.NotificationPlanner, ep *events.EventsProducer) *ThingActions {
+	return &ThingActions{
+		choreRepo:     cr,
+		circleRepo:    circleRepo,
+		stRepo:        stRepo,
+		nPlanner:      np,
+		eventProducer: ep,
+	}
+}
+
+// CompleteChoreByThing completes the chore like its assignee would, without giving them its points as
+// nobody marked it done.
+func (a *ThingActions) CompleteChoreByThing(c context.Context, choreID int, thingID int) error {
+	chore, err := a.activeChore(c, choreID)
+	if err != nil || chore == nil {
+		return err
+	}
+	completedDate := time.Now().UTC()
+	var nextDueDate *time.Time
+	if chore.FrequencyType == chModel.FrequencyTypeAdaptive {
+		history, err := a.choreRepo.GetChoreHistoryWithLimit(c, chore.ID, 5)
+		if err != nil {
+			return err
+		}
+		nextDueDate, err = scheduleAdaptiveNextDueDate(chore, completedDate, history)
+		if err != nil {
+			return err
+		}
+	} else {
+		nextDueDate, err = scheduleNextDueDate(c, chore, completedDate)
+		if err != nil {
+			return err
+		}
+	}
+	choreHistory, err := a.choreRepo.GetChoreHistory(c, chore.ID)
+	if err != nil {
+		return err
+	}
+	nextAssignedTo, err := checkNextAssignee(chore, choreHistory, chore.AssignedTo)
+	if err != nil {
+		return err
+	}
+
+	if err := a.choreRepo.CompleteChore(c, chore, nil, chore.AssignedTo, nextDueDate, &completedDate, nextAssignedTo, false, &thingID); err != nil {
+		return err
+	}
+	updatedChore, err := a.choreRepo.GetChore(c, chore.ID)
+	if err != nil {
+		return err
+	}
+	if updatedChore.SubTasks != nil && updatedChore.FrequencyType != chModel.FrequencyTypeOnce {
+		a.stRepo.ResetSubtasksCompletion(c, updatedChore.ID)
+	}
+	a.nPlanner.GenerateNotifications(c, updatedChore)
+
+	webhookURL, performer := a.eventContext(c, chore)
+	a.eventProducer.ChoreCompleted(c, webhookURL, chore, performer)
+	return nil
+}
+
+// SkipChoreByThing skips the chore to its next due date, it stays with its assignee.
+func (a *ThingActions) SkipChoreByThing(c context.Context, choreID int, thingID int) error {
+	chore, err := a.activeChore(c, choreID)
+	if err != nil || chore == nil {
+		return err
+	}
+	if chore.NextDueDate == nil {
+		// nothing to skip to
+		return nil
+	}
+	nextDueDate, err := scheduleNextDueDate(c, chore, chore.NextDueDate.UTC())
+	if err != nil {
+		return err
+	}
+	if err := a.choreRepo.SkipChore(c, chore, chore.AssignedTo, nextDueDate, chore.AssignedTo, &thingID); err != nil {
+		return err
+	}
+	updatedChore, err := a.choreRepo.GetChore(c, chore.ID)
+	if err != nil {
+		return err
+	}
+
+	webhookURL, performer := a.eventContext(c, updatedChore)
+	a.eventProducer.ChoreSkipped(c, webhookURL, updatedChore, performer)
+	return nil
+}
+
+// ResetSubtasksByThing marks every subtask of the chore as not done.
+func (a *ThingActions) ResetSubtasksByThing(c context.Context, choreID int, thingID int) error {
+	chore, err := a.activeChore(c, choreID)
+	if err != nil || chore == nil {
+		return err
+	}
+	if chore.SubTasks == nil || len(*chore.SubTasks) == 0 {
+		return nil
+	}
+	return a.stRepo.ResetSubtasksCompletion(c, chore.ID)
+}
+
+// activeChore returns the chore, or nil when it is archived or a one time chore that is done.
+func (a *ThingActions) activeChore(c context.Context, choreID int) (*chModel.Chore, error) {
+	chore, err := a.choreRepo.GetChore(c, choreID)
+	if err != nil {
+		return nil, fmt.Errorf("chore %d: %w", choreID, err)
+	}
+	if !chore.IsActive {
+		return nil, nil
+	}
+	return chore, nil
+}
+
+// eventContext returns the webhook URL of the circle of the chore and its assignee as the performer of
+// the event.
+func (a *ThingActions) eventContext(c context.Context, chore *chModel.Chore) (*string, *uModel.User) {
+	log := logging.FromContext(c)
+	performer := &uModel.User{ID: chore.AssignedTo}
+	var webhookURL *string
+	circle, err := a.circleRepo.GetCircleByID(c, chore.CircleID)
+	if err != nil {
+		log.Errorw("Failed to get circle of chore", "chore", chore.ID, "error", err)
+	} else {
+		webhookURL = circle.WebhookURL
+	}
+	circleUsers, err := a.circleRepo.GetCircleUsers(c, chore.CircleID)
+	if err != nil {
+		log.Errorw("Failed to get users of circle", "circle", chore.CircleID, "error", err)
+		return webhookURL, performer
+	}
+	for _, cu := range circleUsers {
+		if cu.UserID == chore.AssignedTo {
+			performer.Username = cu.Username
+			performer.DisplayName = cu.DisplayName
+			break
+		}
+	}
+	return webhookURL, performer
+}
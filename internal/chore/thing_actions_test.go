@@ -0,0 +1,113 @@
+package chore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"donetick.com/core/config"
+	chModel "donetick.com/core/internal/chore/model"
+	chRepo "donetick.com/core/internal/chore/repo"
+	cModel "donetick.com/core/internal/circle/model"
+	cRepo "donetick.com/core/internal/circle/repo"
+	"donetick.com/core/internal/database/dbtest"
+	"donetick.com/core/internal/events"
+	eRepo "donetick.com/core/internal/events/repo"
+	nRepo "donetick.com/core/internal/notifier/repo"
+	nps "donetick.com/core/internal/notifier/service"
+	stModel "donetick.com/core/internal/subtask/model"
+	stRepo "donetick.com/core/internal/subtask/repo"
+	uModel "donetick.com/core/internal/user/model"
+	"gorm.io/gorm"
+)
+
+func newTestThingActions(t *testing.T) (*ThingActions, *gorm.DB) {
+	t.Helper()
+	db := dbtest.Open(t)
+	cfg := &config.Config{WebhookConfig: config.WebhookConfig{Timeout: time.Second, QueueSize: 10}}
+	circleRepo := cRepo.NewCircleRepository(db)
+	actions := NewThingActions(chRepo.NewChoreRepository(db, cfg), circleRepo, stRepo.NewSubTasksRepository(db),
+		nps.NewNotificationPlanner(nRepo.NewNotificationRepository(db), circleRepo),
+		events.NewEventsProducer(cfg, eRepo.NewWebhookDeliveryRepository(db)))
+	return actions, db
+}
+
+func TestThingActions(t *testing.T) {
+	actions, db := newTestThingActions(t)
+	ctx := context.Background()
+	dueDate := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	points := 10
+	completedAt := time.Now().UTC()
+	for _, record := range []interface{}{
+		&cModel.Circle{ID: 1, Name: "Home"},
+		&uModel.User{ID: 1, Username: "alex", Email: "alex@example.com", CircleID: 1},
+		&cModel.UserCircle{UserID: 1, CircleID: 1, Role: string(cModel.RoleAdmin), IsActive: true},
+		&chModel.Chore{ID: 5, Name: "Unload laundry", CircleID: 1, CreatedBy: 1, AssignedTo: 1, IsActive: true,
+			FrequencyType: chModel.FrequencyTypeDaily, Frequency: 1, NextDueDate: &dueDate, Points: &points, AssignStrategy: chModel.AssignmentStrategyKeepLastAssigned},
+		&chModel.Chore{ID: 6, Name: "Empty dehumidifier", CircleID: 1, CreatedBy: 1, AssignedTo: 1, IsActive: true,
+			FrequencyType: chModel.FrequencyTypeDaily, Frequency: 1, NextDueDate: &dueDate},
+		&stModel.SubTask{ID: 1, ChoreID: 6, Name: "Drain the tank", CompletedAt: &completedAt},
+	} {
+		if err := db.Create(record).Error; err != nil {
+			t.Fatalf("failed to create %T: %v", record, err)
+		}
+	}
+
+	if err := actions.CompleteChoreByThing(ctx, 5, 3); err != nil {
+		t.Fatalf("CompleteChoreByThing() error = %v", err)
+	}
+	var history chModel.ChoreHistory
+	if err := db.Where("chore_id = ?", 5).First(&history).Error; err != nil {
+		t.Fatalf("no history for the completed chore: %v", err)
+	}
+	if history.Status != chModel.ChoreHistoryStatusCompleted || history.SourceThingID == nil || *history.SourceThingID != 3 {
+		t.Errorf("history = %+v, want completed by thing 3", history)
+	}
+	if history.CompletedBy != 1 || history.Points != nil {
+		t.Errorf("history completed by %d with points %v, want the assignee without points", history.CompletedBy, history.Points)
+	}
+	var chore chModel.Chore
+	db.First(&chore, 5)
+	if chore.NextDueDate == nil || !chore.NextDueDate.After(dueDate) {
+		t.Errorf("next due date = %v, want after %v", chore.NextDueDate, dueDate)
+	}
+	// an edit made on the completed chore is merged with what changed after the completion
+	revision, err := actions.choreRepo.GetChoreRevisionAt(ctx, 5, chore.UpdatedAt)
+	if err != nil || revision == nil || revision.Action != chModel.RevisionActionCompleted {
+		t.Errorf("revision at %v = %+v, %v, want the completion", chore.UpdatedAt, revision, err)
+	}
+	if revision, _ := actions.choreRepo.GetChoreRevisionAt(ctx, 5, chore.UpdatedAt.Add(time.Second)); revision != nil {
+		t.Errorf("revision at a later time = %+v, want none", revision)
+	}
+
+	if err := actions.SkipChoreByThing(ctx, 6, 4); err != nil {
+		t.Fatalf("SkipChoreByThing() error = %v", err)
+	}
+	var skipped chModel.ChoreHistory
+	if err := db.Where("chore_id = ?", 6).First(&skipped).Error; err != nil {
+		t.Fatalf("no history for the skipped chore: %v", err)
+	}
+	if skipped.Status != chModel.ChoreHistoryStatusSkipped || skipped.SourceThingID == nil || *skipped.SourceThingID != 4 {
+		t.Errorf("history = %+v, want skipped by thing 4", skipped)
+	}
+
+	if err := actions.ResetSubtasksByThing(ctx, 6, 4); err != nil {
+		t.Fatalf("ResetSubtasksByThing() error = %v", err)
+	}
+	var subtask stModel.SubTask
+	db.First(&subtask, 1)
+	if subtask.CompletedAt != nil {
+		t.Errorf("subtask still completed at %v", subtask.CompletedAt)
+	}
+
+	// archived chores are left alone
+	db.Model(&chModel.Chore{}).Where("id = ?", 5).Update("is_active", false)
+	if err := actions.CompleteChoreByThing(ctx, 5, 3); err != nil {
+		t.Fatalf("CompleteChoreByThing() error = %v", err)
+	}
+	var count int64
+	db.Model(&chModel.ChoreHistory{}).Where("chore_id = ?", 5).Count(&count)
+	if count != 1 {
+		t.Errorf("archived chore has %d history records, want 1", count)
+	}
+}
@@ -0,0 +1,79 @@
+package chore
+
+import (
+	"context"
+	"time"
+
+	"donetick.com/core/config"
+	chRepo "donetick.com/core/internal/chore/repo"
+	tRepo "donetick.com/core/internal/thing/repo"
+	"donetick.com/core/logging"
+)
+
+const defaultTrashRetention = 30 * 24 * time.Hour
+
+// TrashPurger deletes the chores that have been in the trash for longer than the trash retention, with their
+// history, revisions and things.
+type TrashPurger struct {
+	choreRepo *chRepo.ChoreRepository
+	tRepo     *tRepo.ThingRepository
+	retention time.Duration
+	ticker    *time.Ticker
+	done      chan bool
+}
+
+func NewTrashPurger(cfg *config.Config, cr *chRepo.ChoreRepository, tr *tRepo.ThingRepository) *TrashPurger {
+	retention := cfg.Trash.Retention
+	if retention <= 0 {
+		retention = defaultTrashRetention
+	}
+	return &TrashPurger{
+		choreRepo: cr,
+		tRepo:     tr,
+		retention: retention,
+		ticker:    time.NewTicker(time.Hour),
+		done:      make(chan bool),
+	}
+}
+
+func (p *TrashPurger) Start(ctx context.Context) {
+	logger := logging.FromContext(ctx)
+
+	go func() {
+		for {
+			select {
+			case <-p.done:
+				return
+			case <-p.ticker.C:
+				if err := p.Run(ctx, time.Now().UTC()); err != nil {
+					logger.Errorw("Failed to purge the trash", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+func (p *TrashPurger) Stop() {
+	p.ticker.Stop()
+	p.done <- true
+}
+
+func (p *TrashPurger) Run(ctx context.Context, now time.Time) error {
+	ids, err := p.choreRepo.GetChoresTrashedBefore(ctx, now.Add(-p.retention))
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := purgeChore(ctx, p.choreRepo, p.tRepo, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func purgeChore(ctx context.Context, cr *chRepo.ChoreRepository, tr *tRepo.ThingRepository, id int) error {
+	if err := cr.DeleteChore(ctx, id); err != nil {
+		return err
+	}
+	return tr.DissociateChoreWithThing(ctx, id)
+}
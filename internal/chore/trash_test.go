@@ -0,0 +1,78 @@
+package chore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"donetick.com/core/config"
+	chModel "donetick.com/core/internal/chore/model"
+	chRepo "donetick.com/core/internal/chore/repo"
+	"donetick.com/core/internal/database/dbtest"
+	oModel "donetick.com/core/internal/offline/model"
+	tRepo "donetick.com/core/internal/thing/repo"
+	"gorm.io/gorm"
+)
+
+func TestTrashPurger(t *testing.T) {
+	db := dbtest.Open(t)
+	ctx := context.Background()
+	cfg := &config.Config{Trash: config.TrashConfig{Retention: 24 * time.Hour}}
+	choreRepo := chRepo.NewChoreRepository(db, cfg)
+	purger := NewTrashPurger(cfg, choreRepo, tRepo.NewThingRepository(db, cfg))
+
+	expired := &chModel.Chore{Name: "Old", CircleID: 1, CreatedBy: 1, IsActive: true}
+	recent := &chModel.Chore{Name: "Recent", CircleID: 1, CreatedBy: 1, IsActive: true}
+	db.Create(expired)
+	db.Create(recent)
+	for _, chore := range []*chModel.Chore{expired, recent} {
+		db.Create(&chModel.ChoreHistory{ChoreID: chore.ID})
+		db.Create(&chModel.ChoreRevision{ChoreID: chore.ID, Action: chModel.RevisionActionCreated, Snapshot: "{}"})
+		if err := choreRepo.SoftDelete(ctx, chore.ID, 1); err != nil {
+			t.Fatalf("SoftDelete() error = %v", err)
+		}
+	}
+	if _, err := choreRepo.GetChore(ctx, recent.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("GetChore() of a chore in the trash error = %v, want not found", err)
+	}
+	db.Unscoped().Model(&chModel.Chore{}).Where("id = ?", expired.ID).Update("deleted_at", time.Now().UTC().Add(-48*time.Hour))
+
+	if err := purger.Run(ctx, time.Now().UTC()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	trashed, err := choreRepo.GetTrashedChores(ctx, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trashed) != 1 || trashed[0].ID != recent.ID {
+		t.Fatalf("trash = %+v, want only the chore deleted within the retention", trashed)
+	}
+	var history int64
+	db.Model(&chModel.ChoreHistory{}).Where("chore_id = ?", expired.ID).Count(&history)
+	if history != 0 {
+		t.Errorf("purged chore has %d history entries, want none", history)
+	}
+	var revisions int64
+	db.Model(&chModel.ChoreRevision{}).Where("chore_id = ?", expired.ID).Count(&revisions)
+	if revisions != 0 {
+		t.Errorf("purged chore has %d revisions, want none", revisions)
+	}
+
+	if err := choreRepo.RestoreChore(ctx, recent.ID, 1); err != nil {
+		t.Fatalf("RestoreChore() error = %v", err)
+	}
+	restored, err := choreRepo.GetChore(ctx, recent.ID)
+	if err != nil {
+		t.Fatalf("GetChore() of the restored chore error = %v", err)
+	}
+	db.Model(&chModel.ChoreHistory{}).Where("chore_id = ?", restored.ID).Count(&history)
+	if history != 1 {
+		t.Errorf("restored chore has %d history entries, want its history", history)
+	}
+	var tombstones int64
+	db.Model(&oModel.Tombstone{}).Where("entity_id = ?", restored.ID).Count(&tombstones)
+	if tombstones != 0 {
+		t.Errorf("restored chore has %d tombstones, want none", tombstones)
+	}
+}
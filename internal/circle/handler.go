@@ -6,32 +6,47 @@ import (
 	"strconv"
 	"time"
 
+	"donetick.com/core/config"
 	auth "donetick.com/core/internal/authorization"
 	"donetick.com/core/internal/chore"
 	chRepo "donetick.com/core/internal/chore/repo"
 	cModel "donetick.com/core/internal/circle/model"
 	cRepo "donetick.com/core/internal/circle/repo"
+	"donetick.com/core/internal/events"
+	eModel "donetick.com/core/internal/events/model"
+	eRepo "donetick.com/core/internal/events/repo"
+	nRepo "donetick.com/core/internal/notifier/repo"
 	pRepo "donetick.com/core/internal/points/repo"
 	uModel "donetick.com/core/internal/user/model"
 	uRepo "donetick.com/core/internal/user/repo"
+	"donetick.com/core/internal/utils"
 	"donetick.com/core/logging"
 	jwt "github.com/appleboy/gin-jwt/v2"
 	"github.com/gin-gonic/gin"
 )
 
 type Handler struct {
-	circleRepo *cRepo.CircleRepository
-	userRepo   *uRepo.UserRepository
-	choreRepo  *chRepo.ChoreRepository
-	pointRepo  *pRepo.PointsRepository
+	circleRepo       *cRepo.CircleRepository
+	userRepo         *uRepo.UserRepository
+	choreRepo        *chRepo.ChoreRepository
+	pointRepo        *pRepo.PointsRepository
+	notificationRepo *nRepo.NotificationRepository
+	deliveryRepo     *eRepo.WebhookDeliveryRepository
+	eventsProducer   *events.EventsProducer
+	egress           *utils.EgressPolicy
 }
 
-func NewHandler(cr *cRepo.CircleRepository, ur *uRepo.UserRepository, c *chRepo.ChoreRepository, pr *pRepo.PointsRepository) *Handler {
+func NewHandler(cr *cRepo.CircleRepository, ur *uRepo.UserRepository, c *chRepo.ChoreRepository, pr *pRepo.PointsRepository,
+	nr *nRepo.NotificationRepository, dr *eRepo.WebhookDeliveryRepository, ep *events.EventsProducer, cfg *config.Config) *Handler {
 	return &Handler{
-		circleRepo: cr,
-		userRepo:   ur,
-		choreRepo:  c,
-		pointRepo:  pr,
+		circleRepo:       cr,
+		userRepo:         ur,
+		choreRepo:        c,
+		pointRepo:        pr,
+		notificationRepo: nr,
+		deliveryRepo:     dr,
+		eventsProducer:   ep,
+		egress:           utils.NewEgressPolicy(cfg),
 	}
 }
 
@@ -169,6 +184,7 @@ func (h *Handler) LeaveCircle(c *gin.Context) {
 		})
 		return
 	}
+	h.eventsProducer.MemberLeft(c, circleID, currentUser.WebhookURL, events.NewUserPayload(&currentUser.User), &currentUser.User)
 	c.JSON(200, gin.H{
 		"res": "User left circle successfully",
 	})
@@ -245,6 +261,7 @@ func (h *Handler) DeleteCircleMember(c *gin.Context) {
 		})
 		return
 	}
+	member := h.memberPayload(c, circleID, memberIDToDeleted)
 	orginalCircleID, err := h.circleRepo.GetUserOriginalCircle(c, memberIDToDeleted)
 	if handleUserLeavingCircle(h, c, &uModel.User{ID: memberIDToDeleted, CircleID: circleID}, orginalCircleID) != nil {
 		log.Error("Error handling user leaving circle:", err)
@@ -262,6 +279,7 @@ func (h *Handler) DeleteCircleMember(c *gin.Context) {
 		})
 		return
 	}
+	h.eventsProducer.MemberRemoved(c, circleID, currentUser.WebhookURL, member, &currentUser.User)
 	c.JSON(200, gin.H{
 		"res": "User deleted from circle successfully",
 	})
@@ -421,6 +439,11 @@ func (h *Handler) AcceptJoinRequest(c *gin.Context) {
 		})
 		return
 	}
+	h.eventsProducer.MemberJoined(c, currentUser.CircleID, currentUser.WebhookURL, events.UserPayload{
+		ID:          requestedCircle.UserID,
+		Username:    requestedCircle.Username,
+		DisplayName: requestedCircle.DisplayName,
+	}, requestedCircle.Role, &currentUser.User)
 
 	c.JSON(200, gin.H{
 		"res": "Join request accepted successfully",
@@ -527,6 +550,11 @@ func (h *Handler) RedeemPoints(c *gin.Context) {
 		})
 		return
 	}
+	h.eventsProducer.PointsRedeemed(c, currentUser.CircleID, currentUser.WebhookURL, events.UserPayload{
+		ID:          member.UserID,
+		Username:    member.Username,
+		DisplayName: member.DisplayName,
+	}, redeemReq.Points, &currentUser.User)
 
 	c.JSON(200, gin.H{
 		"res": "Points redeemed successfully",
@@ -573,6 +601,7 @@ func (h *Handler) ChangeMemberRole(c *gin.Context) {
 	isAdmin := false
 	memberFound := false
 	adminCount := 0
+	var member events.UserPayload
 	for _, user := range users {
 		if user.Role == "admin" {
 			adminCount++
@@ -582,6 +611,7 @@ func (h *Handler) ChangeMemberRole(c *gin.Context) {
 		}
 		if user.UserID == req.MemberID {
 			memberFound = true
+			member = events.UserPayload{ID: user.UserID, Username: user.Username, DisplayName: user.DisplayName}
 		}
 	}
 	if !isAdmin {
@@ -606,12 +636,198 @@ func (h *Handler) ChangeMemberRole(c *gin.Context) {
 		return
 	}
 
+	h.eventsProducer.MemberRoleChanged(c, currentUser.CircleID, currentUser.WebhookURL, member, string(req.Role), &currentUser.User)
 	c.JSON(200, gin.H{
 		"res": "Member role changed successfully",
 	})
 
 }
 
+// GetFailedNotifications lists the notifications of the circle that could not be delivered after all retries.
+func (h *Handler) GetFailedNotifications(c *gin.Context) {
+	log := logging.FromContext(c)
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+
+	if !h.requireCircleAdmin(c, currentUser.CircleID, currentUser.ID) {
+		return
+	}
+
+	notifications, err := h.notificationRepo.GetFailedNotifications(c, currentUser.CircleID)
+	if err != nil {
+		log.Error("Error getting failed notifications:", err)
+		c.JSON(500, gin.H{
+			"error": "Error getting failed notifications",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"res": notifications,
+	})
+}
+
+// GetWebhookDeliveries returns the webhook delivery log of the circle, newest first.
+func (h *Handler) GetWebhookDeliveries(c *gin.Context) {
+	log := logging.FromContext(c)
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+	if !h.requireCircleAdmin(c, currentUser.CircleID, currentUser.ID) {
+		return
+	}
+
+	limit := 50
+	if rawLimit := c.Query("limit"); rawLimit != "" {
+		parsed, err := strconv.Atoi(rawLimit)
+		if err != nil || parsed < 1 || parsed > 500 {
+			c.JSON(400, gin.H{
+				"error": "limit must be between 1 and 500",
+			})
+			return
+		}
+		limit = parsed
+	}
+
+	deliveries, err := h.deliveryRepo.GetCircleDeliveries(c, currentUser.CircleID, limit)
+	if err != nil {
+		log.Error("Error getting webhook deliveries:", err)
+		c.JSON(500, gin.H{
+			"error": "Error getting webhook deliveries",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"res": deliveries,
+	})
+}
+
+// RedeliverWebhook sends the payload of a past delivery again to the current URL of its subscription or circle webhook.
+func (h *Handler) RedeliverWebhook(c *gin.Context) {
+	log := logging.FromContext(c)
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+	if !h.requireCircleAdmin(c, currentUser.CircleID, currentUser.ID) {
+		return
+	}
+
+	deliveryID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{
+			"error": "Invalid delivery ID",
+		})
+		return
+	}
+	original, err := h.deliveryRepo.GetDelivery(c, deliveryID)
+	if err != nil || original.CircleID != currentUser.CircleID {
+		c.JSON(404, gin.H{
+			"error": "Delivery not found",
+		})
+		return
+	}
+	target, ok := h.redeliveryURL(c, original)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.eventsProducer.Redeliver(c, original, target)
+	if err != nil {
+		log.Error("Error redelivering webhook:", err)
+		c.JSON(500, gin.H{
+			"error": "Error redelivering webhook",
+		})
+		return
+	}
+
+	c.JSON(202, gin.H{
+		"res": delivery,
+	})
+}
+
+// redeliveryURL returns the current URL of the subscription or the circle webhook the delivery was sent to,
+// writing a 400 and returning false when it no longer exists.
+func (h *Handler) redeliveryURL(c *gin.Context, delivery *eModel.WebhookDelivery) (string, bool) {
+	if delivery.SubscriptionID != nil {
+		subscription, err := h.deliveryRepo.GetSubscription(c, *delivery.SubscriptionID)
+		if err != nil {
+			c.JSON(400, gin.H{
+				"error": "The webhook subscription was deleted",
+			})
+			return "", false
+		}
+		return subscription.URL, true
+	}
+
+	circle, err := h.circleRepo.GetCircleByID(c, delivery.CircleID)
+	if err != nil {
+		logging.FromContext(c).Error("Error getting circle:", err)
+		c.JSON(500, gin.H{
+			"error": "Error getting circle",
+		})
+		return "", false
+	}
+	if circle.WebhookURL == nil || *circle.WebhookURL == "" {
+		c.JSON(400, gin.H{
+			"error": "The circle has no webhook",
+		})
+		return "", false
+	}
+	return *circle.WebhookURL, true
+}
+
+// memberPayload describes a member of the circle for an event, only the id is known when the member can't be loaded.
+func (h *Handler) memberPayload(c *gin.Context, circleID int, userID int) events.UserPayload {
+	member := events.UserPayload{ID: userID}
+	users, err := h.circleRepo.GetCircleUsers(c, circleID)
+	if err != nil {
+		return member
+	}
+	for _, user := range users {
+		if user.UserID == userID {
+			member.Username = user.Username
+			member.DisplayName = user.DisplayName
+			break
+		}
+	}
+	return member
+}
+
+// requireCircleAdmin writes a 403 and returns false unless the user is an admin of the circle.
+func (h *Handler) requireCircleAdmin(c *gin.Context, circleID int, userID int) bool {
+	members, err := h.circleRepo.GetCircleUsers(c, circleID)
+	if err != nil {
+		logging.FromContext(c).Error("Error getting circle members:", err)
+		c.JSON(500, gin.H{
+			"error": "Error getting circle members",
+		})
+		return false
+	}
+	for _, member := range members {
+		if member.UserID == userID && member.Role == "admin" {
+			return true
+		}
+	}
+	c.JSON(403, gin.H{
+		"error": "You are not an admin of this circle",
+	})
+	return false
+}
+
 func Routes(router *gin.Engine, h *Handler, auth *jwt.GinJWTMiddleware) {
 	log.Println("Registering routes")
 
@@ -627,6 +843,13 @@ func Routes(router *gin.Engine, h *Handler, auth *jwt.GinJWTMiddleware) {
 		circleRoutes.DELETE("/leave", h.LeaveCircle)
 		circleRoutes.DELETE("/:id/members/delete", h.DeleteCircleMember)
 		circleRoutes.POST("/:id/members/points/redeem", h.RedeemPoints)
+		circleRoutes.GET("/notifications/failed", h.GetFailedNotifications)
+		circleRoutes.GET("/webhook/deliveries", h.GetWebhookDeliveries)
+		circleRoutes.POST("/webhook/deliveries/:id/redeliver", h.RedeliverWebhook)
+		circleRoutes.GET("/webhook/subscriptions", h.GetWebhookSubscriptions)
+		circleRoutes.POST("/webhook/subscriptions", h.CreateWebhookSubscription)
+		circleRoutes.PUT("/webhook/subscriptions/:id", h.UpdateWebhookSubscription)
+		circleRoutes.DELETE("/webhook/subscriptions/:id", h.DeleteWebhookSubscription)
 
 	}
 
@@ -168,6 +168,14 @@ func (r *CircleRepository) RedeemPoints(c context.Context, circleID int, userID
 	return nil
 }
 
+func (r *CircleRepository) SetWebhookEvents(c context.Context, circleID int, eventTypes string) error {
+	return r.db.WithContext(c).Model(&cModel.Circle{}).Where("id = ?", circleID).Update("webhook_events", eventTypes).Error
+}
+
+func (r *CircleRepository) SetWebhookSecret(c context.Context, circleID int, secret string) error {
+	return r.db.WithContext(c).Model(&cModel.Circle{}).Where("id = ?", circleID).Update("webhook_secret", secret).Error
+}
+
 func (r *CircleRepository) SetWebhookURL(c context.Context, circleID int, webhookURL *string) error {
 	return r.db.WithContext(c).Model(&cModel.Circle{}).Where("id = ?", circleID).Update("webhook_url", webhookURL).Error
 }
@@ -0,0 +1,290 @@
+package circle
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	auth "donetick.com/core/internal/authorization"
+	"donetick.com/core/internal/events"
+	eModel "donetick.com/core/internal/events/model"
+	"donetick.com/core/internal/utils"
+	"donetick.com/core/logging"
+	"github.com/gin-gonic/gin"
+)
+
+const maxWebhookSubscriptions = 20
+
+type webhookSubscriptionReq struct {
+	URL          string   `json:"url" binding:"required"`
+	Events       []string `json:"events"` // event types to send, all when empty
+	Labels       []int    `json:"labels"` // only events of tasks and labels with one of these labels, all when empty
+	IsEnabled    *bool    `json:"isEnabled"`
+	RotateSecret bool     `json:"rotateSecret"`
+}
+
+type webhookSubscriptionResp struct {
+	*eModel.WebhookSubscription
+	Events []string `json:"events"`
+	Labels []int    `json:"labels"`
+	Secret string   `json:"secret,omitempty"` // only returned when the secret is created or rotated
+}
+
+func newWebhookSubscriptionResp(subscription *eModel.WebhookSubscription, withSecret bool) webhookSubscriptionResp {
+	resp := webhookSubscriptionResp{WebhookSubscription: subscription, Events: []string{}, Labels: []int{}}
+	for _, eventType := range strings.Split(subscription.EventTypes, ",") {
+		if eventType != "" {
+			resp.Events = append(resp.Events, eventType)
+		}
+	}
+	for _, rawID := range strings.Split(subscription.LabelIDs, ",") {
+		if id, err := strconv.Atoi(rawID); err == nil {
+			resp.Labels = append(resp.Labels, id)
+		}
+	}
+	if withSecret {
+		resp.Secret = subscription.Secret
+	}
+	return resp
+}
+
+// validate checks the request and returns the event types and label IDs in the form they are stored.
+func (req *webhookSubscriptionReq) validate(egress *utils.EgressPolicy) (string, string, error) {
+	parsed, err := url.Parse(req.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", "", fmt.Errorf("URL must be an absolute http or https URL")
+	}
+	if err := egress.CheckHost(parsed.Hostname()); err != nil {
+		return "", "", fmt.Errorf("URL must not be a loopback or private address")
+	}
+	for _, eventType := range req.Events {
+		if !events.IsValidEventType(events.EventType(eventType)) {
+			return "", "", fmt.Errorf("Unknown event type %s", eventType)
+		}
+	}
+	labelIDs := make([]string, 0, len(req.Labels))
+	for _, id := range req.Labels {
+		labelIDs = append(labelIDs, strconv.Itoa(id))
+	}
+	return strings.Join(req.Events, ","), strings.Join(labelIDs, ","), nil
+}
+
+func (h *Handler) GetWebhookSubscriptions(c *gin.Context) {
+	log := logging.FromContext(c)
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+	if !h.requireCircleAdmin(c, currentUser.CircleID, currentUser.ID) {
+		return
+	}
+
+	subscriptions, err := h.deliveryRepo.GetCircleSubscriptions(c, currentUser.CircleID)
+	if err != nil {
+		log.Error("Error getting webhook subscriptions:", err)
+		c.JSON(500, gin.H{
+			"error": "Error getting webhook subscriptions",
+		})
+		return
+	}
+	resp := make([]webhookSubscriptionResp, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		resp = append(resp, newWebhookSubscriptionResp(subscription, false))
+	}
+
+	c.JSON(200, gin.H{
+		"res": resp,
+	})
+}
+
+// CreateWebhookSubscription adds a webhook subscription to the circle, the response carries its signing
+// secret which is not returned again unless rotated.
+func (h *Handler) CreateWebhookSubscription(c *gin.Context) {
+	log := logging.FromContext(c)
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+	if !h.requireCircleAdmin(c, currentUser.CircleID, currentUser.ID) {
+		return
+	}
+
+	var req webhookSubscriptionReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+	eventTypes, labelIDs, err := req.validate(h.egress)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	existing, err := h.deliveryRepo.GetCircleSubscriptions(c, currentUser.CircleID)
+	if err != nil {
+		log.Error("Error getting webhook subscriptions:", err)
+		c.JSON(500, gin.H{
+			"error": "Error getting webhook subscriptions",
+		})
+		return
+	}
+	if len(existing) >= maxWebhookSubscriptions {
+		c.JSON(400, gin.H{
+			"error": fmt.Sprintf("A circle can have at most %d webhook subscriptions", maxWebhookSubscriptions),
+		})
+		return
+	}
+
+	secret, err := utils.GenerateWebhookSecret()
+	if err != nil {
+		log.Error("Error generating webhook secret:", err)
+		c.JSON(500, gin.H{
+			"error": "Error generating webhook secret",
+		})
+		return
+	}
+	now := time.Now().UTC()
+	subscription := &eModel.WebhookSubscription{
+		CircleID:   currentUser.CircleID,
+		URL:        req.URL,
+		Secret:     secret,
+		EventTypes: eventTypes,
+		LabelIDs:   labelIDs,
+		IsEnabled:  req.IsEnabled == nil || *req.IsEnabled,
+		CreatedBy:  currentUser.ID,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := h.deliveryRepo.CreateSubscription(c, subscription); err != nil {
+		log.Error("Error creating webhook subscription:", err)
+		c.JSON(500, gin.H{
+			"error": "Error creating webhook subscription",
+		})
+		return
+	}
+
+	c.JSON(201, gin.H{
+		"res": newWebhookSubscriptionResp(subscription, true),
+	})
+}
+
+func (h *Handler) UpdateWebhookSubscription(c *gin.Context) {
+	log := logging.FromContext(c)
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+	if !h.requireCircleAdmin(c, currentUser.CircleID, currentUser.ID) {
+		return
+	}
+	subscription, ok := h.circleSubscription(c, currentUser.CircleID)
+	if !ok {
+		return
+	}
+
+	var req webhookSubscriptionReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+	eventTypes, labelIDs, err := req.validate(h.egress)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	subscription.URL = req.URL
+	subscription.EventTypes = eventTypes
+	subscription.LabelIDs = labelIDs
+	if req.IsEnabled != nil {
+		subscription.IsEnabled = *req.IsEnabled
+	}
+	if req.RotateSecret {
+		subscription.Secret, err = utils.GenerateWebhookSecret()
+		if err != nil {
+			log.Error("Error generating webhook secret:", err)
+			c.JSON(500, gin.H{
+				"error": "Error generating webhook secret",
+			})
+			return
+		}
+	}
+	if err := h.deliveryRepo.UpdateSubscription(c, subscription); err != nil {
+		log.Error("Error updating webhook subscription:", err)
+		c.JSON(500, gin.H{
+			"error": "Error updating webhook subscription",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"res": newWebhookSubscriptionResp(subscription, req.RotateSecret),
+	})
+}
+
+func (h *Handler) DeleteWebhookSubscription(c *gin.Context) {
+	log := logging.FromContext(c)
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+	if !h.requireCircleAdmin(c, currentUser.CircleID, currentUser.ID) {
+		return
+	}
+	subscription, ok := h.circleSubscription(c, currentUser.CircleID)
+	if !ok {
+		return
+	}
+
+	if err := h.deliveryRepo.DeleteSubscription(c, subscription.ID); err != nil {
+		log.Error("Error deleting webhook subscription:", err)
+		c.JSON(500, gin.H{
+			"error": "Error deleting webhook subscription",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{})
+}
+
+// circleSubscription loads the subscription in the id path parameter, writing a 404 and returning false
+// unless it belongs to the circle.
+func (h *Handler) circleSubscription(c *gin.Context, circleID int) (*eModel.WebhookSubscription, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{
+			"error": "Invalid subscription ID",
+		})
+		return nil, false
+	}
+	subscription, err := h.deliveryRepo.GetSubscription(c, id)
+	if err != nil || subscription.CircleID != circleID {
+		c.JSON(404, gin.H{
+			"error": "Subscription not found",
+		})
+		return nil, false
+	}
+	return subscription, true
+}
@@ -0,0 +1,30 @@
+// Package dbtest opens databases for tests.
+package dbtest
+
+import (
+	"testing"
+
+	"donetick.com/core/internal/database"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// Open returns a new in-memory sqlite database with every table of the server migrated.
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	// every connection to :memory: is a new database
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := database.Migration(db); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return db
+}
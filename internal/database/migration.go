@@ -10,7 +10,10 @@ import (
 	"donetick.com/core/config"
 	chModel "donetick.com/core/internal/chore/model"
 	cModel "donetick.com/core/internal/circle/model"
+	eModel "donetick.com/core/internal/events/model"
+	lModel "donetick.com/core/internal/label/model"
 	nModel "donetick.com/core/internal/notifier/model"
+	oModel "donetick.com/core/internal/offline/model"
 	pModel "donetick.com/core/internal/points"
 	storageModel "donetick.com/core/internal/storage/model"
 	stModel "donetick.com/core/internal/subtask/model"
@@ -28,21 +31,29 @@ func Migration(db *gorm.DB) error {
 		cModel.Circle{},
 		cModel.UserCircle{},
 		chModel.ChoreAssignees{},
+		chModel.ChoreRevision{},
 		nModel.Notification{},
 		uModel.UserPasswordReset{},
 		uModel.MFASession{}, // Add MFA session model
 		tModel.Thing{},
 		tModel.ThingChore{},
 		tModel.ThingHistory{},
+		tModel.ThingHistoryBucket{},
+		tModel.ThingMember{},
 		uModel.APIToken{},
 		uModel.UserNotificationTarget{},
 		chModel.Label{},
+		lModel.Label{}, // has the updated_at of labels for delta sync
 		chModel.ChoreLabels{},
 		migrations.Migration{},
 		pModel.PointsHistory{},
 		stModel.SubTask{},
 		storageModel.StorageFile{},
 		storageModel.StorageUsage{},
+		eModel.WebhookDelivery{},
+		eModel.WebhookSubscription{},
+		oModel.Tombstone{},
+		oModel.AppliedOperation{},
 	); err != nil {
 		return err
 	}
@@ -0,0 +1,257 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	htmlTemplate "html/template"
+	"net/mail"
+	"strconv"
+	"strings"
+	textTemplate "text/template"
+	"time"
+
+	"donetick.com/core/config"
+	nModel "donetick.com/core/internal/notifier/model"
+	gomail "gopkg.in/gomail.v2"
+)
+
+// EmailNotifier delivers chore reminders through the SMTP settings of the EmailSender.
+type EmailNotifier struct {
+	sender      *EmailSender
+	host        string
+	dialAndSend func(m ...*gomail.Message) error
+}
+
+type reminderData struct {
+	Heading        string
+	ChoreName      string
+	DueDate        string
+	Assignee       string
+	ChoreURL       string
+	UnsubscribeURL string
+}
+
+var reminderHTML = htmlTemplate.Must(htmlTemplate.New("reminder.html").Parse(`<!DOCTYPE html>
+<html lang="en"><head><meta content="text/html; charset=utf-8" http-equiv="Content-Type"><title>{{.Heading}}</title></head>
+<body style="margin:0;padding:0;background-color:#F4F4F4;font-family:Arial,sans-serif;">
+<table role="presentation" width="100%" cellpadding="0" cellspacing="0" style="background-color:#F4F4F4;"><tr><td align="center" style="padding:40px 10px;">
+<table role="presentation" width="480" cellpadding="0" cellspacing="0" style="background-color:#FFFFFF;border-radius:8px;"><tr><td style="padding:40px 30px;">
+<h1 style="margin:0 0 16px;font-size:22px;color:#141414;">{{.Heading}}</h1>
+<p style="margin:0 0 8px;font-size:16px;color:#141414;"><strong>{{.ChoreName}}</strong></p>
+{{if .DueDate}}<p style="margin:0 0 8px;font-size:14px;color:#555555;">Due {{.DueDate}}</p>{{end}}
+{{if .Assignee}}<p style="margin:0 0 24px;font-size:14px;color:#555555;">Assigned to {{.Assignee}}</p>{{end}}
+{{if .ChoreURL}}<a href="{{.ChoreURL}}" style="display:inline-block;padding:12px 24px;background-color:#06B6D4;color:#FFFFFF;border-radius:6px;text-decoration:none;font-weight:bold;">Open in Donetick</a>{{end}}
+</td></tr></table>
+{{if .UnsubscribeURL}}<p style="margin:16px 0 0;font-size:12px;color:#888888;">You get this email because email notifications are enabled for your Donetick account. <a href="{{.UnsubscribeURL}}" style="color:#888888;text-decoration:underline;">Unsubscribe</a></p>{{end}}
+</td></tr></table>
+</body></html>
+`))
+
+var reminderText = textTemplate.Must(textTemplate.New("reminder.txt").Parse(`{{.Heading}}
+
+{{.ChoreName}}
+{{if .DueDate}}Due {{.DueDate}}
+{{end}}{{if .Assignee}}Assigned to {{.Assignee}}
+{{end}}{{if .ChoreURL}}
+Open in Donetick: {{.ChoreURL}}
+{{end}}{{if .UnsubscribeURL}}
+Unsubscribe from email notifications: {{.UnsubscribeURL}}
+{{end}}`))
+
+func NewEmailNotifier(sender *EmailSender, cfg *config.Config) *EmailNotifier {
+	return &EmailNotifier{
+		sender:      sender,
+		host:        cfg.EmailConfig.Host,
+		dialAndSend: sender.client.DialAndSend,
+	}
+}
+
+func (en *EmailNotifier) Platform() nModel.NotificationPlatform {
+	return nModel.NotificationPlatformEmail
+}
+
+func (en *EmailNotifier) CheckConfig() error {
+	if en.host == "" {
+		return errors.New("email.host is not set")
+	}
+	return nil
+}
+
+// ValidateTarget only checks the address, the user handler sends it a confirmation link before it is saved.
+func (en *EmailNotifier) ValidateTarget(targetID string) error {
+	address, err := mail.ParseAddress(targetID)
+	if err != nil || address.Address != targetID {
+		return errors.New("email target must be a plain email address")
+	}
+	return nil
+}
+
+func (en *EmailNotifier) SendNotification(c context.Context, notification *nModel.NotificationDetails) error {
+	if notification.TargetID == "" {
+		return errors.New("unable to send notification, targetID is empty")
+	}
+
+	data := en.reminderData(notification)
+	var html, text bytes.Buffer
+	if err := reminderHTML.Execute(&html, data); err != nil {
+		return err
+	}
+	if err := reminderText.Execute(&text, data); err != nil {
+		return err
+	}
+
+	msg := gomail.NewMessage()
+	msg.SetHeader("From", en.sender.client.Username)
+	msg.SetHeader("To", notification.TargetID)
+	msg.SetHeader("Subject", fmt.Sprintf("%s: %s", data.Heading, data.ChoreName))
+	if data.UnsubscribeURL != "" {
+		// RFC 8058 one-click unsubscribe, the POST goes to the same URL
+		msg.SetHeader("List-Unsubscribe", "<"+data.UnsubscribeURL+">")
+		msg.SetHeader("List-Unsubscribe-Post", "List-Unsubscribe=One-Click")
+	}
+	msg.SetBody("text/plain", text.String())
+	msg.AddAlternative("text/html", html.String())
+
+	return en.dialAndSend(msg)
+}
+
+func (en *EmailNotifier) reminderData(notification *nModel.NotificationDetails) reminderData {
+	data := reminderData{Heading: "Reminder"}
+	switch notification.RawEvent["type"] {
+	case "due":
+		data.Heading = "Due today"
+	case "pre_due":
+		data.Heading = "Due soon"
+	case "overdue":
+		data.Heading = "Overdue"
+	}
+	if name, ok := notification.RawEvent["name"].(string); ok && name != "" {
+		data.ChoreName = name
+	} else {
+		// fall back to the chat message without its markdown
+		data.ChoreName = strings.ReplaceAll(notification.Text, "*", "")
+	}
+	if assignee, ok := notification.RawEvent["assignee"].(string); ok {
+		data.Assignee = assignee
+	}
+	switch dueDate := notification.RawEvent["due_date"].(type) {
+	case time.Time:
+		data.DueDate = dueDate.UTC().Format("Monday, January 2 at 15:04 UTC")
+	case *time.Time:
+		if dueDate != nil {
+			data.DueDate = dueDate.UTC().Format("Monday, January 2 at 15:04 UTC")
+		}
+	case string:
+		if parsed, err := time.Parse(time.RFC3339, dueDate); err == nil {
+			data.DueDate = parsed.UTC().Format("Monday, January 2 at 15:04 UTC")
+		} else {
+			data.DueDate = dueDate
+		}
+	}
+
+	appHost := strings.TrimRight(en.sender.appHost, "/")
+	if appHost != "" {
+		if notification.ChoreID != 0 {
+			data.ChoreURL = fmt.Sprintf("%s/chores/%d", appHost, notification.ChoreID)
+		}
+		if notification.UserID != 0 {
+			data.UnsubscribeURL = appHost + "/api/v1/auth/unsubscribe?t=" + en.sender.UnsubscribeToken(notification.UserID, notification.TargetID)
+		}
+	}
+	return data
+}
+
+// UnsubscribeToken signs the user and address so the unsubscribe link works without logging in,
+// and stops working once the user changes their notification email.
+func (es *EmailSender) UnsubscribeToken(userID int, address string) string {
+	payload := strconv.Itoa(userID) + ":" + address
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + base64.RawURLEncoding.EncodeToString(es.unsubscribeSignature(payload))
+}
+
+// DecodeUnsubscribeToken verifies a token made by UnsubscribeToken and returns the user and address.
+func (es *EmailSender) DecodeUnsubscribeToken(token string) (int, string, error) {
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return 0, "", errors.New("invalid unsubscribe token")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return 0, "", errors.New("invalid unsubscribe token")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, es.unsubscribeSignature(string(payload))) {
+		return 0, "", errors.New("invalid unsubscribe token")
+	}
+	rawUserID, address, _ := strings.Cut(string(payload), ":")
+	userID, err := strconv.Atoi(rawUserID)
+	if err != nil {
+		return 0, "", errors.New("invalid unsubscribe token")
+	}
+	return userID, address, nil
+}
+
+func (es *EmailSender) unsubscribeSignature(payload string) []byte {
+	return es.signature("unsubscribe", payload)
+}
+
+func (es *EmailSender) signature(purpose string, payload string) []byte {
+	mac := hmac.New(sha256.New, es.linkKey)
+	mac.Write([]byte(purpose + ":" + payload))
+	return mac.Sum(nil)
+}
+
+// TargetConfirmationToken signs the user and the address they want their reminders sent to, reminders only
+// go to the address once the link with the token in the email sent to it is opened.
+func (es *EmailSender) TargetConfirmationToken(userID int, address string, expiresAt time.Time) string {
+	payload := strconv.Itoa(userID) + ":" + strconv.FormatInt(expiresAt.Unix(), 10) + ":" + address
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + base64.RawURLEncoding.EncodeToString(es.signature("confirm_target", payload))
+}
+
+// DecodeTargetConfirmationToken verifies a token made by TargetConfirmationToken that has not expired and
+// returns the user and address.
+func (es *EmailSender) DecodeTargetConfirmationToken(token string) (int, string, error) {
+	invalid := errors.New("invalid confirmation token")
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return 0, "", invalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return 0, "", invalid
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, es.signature("confirm_target", string(payload))) {
+		return 0, "", invalid
+	}
+	parts := strings.SplitN(string(payload), ":", 3)
+	if len(parts) != 3 {
+		return 0, "", invalid
+	}
+	userID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, "", invalid
+	}
+	expiresAt, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return 0, "", errors.New("confirmation link expired")
+	}
+	return userID, parts[2], nil
+}
+
+// SendTargetConfirmationEmail sends the link that confirms the address as the notification target of the user.
+func (es *EmailSender) SendTargetConfirmationEmail(c context.Context, userID int, address string) error {
+	link := strings.TrimRight(es.appHost, "/") + "/api/v1/auth/confirm_email_target?t=" +
+		es.TargetConfirmationToken(userID, address, time.Now().Add(24*time.Hour))
+	msg := gomail.NewMessage()
+	msg.SetHeader("From", es.client.Username)
+	msg.SetHeader("To", address)
+	msg.SetHeader("Subject", "Donetick! Confirm your notification email")
+	msg.SetBody("text/plain", "Open this link within 24 hours to get your Donetick reminders at this address:\n\n"+link+
+		"\n\nIf you did not ask for this, ignore this email.\n")
+	return es.client.DialAndSend(msg)
+}
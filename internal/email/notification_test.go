@@ -0,0 +1,121 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"donetick.com/core/config"
+	nModel "donetick.com/core/internal/notifier/model"
+	gomail "gopkg.in/gomail.v2"
+)
+
+func TestEmailNotifierSendNotification(t *testing.T) {
+	cfg := &config.Config{
+		EmailConfig: config.EmailConfig{Host: "smtp.example.com", Port: 587, Email: "noreply@example.com", AppHost: "https://donetick.example.com/"},
+		Jwt:         config.JwtConfig{Secret: "secret"},
+	}
+	sender := NewEmailSender(cfg)
+	en := NewEmailNotifier(sender, cfg)
+	var sent []*gomail.Message
+	en.dialAndSend = func(m ...*gomail.Message) error {
+		sent = append(sent, m...)
+		return nil
+	}
+
+	err := en.SendNotification(context.Background(), &nModel.NotificationDetails{
+		Notification: nModel.Notification{
+			ChoreID:  12,
+			UserID:   3,
+			TargetID: "alex@example.com",
+			Text:     "🚨 *Dishes <3* is now 24 hours overdue.",
+			RawEvent: nModel.JSONB{
+				"type":     "overdue",
+				"name":     "Dishes <3",
+				"assignee": "Alex",
+				"due_date": "2025-01-07T09:00:00Z",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SendNotification() error = %v", err)
+	}
+	if len(sent) != 1 {
+		t.Fatalf("sent %d messages, want 1", len(sent))
+	}
+	msg := sent[0]
+	if got := msg.GetHeader("Subject"); len(got) != 1 || got[0] != "Overdue: Dishes <3" {
+		t.Errorf("Subject = %v", got)
+	}
+	token := sender.UnsubscribeToken(3, "alex@example.com")
+	unsubscribeURL := "https://donetick.example.com/api/v1/auth/unsubscribe?t=" + token
+	if got := msg.GetHeader("List-Unsubscribe"); len(got) != 1 || got[0] != "<"+unsubscribeURL+">" {
+		t.Errorf("List-Unsubscribe = %v", got)
+	}
+
+	var raw bytes.Buffer
+	if _, err := msg.WriteTo(&raw); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	body := strings.ReplaceAll(raw.String(), "=\r\n", "")
+	for _, want := range []string{
+		"Content-Type: text/plain",
+		"Content-Type: text/html",
+		"Due Tuesday, January 7 at 09:00 UTC",
+		"Open in Donetick: https://donetick.example.com/chores/12",
+		"Dishes &lt;3",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("message is missing %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestUnsubscribeToken(t *testing.T) {
+	sender := NewEmailSender(&config.Config{Jwt: config.JwtConfig{Secret: "secret"}})
+	token := sender.UnsubscribeToken(3, "alex@example.com")
+
+	userID, address, err := sender.DecodeUnsubscribeToken(token)
+	if err != nil || userID != 3 || address != "alex@example.com" {
+		t.Errorf("DecodeUnsubscribeToken() = %d, %q, %v", userID, address, err)
+	}
+
+	other := NewEmailSender(&config.Config{Jwt: config.JwtConfig{Secret: "other"}})
+	if _, _, err := other.DecodeUnsubscribeToken(token); err == nil {
+		t.Errorf("DecodeUnsubscribeToken() accepted a token signed with another secret")
+	}
+	if _, _, err := sender.DecodeUnsubscribeToken("bogus"); err == nil {
+		t.Errorf("DecodeUnsubscribeToken() accepted a malformed token")
+	}
+	if string(sender.linkKey) == "secret" {
+		t.Errorf("links are signed with the JWT secret")
+	}
+
+	// with a link secret the links still work after the JWT secret is rotated
+	withLinkSecret := NewEmailSender(&config.Config{Jwt: config.JwtConfig{Secret: "secret"}, EmailConfig: config.EmailConfig{LinkSecret: "links"}})
+	rotated := NewEmailSender(&config.Config{Jwt: config.JwtConfig{Secret: "rotated"}, EmailConfig: config.EmailConfig{LinkSecret: "links"}})
+	if _, _, err := rotated.DecodeUnsubscribeToken(withLinkSecret.UnsubscribeToken(3, "alex@example.com")); err != nil {
+		t.Errorf("DecodeUnsubscribeToken() after rotating the JWT secret error = %v", err)
+	}
+}
+
+func TestTargetConfirmationToken(t *testing.T) {
+	sender := NewEmailSender(&config.Config{Jwt: config.JwtConfig{Secret: "secret"}})
+	token := sender.TargetConfirmationToken(3, "alex@example.com", time.Now().Add(time.Hour))
+
+	userID, address, err := sender.DecodeTargetConfirmationToken(token)
+	if err != nil || userID != 3 || address != "alex@example.com" {
+		t.Errorf("DecodeTargetConfirmationToken() = %d, %q, %v", userID, address, err)
+	}
+
+	expired := sender.TargetConfirmationToken(3, "alex@example.com", time.Now().Add(-time.Hour))
+	if _, _, err := sender.DecodeTargetConfirmationToken(expired); err == nil {
+		t.Errorf("DecodeTargetConfirmationToken() accepted an expired token")
+	}
+	// an unsubscribe link can't confirm an address
+	if _, _, err := sender.DecodeTargetConfirmationToken(sender.UnsubscribeToken(3, "alex@example.com")); err == nil {
+		t.Errorf("DecodeTargetConfirmationToken() accepted an unsubscribe token")
+	}
+}
@@ -2,6 +2,8 @@ package email
 
 import (
 	"context"
+	"crypto/hkdf"
+	"crypto/sha256"
 	"encoding/base64"
 	"fmt"
 	"strings"
@@ -13,6 +15,7 @@ import (
 type EmailSender struct {
 	client  *gomail.Dialer
 	appHost string
+	linkKey []byte // signs the links in emails
 }
 
 func NewEmailSender(conf *config.Config) *EmailSender {
@@ -26,9 +29,24 @@ func NewEmailSender(conf *config.Config) *EmailSender {
 
 		client:  client,
 		appHost: conf.EmailConfig.AppHost,
+		linkKey: linkKey(conf),
 	}
 }
 
+// linkKey returns the key of the links in emails, a key derived from the JWT secret when no link secret is
+// configured so tokens in emails are never signed with the key of the sessions.
+func linkKey(conf *config.Config) []byte {
+	if conf.EmailConfig.LinkSecret != "" {
+		return []byte(conf.EmailConfig.LinkSecret)
+	}
+	key, err := hkdf.Key(sha256.New, []byte(conf.Jwt.Secret), nil, "donetick email links", sha256.Size)
+	if err != nil {
+		// only fails for a key length sha256 can't produce
+		panic(err)
+	}
+	return key
+}
+
 func (es *EmailSender) SendVerificationEmail(to, code string) error {
 	// msg := []byte(fmt.Sprintf("To: %s\r\nSubject: %s\r\n\r\n%s\r\n", to, subject, body))
 	msg := gomail.NewMessage()
@@ -0,0 +1,350 @@
+package events
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	chModel "donetick.com/core/internal/chore/model"
+	uModel "donetick.com/core/internal/user/model"
+)
+
+// EventSchemaVersion is sent as the version of every event. Fields are only ever added to a payload
+// within a version, renaming or removing one bumps it.
+const EventSchemaVersion = 1
+
+const (
+	EventTypeTaskCreated         EventType = "task.created"
+	EventTypeTaskUpdated         EventType = "task.updated"
+	EventTypeTaskReassigned      EventType = "task.reassigned"
+	EventTypeTaskDeleted         EventType = "task.deleted"
+	EventTypeTaskRestored        EventType = "task.restored" // out of the trash
+	EventTypeTaskArchived        EventType = "task.archived"
+	EventTypeTaskUnarchived      EventType = "task.unarchived"
+	EventTypeTaskPriorityChanged EventType = "task.priority_changed"
+	EventTypeTaskDueDateChanged  EventType = "task.due_date_changed"
+	EventTypeTaskLabelsChanged   EventType = "task.labels_changed"
+	EventTypeLabelCreated        EventType = "label.created"
+	EventTypeLabelUpdated        EventType = "label.updated"
+	EventTypeLabelDeleted        EventType = "label.deleted"
+	EventTypeMemberJoined        EventType = "circle.member_joined"
+	EventTypeMemberLeft          EventType = "circle.member_left"
+	EventTypeMemberRemoved       EventType = "circle.member_removed"
+	EventTypeMemberRoleChanged   EventType = "circle.member_role_changed"
+	EventTypePointsRedeemed      EventType = "points.redeemed"
+)
+
+// EventTypes is the catalog of events a circle can subscribe to.
+var EventTypes = []EventType{
+	EventTypeTaskCreated,
+	EventTypeTaskUpdated,
+	EventTypeTaskReassigned,
+	EventTypeTaskDeleted,
+	EventTypeTaskRestored,
+	EventTypeTaskArchived,
+	EventTypeTaskUnarchived,
+	EventTypeTaskPriorityChanged,
+	EventTypeTaskDueDateChanged,
+	EventTypeTaskLabelsChanged,
+	EventTypeTaskCompleted,
+	EventTypeTaskSkipped,
+	EventTypeTaskReminder,
+	EventTypeSubTaskCompleted,
+	EventTypeThingChanged,
+	EventTypeLabelCreated,
+	EventTypeLabelUpdated,
+	EventTypeLabelDeleted,
+	EventTypeMemberJoined,
+	EventTypeMemberLeft,
+	EventTypeMemberRemoved,
+	EventTypeMemberRoleChanged,
+	EventTypePointsRedeemed,
+}
+
+func IsValidEventType(eventType EventType) bool {
+	for _, known := range EventTypes {
+		if known == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+type UserPayload struct {
+	ID          int    `json:"id"`
+	Username    string `json:"username"`
+	DisplayName string `json:"displayName"`
+}
+
+type LabelPayload struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+type ChorePayload struct {
+	ID            int            `json:"id"`
+	Name          string         `json:"name"`
+	CircleID      int            `json:"circleId"`
+	FrequencyType string         `json:"frequencyType"`
+	NextDueDate   *time.Time     `json:"nextDueDate"`
+	AssignedTo    int            `json:"assignedTo"`
+	Assignees     []int          `json:"assignees"`
+	Priority      int            `json:"priority"`
+	Points        *int           `json:"points"`
+	IsActive      bool           `json:"isActive"`
+	Labels        []LabelPayload `json:"labels"`
+}
+
+// FieldChange is the value of a chore field before and after an update.
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+type ChoreEventData struct {
+	Chore   ChorePayload           `json:"chore"`
+	Actor   UserPayload            `json:"actor"`
+	Changes map[string]FieldChange `json:"changes,omitempty"`
+}
+
+type LabelEventData struct {
+	Label LabelPayload `json:"label"`
+	Actor UserPayload  `json:"actor"`
+}
+
+type MemberEventData struct {
+	CircleID int         `json:"circleId"`
+	Member   UserPayload `json:"member"`
+	Role     string      `json:"role"`
+	Actor    UserPayload `json:"actor"`
+}
+
+type PointsEventData struct {
+	CircleID int         `json:"circleId"`
+	Member   UserPayload `json:"member"`
+	Points   int         `json:"points"`
+	Actor    UserPayload `json:"actor"`
+}
+
+func NewUserPayload(user *uModel.User) UserPayload {
+	if user == nil {
+		return UserPayload{}
+	}
+	return UserPayload{ID: user.ID, Username: user.Username, DisplayName: user.DisplayName}
+}
+
+func NewChorePayload(chore *chModel.Chore) ChorePayload {
+	payload := ChorePayload{
+		ID:            chore.ID,
+		Name:          chore.Name,
+		CircleID:      chore.CircleID,
+		FrequencyType: string(chore.FrequencyType),
+		NextDueDate:   chore.NextDueDate,
+		AssignedTo:    chore.AssignedTo,
+		Assignees:     []int{},
+		Priority:      chore.Priority,
+		Points:        chore.Points,
+		IsActive:      chore.IsActive,
+		Labels:        []LabelPayload{},
+	}
+	for _, assignee := range chore.Assignees {
+		payload.Assignees = append(payload.Assignees, assignee.UserID)
+	}
+	if chore.LabelsV2 != nil {
+		for _, label := range *chore.LabelsV2 {
+			payload.Labels = append(payload.Labels, LabelPayload{ID: label.ID, Name: label.Name, Color: label.Color})
+		}
+	}
+	return payload
+}
+
+func (p *EventsProducer) choreEvent(ctx context.Context, webhookURL *string, eventType EventType, chore *chModel.Chore, actor *uModel.User, changes map[string]FieldChange) {
+	p.publishEvent(ctx, chore.CircleID, Event{
+		Type:      eventType,
+		URL:       urlOrEmpty(webhookURL),
+		Timestamp: time.Now(),
+		Data:      ChoreEventData{Chore: NewChorePayload(chore), Actor: NewUserPayload(actor), Changes: changes},
+		labels:    labelIDs(chore),
+		choreID:   chore.ID,
+	})
+}
+
+func (p *EventsProducer) ChoreCreated(ctx context.Context, webhookURL *string, chore *chModel.Chore, actor *uModel.User) {
+	p.choreEvent(ctx, webhookURL, EventTypeTaskCreated, chore, actor, nil)
+}
+
+// ChoreUpdated sends task.updated with the changed fields, followed by the specific event of each
+// field that has one.
+func (p *EventsProducer) ChoreUpdated(ctx context.Context, webhookURL *string, before *chModel.Chore, after *chModel.Chore, actor *uModel.User) {
+	changes := choreChanges(before, after)
+	if len(changes) == 0 {
+		return
+	}
+	p.choreEvent(ctx, webhookURL, EventTypeTaskUpdated, after, actor, changes)
+	if change, ok := changes["assignedTo"]; ok {
+		p.choreEvent(ctx, webhookURL, EventTypeTaskReassigned, after, actor, map[string]FieldChange{"assignedTo": change})
+	}
+	if change, ok := changes["priority"]; ok {
+		p.choreEvent(ctx, webhookURL, EventTypeTaskPriorityChanged, after, actor, map[string]FieldChange{"priority": change})
+	}
+	if change, ok := changes["nextDueDate"]; ok {
+		p.choreEvent(ctx, webhookURL, EventTypeTaskDueDateChanged, after, actor, map[string]FieldChange{"nextDueDate": change})
+	}
+	if change, ok := changes["labels"]; ok {
+		p.choreEvent(ctx, webhookURL, EventTypeTaskLabelsChanged, after, actor, map[string]FieldChange{"labels": change})
+	}
+}
+
+func (p *EventsProducer) ChoreReassigned(ctx context.Context, webhookURL *string, chore *chModel.Chore, previousAssignee int, actor *uModel.User) {
+	p.choreEvent(ctx, webhookURL, EventTypeTaskReassigned, chore, actor, map[string]FieldChange{
+		"assignedTo": {From: previousAssignee, To: chore.AssignedTo},
+	})
+}
+
+func (p *EventsProducer) ChorePriorityChanged(ctx context.Context, webhookURL *string, chore *chModel.Chore, previousPriority int, actor *uModel.User) {
+	p.choreEvent(ctx, webhookURL, EventTypeTaskPriorityChanged, chore, actor, map[string]FieldChange{
+		"priority": {From: previousPriority, To: chore.Priority},
+	})
+}
+
+func (p *EventsProducer) ChoreDueDateChanged(ctx context.Context, webhookURL *string, chore *chModel.Chore, previousDueDate *time.Time, actor *uModel.User) {
+	p.choreEvent(ctx, webhookURL, EventTypeTaskDueDateChanged, chore, actor, map[string]FieldChange{
+		"nextDueDate": {From: previousDueDate, To: chore.NextDueDate},
+	})
+}
+
+func (p *EventsProducer) ChoreArchived(ctx context.Context, webhookURL *string, chore *chModel.Chore, actor *uModel.User) {
+	p.choreEvent(ctx, webhookURL, EventTypeTaskArchived, chore, actor, nil)
+}
+
+func (p *EventsProducer) ChoreUnarchived(ctx context.Context, webhookURL *string, chore *chModel.Chore, actor *uModel.User) {
+	p.choreEvent(ctx, webhookURL, EventTypeTaskUnarchived, chore, actor, nil)
+}
+
+func (p *EventsProducer) ChoreDeleted(ctx context.Context, webhookURL *string, chore *chModel.Chore, actor *uModel.User) {
+	p.choreEvent(ctx, webhookURL, EventTypeTaskDeleted, chore, actor, nil)
+}
+
+func (p *EventsProducer) ChoreRestored(ctx context.Context, webhookURL *string, chore *chModel.Chore, actor *uModel.User) {
+	p.choreEvent(ctx, webhookURL, EventTypeTaskRestored, chore, actor, nil)
+}
+
+func (p *EventsProducer) labelEvent(ctx context.Context, circleID int, webhookURL *string, eventType EventType, label LabelPayload, actor *uModel.User) {
+	p.publishEvent(ctx, circleID, Event{
+		Type:      eventType,
+		URL:       urlOrEmpty(webhookURL),
+		Timestamp: time.Now(),
+		Data:      LabelEventData{Label: label, Actor: NewUserPayload(actor)},
+		labels:    []int{label.ID},
+	})
+}
+
+func (p *EventsProducer) LabelCreated(ctx context.Context, circleID int, webhookURL *string, label LabelPayload, actor *uModel.User) {
+	p.labelEvent(ctx, circleID, webhookURL, EventTypeLabelCreated, label, actor)
+}
+
+func (p *EventsProducer) LabelUpdated(ctx context.Context, circleID int, webhookURL *string, label LabelPayload, actor *uModel.User) {
+	p.labelEvent(ctx, circleID, webhookURL, EventTypeLabelUpdated, label, actor)
+}
+
+func (p *EventsProducer) LabelDeleted(ctx context.Context, circleID int, webhookURL *string, label LabelPayload, actor *uModel.User) {
+	p.labelEvent(ctx, circleID, webhookURL, EventTypeLabelDeleted, label, actor)
+}
+
+func (p *EventsProducer) MemberJoined(ctx context.Context, circleID int, webhookURL *string, member UserPayload, role string, actor *uModel.User) {
+	p.publishEvent(ctx, circleID, Event{
+		Type:      EventTypeMemberJoined,
+		URL:       urlOrEmpty(webhookURL),
+		Timestamp: time.Now(),
+		Data:      MemberEventData{CircleID: circleID, Member: member, Role: role, Actor: NewUserPayload(actor)},
+	})
+}
+
+func (p *EventsProducer) MemberLeft(ctx context.Context, circleID int, webhookURL *string, member UserPayload, actor *uModel.User) {
+	p.memberEvent(ctx, circleID, webhookURL, EventTypeMemberLeft, member, "", actor)
+}
+
+func (p *EventsProducer) MemberRemoved(ctx context.Context, circleID int, webhookURL *string, member UserPayload, actor *uModel.User) {
+	p.memberEvent(ctx, circleID, webhookURL, EventTypeMemberRemoved, member, "", actor)
+}
+
+func (p *EventsProducer) MemberRoleChanged(ctx context.Context, circleID int, webhookURL *string, member UserPayload, role string, actor *uModel.User) {
+	p.memberEvent(ctx, circleID, webhookURL, EventTypeMemberRoleChanged, member, role, actor)
+}
+
+func (p *EventsProducer) memberEvent(ctx context.Context, circleID int, webhookURL *string, eventType EventType, member UserPayload, role string, actor *uModel.User) {
+	p.publishEvent(ctx, circleID, Event{
+		Type:      eventType,
+		URL:       urlOrEmpty(webhookURL),
+		Timestamp: time.Now(),
+		Data:      MemberEventData{CircleID: circleID, Member: member, Role: role, Actor: NewUserPayload(actor)},
+	})
+}
+
+func (p *EventsProducer) PointsRedeemed(ctx context.Context, circleID int, webhookURL *string, member UserPayload, points int, actor *uModel.User) {
+	p.publishEvent(ctx, circleID, Event{
+		Type:      EventTypePointsRedeemed,
+		URL:       urlOrEmpty(webhookURL),
+		Timestamp: time.Now(),
+		Data:      PointsEventData{CircleID: circleID, Member: member, Points: points, Actor: NewUserPayload(actor)},
+	})
+}
+
+// choreChanges compares the fields of a chore the catalog has events for.
+func choreChanges(before *chModel.Chore, after *chModel.Chore) map[string]FieldChange {
+	changes := make(map[string]FieldChange)
+	if before.Name != after.Name {
+		changes["name"] = FieldChange{From: before.Name, To: after.Name}
+	}
+	if before.AssignedTo != after.AssignedTo {
+		changes["assignedTo"] = FieldChange{From: before.AssignedTo, To: after.AssignedTo}
+	}
+	if before.Priority != after.Priority {
+		changes["priority"] = FieldChange{From: before.Priority, To: after.Priority}
+	}
+	if !sameTime(before.NextDueDate, after.NextDueDate) {
+		changes["nextDueDate"] = FieldChange{From: before.NextDueDate, To: after.NextDueDate}
+	}
+	if before.FrequencyType != after.FrequencyType || before.Frequency != after.Frequency {
+		changes["frequencyType"] = FieldChange{From: before.FrequencyType, To: after.FrequencyType}
+	}
+	if before.IsActive != after.IsActive {
+		changes["isActive"] = FieldChange{From: before.IsActive, To: after.IsActive}
+	}
+	beforeLabels, afterLabels := labelIDs(before), labelIDs(after)
+	if !sameInts(beforeLabels, afterLabels) {
+		changes["labels"] = FieldChange{From: beforeLabels, To: afterLabels}
+	}
+	return changes
+}
+
+func sameTime(a *time.Time, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equal(*b)
+}
+
+func labelIDs(chore *chModel.Chore) []int {
+	ids := []int{}
+	if chore.LabelsV2 != nil {
+		for _, label := range *chore.LabelsV2 {
+			ids = append(ids, label.ID)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func sameInts(a []int, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,95 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	chModel "donetick.com/core/internal/chore/model"
+	uModel "donetick.com/core/internal/user/model"
+)
+
+func TestChoreUpdatedEvents(t *testing.T) {
+	p, repo, _ := newTestProducer(t, "whsec_test")
+	ctx := context.Background()
+	url := "https://example.com/hook"
+	due := time.Date(2025, 1, 7, 9, 0, 0, 0, time.UTC)
+	before := &chModel.Chore{ID: 7, Name: "Dishes", CircleID: 1, AssignedTo: 1, Priority: 2, NextDueDate: &due, IsActive: true,
+		LabelsV2: &[]chModel.Label{{ID: 3, Name: "Kitchen"}}}
+	after := *before
+	after.AssignedTo = 2
+	after.LabelsV2 = &[]chModel.Label{{ID: 3, Name: "Kitchen"}, {ID: 4, Name: "Daily"}}
+
+	p.ChoreUpdated(ctx, &url, before, &after, &uModel.User{ID: 1, Username: "alex"})
+
+	var types []string
+	for len(p.queue) > 0 {
+		delivery, err := repo.GetDelivery(ctx, <-p.queue)
+		if err != nil {
+			t.Fatalf("GetDelivery() error = %v", err)
+		}
+		types = append(types, delivery.EventType)
+		if delivery.EventType != string(EventTypeTaskUpdated) {
+			continue
+		}
+		var event struct {
+			Version int            `json:"version"`
+			Data    ChoreEventData `json:"data"`
+		}
+		if err := json.Unmarshal([]byte(delivery.Payload), &event); err != nil {
+			t.Fatalf("invalid payload: %v", err)
+		}
+		if event.Version != EventSchemaVersion || event.Data.Actor.Username != "alex" || len(event.Data.Changes) != 2 {
+			t.Errorf("task.updated payload = %s", delivery.Payload)
+		}
+	}
+	want := []string{"task.updated", "task.reassigned", "task.labels_changed"}
+	if len(types) != len(want) {
+		t.Fatalf("sent %v, want %v", types, want)
+	}
+	for i := range want {
+		if types[i] != want[i] {
+			t.Errorf("sent %v, want %v", types, want)
+		}
+	}
+
+	// nothing changed, nothing sent
+	p.ChoreUpdated(ctx, &url, before, before, nil)
+	if len(p.queue) != 0 {
+		t.Errorf("ChoreUpdated() without changes sent %d events", len(p.queue))
+	}
+}
+
+func TestEventSubscription(t *testing.T) {
+	p, _, db := newTestProducer(t, "whsec_test")
+	ctx := context.Background()
+	url := "https://example.com/hook"
+	events := "task.completed, task.deleted"
+	if err := db.Exec("UPDATE circles SET webhook_events = ? WHERE id = 1", events).Error; err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+
+	chore := &chModel.Chore{ID: 7, Name: "Dishes", CircleID: 1}
+	p.ChoreCreated(ctx, &url, chore, nil)
+	if len(p.queue) != 0 {
+		t.Errorf("task.created was sent to a circle not subscribed to it")
+	}
+	p.ChoreDeleted(ctx, &url, chore, nil)
+	if len(p.queue) != 1 {
+		t.Errorf("task.deleted was not sent to a circle subscribed to it")
+	}
+
+	for eventType, subscribed := range map[EventType]bool{
+		EventTypeTaskCompleted: true,
+		EventTypeTaskDeleted:   true,
+		EventTypeTaskCreated:   false,
+	} {
+		if isSubscribed(events, eventType) != subscribed {
+			t.Errorf("isSubscribed(%q, %s) = %v", events, eventType, !subscribed)
+		}
+	}
+	if !isSubscribed("", EventTypeThingChanged) {
+		t.Errorf("a circle without a list should get every event")
+	}
+}
@@ -0,0 +1,47 @@
+package model
+
+import "time"
+
+type DeliveryStatus string
+
+const (
+	DeliveryStatusPending   DeliveryStatus = "pending"
+	DeliveryStatusDelivered DeliveryStatus = "delivered"
+	DeliveryStatusFailed    DeliveryStatus = "failed"
+)
+
+// WebhookDelivery is a webhook event sent to a circle. It is stored before the first attempt so
+// pending deliveries survive a restart, and kept afterwards as the delivery log of the circle.
+type WebhookDelivery struct {
+	ID             int            `json:"id" gorm:"primaryKey"`
+	DeliveryID     string         `json:"deliveryId" gorm:"column:delivery_id;uniqueIndex"` // sent in the X-Donetick-Delivery header
+	CircleID       int            `json:"circleId" gorm:"column:circle_id;index"`
+	SubscriptionID *int           `json:"subscriptionId" gorm:"column:subscription_id;index"` // empty for the circle webhook URL
+	EventType      string         `json:"eventType" gorm:"column:event_type"`
+	URL            string         `json:"url" gorm:"column:url"`
+	Payload        string         `json:"payload" gorm:"column:payload"`
+	Status         DeliveryStatus `json:"status" gorm:"column:status;index"`
+	Attempts       int            `json:"attempts" gorm:"column:attempts;default:0"`
+	ResponseCode   *int           `json:"responseCode" gorm:"column:response_code"`
+	LastError      *string        `json:"lastError" gorm:"column:last_error"`
+	NextAttemptAt  *time.Time     `json:"nextAttemptAt" gorm:"column:next_attempt_at;index"`
+	RedeliveryOf   *int           `json:"redeliveryOf" gorm:"column:redelivery_of"` // ID of the delivery this one repeats
+	DeliveredAt    *time.Time     `json:"deliveredAt" gorm:"column:delivered_at"`
+	CreatedAt      time.Time      `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt      time.Time      `json:"updatedAt" gorm:"column:updated_at"`
+}
+
+// WebhookSubscription is a webhook target of a circle, next to the circle webhook URL. Events are sent to
+// every enabled subscription whose filters match, each delivery signed with the secret of its subscription.
+type WebhookSubscription struct {
+	ID         int       `json:"id" gorm:"primaryKey"`
+	CircleID   int       `json:"circleId" gorm:"column:circle_id;index"`
+	URL        string    `json:"url" gorm:"column:url"`
+	Secret     string    `json:"-" gorm:"column:secret"`
+	EventTypes string    `json:"-" gorm:"column:event_types"` // comma separated, all events when empty
+	LabelIDs   string    `json:"-" gorm:"column:label_ids"`   // comma separated, events of any label when empty
+	IsEnabled  bool      `json:"isEnabled" gorm:"column:is_enabled"`
+	CreatedBy  int       `json:"createdBy" gorm:"column:created_by"`
+	CreatedAt  time.Time `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt  time.Time `json:"updatedAt" gorm:"column:updated_at"`
+}
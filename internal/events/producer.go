@@ -1,45 +1,68 @@
 package events
 
 import (
-	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
-	"log"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"donetick.com/core/config"
 	chModel "donetick.com/core/internal/chore/model"
+	eModel "donetick.com/core/internal/events/model"
+	eRepo "donetick.com/core/internal/events/repo"
 	uModel "donetick.com/core/internal/user/model"
+	"donetick.com/core/internal/utils"
 	"donetick.com/core/logging"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
 const (
 	METHOD_POST       = "POST"
 	HEAD_CONTENT_TYPE = "Content-Type"
+	HEAD_EVENT        = "X-Donetick-Event"
+	HEAD_DELIVERY     = "X-Donetick-Delivery"
+	HEAD_TIMESTAMP    = "X-Donetick-Timestamp"
+	HEAD_SIGNATURE    = "X-Donetick-Signature"
 	CONTENT_TYPE_JSON = "application/json"
 )
 
+const (
+	// a failing delivery is retried after 30s, 1m, 2m, 4m, 8m, 16m and 32m before it is marked failed
+	maxDeliveryAttempts  = 8
+	deliveryRetryBackoff = 30 * time.Second
+	deliveryPollInterval = 30 * time.Second
+	deliveryLease        = 2 * time.Minute
+	deliveryRetention    = 30 * 24 * time.Hour
+)
+
 type EventType string
 
 const (
-	EventTypeUnknown EventType = ""
-	// EventTypeTaskCreated    EventType = "task.created"
-	EventTypeTaskReminder EventType = "task.reminder"
-	// EventTypeTaskUpdated    EventType = "task.updated"
+	EventTypeUnknown          EventType = ""
+	EventTypeTaskReminder     EventType = "task.reminder"
 	EventTypeTaskCompleted    EventType = "task.completed"
 	EventTypeSubTaskCompleted EventType = "subtask.completed"
-	// EventTypeTaskReassigned EventType = "task.reassigned"
-	EventTypeTaskSkipped  EventType = "task.skipped"
-	EventTypeThingChanged EventType = "thing.changed"
+	EventTypeTaskSkipped      EventType = "task.skipped"
+	EventTypeThingChanged     EventType = "thing.changed"
 )
 
 type Event struct {
 	Type      EventType   `json:"type"`
+	Version   int         `json:"version"`
 	URL       string      `json:"-"`
 	Timestamp time.Time   `json:"timestamp"`
 	Data      interface{} `json:"data"`
+
+	labels  []int // labels of the task or the label the event is about, for the label filter of subscriptions
+	choreID int   // chore the event is about, for the sinks that only show it to the users who can view the chore
 }
 
 type ChoreData struct {
@@ -49,144 +72,372 @@ type ChoreData struct {
 	Note        string         `json:"note"`
 }
 
+// EventSink gets every event published in a circle, whatever its webhooks are subscribed to. choreID is the
+// chore the event is about, 0 for the events that are not about a chore.
+type EventSink interface {
+	PublishEvent(ctx context.Context, circleID int, eventType EventType, choreID int, payload []byte)
+}
+
 type EventsProducer struct {
-	client *http.Client
-	queue  chan Event
-	logger *zap.SugaredLogger
+	client       *http.Client
+	queue        chan int
+	logger       *zap.SugaredLogger
+	deliveryRepo *eRepo.WebhookDeliveryRepository
+	sinks        []EventSink
 }
 
 func (p *EventsProducer) Start(ctx context.Context) {
 
 	p.logger = logging.FromContext(ctx)
 
-	go func() {
-		for event := range p.queue {
-			p.processEvent(event)
-		}
-	}()
+	go p.run(ctx)
 }
 
-func NewEventsProducer(cfg *config.Config) *EventsProducer {
+func NewEventsProducer(cfg *config.Config, dr *eRepo.WebhookDeliveryRepository) *EventsProducer {
 	return &EventsProducer{
-		client: &http.Client{
-			Timeout: cfg.WebhookConfig.Timeout,
-		},
-		queue: make(chan Event, cfg.WebhookConfig.QueueSize),
+		client:       utils.NewEgressPolicy(cfg).NewHTTPClient(cfg.WebhookConfig.Timeout),
+		queue:        make(chan int, cfg.WebhookConfig.QueueSize),
+		deliveryRepo: dr,
 	}
 }
 
-func (p *EventsProducer) publishEvent(event Event) {
-	select {
-	case p.queue <- event:
-		// Successfully added to queue
-	default:
-		log.Println("Webhook queue is full, dropping event")
+// AddSink registers a sink for all events, it has to be called before the producer is started.
+func (p *EventsProducer) AddSink(sink EventSink) {
+	p.sinks = append(p.sinks, sink)
+}
+
+// run sends queued deliveries as they come in and periodically retries the pending ones, the queue only
+// speeds up the first attempt, the database is the source of truth so nothing is lost on a restart or a full queue.
+// Everything runs on this goroutine so a delivery is never attempted twice at the same time.
+func (p *EventsProducer) run(ctx context.Context) {
+	retryTicker := time.NewTicker(deliveryPollInterval)
+	defer retryTicker.Stop()
+	cleanupTicker := time.NewTicker(24 * time.Hour)
+	defer cleanupTicker.Stop()
+
+	p.retryDueDeliveries(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case id := <-p.queue:
+			delivery, err := p.deliveryRepo.GetDelivery(ctx, id)
+			if err != nil {
+				p.logger.Errorw("Failed to load webhook delivery", "id", id, "error", err)
+				continue
+			}
+			if delivery.Status == eModel.DeliveryStatusPending {
+				p.deliver(ctx, delivery)
+			}
+		case <-retryTicker.C:
+			p.retryDueDeliveries(ctx)
+		case <-cleanupTicker.C:
+			if err := p.deliveryRepo.DeleteDeliveriesBefore(ctx, time.Now().UTC().Add(-deliveryRetention)); err != nil {
+				p.logger.Errorw("Failed to delete old webhook deliveries", "error", err)
+			}
+		}
 	}
 }
 
-func (p *EventsProducer) processEvent(event Event) {
-	p.logger.Debugw("Sending webhook event", "type", event.Type, "url", event.URL)
+func (p *EventsProducer) retryDueDeliveries(ctx context.Context) {
+	deliveries, err := p.deliveryRepo.GetDueDeliveries(ctx, time.Now().UTC(), 50)
+	if err != nil {
+		p.logger.Errorw("Failed to load pending webhook deliveries", "error", err)
+		return
+	}
+	for _, delivery := range deliveries {
+		p.deliver(ctx, delivery)
+	}
+}
 
-	eventJSON, err := json.Marshal(event)
+// publishEvent hands the event to the sinks, stores a pending delivery of it for the circle webhook URL and
+// for every enabled subscription of the circle whose filters match, and queues their first attempts.
+func (p *EventsProducer) publishEvent(ctx context.Context, circleID int, event Event) {
+	event.Version = EventSchemaVersion
+	payload, err := json.Marshal(event)
 	if err != nil {
 		p.logger.Errorw("Failed to marshal webhook event", "error", err)
 		return
 	}
+	for _, sink := range p.sinks {
+		sink.PublishEvent(ctx, circleID, event.Type, event.choreID, payload)
+	}
 
-	// Pring the event and the url:
-	p.logger.Debug("Sending event to webhook", "url", event.URL, "event", event)
-	p.logger.Debug("Event: ", event)
+	if event.URL != "" {
+		eventTypes, err := p.deliveryRepo.GetWebhookEventTypes(ctx, circleID)
+		if err != nil {
+			p.logger.Errorw("Failed to get webhook event types of circle", "circle", circleID, "error", err)
+		} else if isSubscribed(eventTypes, event.Type) {
+			p.createDelivery(ctx, circleID, nil, event.URL, event.Type, payload)
+		} else {
+			p.logger.Debugw("Circle is not subscribed to event, skipping webhook", "circle", circleID, "type", event.Type)
+		}
+	}
 
-	req, err := http.NewRequest(METHOD_POST, event.URL, bytes.NewBuffer(eventJSON))
+	subscriptions, err := p.deliveryRepo.GetEnabledSubscriptions(ctx, circleID)
 	if err != nil {
-		p.logger.Errorw("Failed to create webhook request", "error", err)
+		p.logger.Errorw("Failed to get webhook subscriptions of circle", "circle", circleID, "error", err)
 		return
 	}
+	for _, subscription := range subscriptions {
+		if isSubscribed(subscription.EventTypes, event.Type) && hasLabel(subscription.LabelIDs, event.labels) {
+			p.createDelivery(ctx, circleID, &subscription.ID, subscription.URL, event.Type, payload)
+		}
+	}
+}
+
+func (p *EventsProducer) createDelivery(ctx context.Context, circleID int, subscriptionID *int, url string, eventType EventType, payload []byte) {
+	now := time.Now().UTC()
+	// until the queue gets to it the delivery is leased, after that the retry loop picks it up
+	lease := now.Add(deliveryLease)
+	delivery := &eModel.WebhookDelivery{
+		DeliveryID:     uuid.NewString(),
+		CircleID:       circleID,
+		SubscriptionID: subscriptionID,
+		EventType:      string(eventType),
+		URL:            url,
+		Payload:        string(payload),
+		Status:         eModel.DeliveryStatusPending,
+		NextAttemptAt:  &lease,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := p.deliveryRepo.CreateDelivery(ctx, delivery); err != nil {
+		p.logger.Errorw("Failed to store webhook delivery, dropping event", "type", eventType, "url", url, "error", err)
+		return
+	}
+	p.enqueue(delivery.ID)
+}
+
+func (p *EventsProducer) enqueue(id int) {
+	select {
+	case p.queue <- id:
+		// Successfully added to queue
+	default:
+		p.logger.Warnw("Webhook queue is full, delivery will be sent by the retry loop", "id", id)
+	}
+}
+
+// isSubscribed checks the event against the comma separated event types a circle subscribed to,
+// a circle without a list gets every event.
+func isSubscribed(eventTypes string, eventType EventType) bool {
+	if strings.TrimSpace(eventTypes) == "" {
+		return true
+	}
+	for _, subscribed := range strings.Split(eventTypes, ",") {
+		if EventType(strings.TrimSpace(subscribed)) == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// hasLabel checks the labels of an event against the comma separated label IDs a subscription is
+// limited to. A subscription without labels gets every event, one with labels only the events of
+// tasks and labels carrying one of them.
+func hasLabel(labelIDs string, labels []int) bool {
+	if strings.TrimSpace(labelIDs) == "" {
+		return true
+	}
+	for _, id := range strings.Split(labelIDs, ",") {
+		for _, label := range labels {
+			if strings.TrimSpace(id) == strconv.Itoa(label) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func urlOrEmpty(url *string) string {
+	if url == nil {
+		return ""
+	}
+	return *url
+}
+
+// Redeliver sends the payload of a past delivery again as a new delivery to url, signed like the original.
+func (p *EventsProducer) Redeliver(ctx context.Context, original *eModel.WebhookDelivery, url string) (*eModel.WebhookDelivery, error) {
+	now := time.Now().UTC()
+	lease := now.Add(deliveryLease)
+	delivery := &eModel.WebhookDelivery{
+		DeliveryID:     uuid.NewString(),
+		CircleID:       original.CircleID,
+		SubscriptionID: original.SubscriptionID,
+		EventType:      original.EventType,
+		URL:            url,
+		Payload:        original.Payload,
+		Status:         eModel.DeliveryStatusPending,
+		NextAttemptAt:  &lease,
+		RedeliveryOf:   &original.ID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := p.deliveryRepo.CreateDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+	p.enqueue(delivery.ID)
+	return delivery, nil
+}
+
+// deliver makes one attempt to send a delivery and records the outcome.
+func (p *EventsProducer) deliver(ctx context.Context, delivery *eModel.WebhookDelivery) {
+	p.logger.Debugw("Sending webhook event", "type", delivery.EventType, "url", delivery.URL, "delivery", delivery.DeliveryID)
+
+	delivery.Attempts++
+	delivery.ResponseCode = nil
+	statusCode, err := p.send(ctx, delivery)
+	if statusCode != 0 {
+		delivery.ResponseCode = &statusCode
+	}
+
+	now := time.Now().UTC()
+	if err == nil {
+		delivery.Status = eModel.DeliveryStatusDelivered
+		delivery.DeliveredAt = &now
+		delivery.NextAttemptAt = nil
+		delivery.LastError = nil
+	} else {
+		lastError := err.Error()
+		delivery.LastError = &lastError
+		if nextAttemptAt, retry := deliveryRetry(delivery.Attempts, now); retry {
+			p.logger.Warnw("Webhook delivery failed, will retry", "delivery", delivery.DeliveryID, "attempts", delivery.Attempts, "next_attempt_at", nextAttemptAt, "error", err)
+			delivery.NextAttemptAt = &nextAttemptAt
+		} else {
+			p.logger.Errorw("Webhook delivery failed, giving up", "delivery", delivery.DeliveryID, "attempts", delivery.Attempts, "error", err)
+			delivery.Status = eModel.DeliveryStatusFailed
+			delivery.NextAttemptAt = nil
+		}
+	}
+	if err := p.deliveryRepo.UpdateDeliveryAttempt(ctx, delivery); err != nil {
+		p.logger.Errorw("Failed to update webhook delivery", "delivery", delivery.DeliveryID, "error", err)
+	}
+}
+
+// send posts the payload signed with the current secret of its subscription, or of the circle, and returns
+// the response status code.
+func (p *EventsProducer) send(ctx context.Context, delivery *eModel.WebhookDelivery) (int, error) {
+	secret, err := p.deliverySecret(ctx, delivery)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get webhook secret: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, METHOD_POST, delivery.URL, strings.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := time.Now().Unix()
 	req.Header.Set(HEAD_CONTENT_TYPE, CONTENT_TYPE_JSON)
+	req.Header.Set(HEAD_EVENT, delivery.EventType)
+	req.Header.Set(HEAD_DELIVERY, delivery.DeliveryID)
+	req.Header.Set(HEAD_TIMESTAMP, strconv.FormatInt(timestamp, 10))
+	if secret != "" {
+		req.Header.Set(HEAD_SIGNATURE, "sha256="+Signature(secret, timestamp, []byte(delivery.Payload)))
+	}
 
 	resp, err := p.client.Do(req)
 	if err != nil {
-		p.logger.Errorw("Failed to send webhook event", "error", err)
-		return
+		return 0, err
 	}
 	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
 
 	if resp.StatusCode >= 400 {
-		p.logger.Errorw("Webhook request failed", "status", resp.StatusCode)
-		return
+		return resp.StatusCode, fmt.Errorf("webhook returned %s", resp.Status)
 	}
+	return resp.StatusCode, nil
 }
 
-func (p *EventsProducer) ChoreCompleted(ctx context.Context, webhookURL *string, chore *chModel.Chore, performer *uModel.User) {
-	if webhookURL == nil {
-		p.logger.Debug("No subscribers for circle, skipping webhook")
-		return
+func (p *EventsProducer) deliverySecret(ctx context.Context, delivery *eModel.WebhookDelivery) (string, error) {
+	if delivery.SubscriptionID == nil {
+		return p.deliveryRepo.GetWebhookSecret(ctx, delivery.CircleID)
+	}
+	subscription, err := p.deliveryRepo.GetSubscription(ctx, *delivery.SubscriptionID)
+	if err != nil {
+		return "", err
 	}
+	return subscription.Secret, nil
+}
+
+// Signature is the hex encoded HMAC-SHA256 of "<timestamp>.<payload>" sent in the X-Donetick-Signature
+// header. Receivers recompute it with the circle secret and should reject old timestamps to stop replays.
+func Signature(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliveryRetry returns when to retry a delivery after its attempts-th failure, the backoff doubles with
+// every attempt. It returns false when no attempts are left.
+func deliveryRetry(attempts int, now time.Time) (time.Time, bool) {
+	if attempts >= maxDeliveryAttempts {
+		return time.Time{}, false
+	}
+	return now.Add(deliveryRetryBackoff << (attempts - 1)), true
+}
+
+func (p *EventsProducer) ChoreCompleted(ctx context.Context, webhookURL *string, chore *chModel.Chore, performer *uModel.User) {
 
 	event := Event{
 		Type:      EventTypeTaskCompleted,
-		URL:       *webhookURL,
+		URL:       urlOrEmpty(webhookURL),
 		Timestamp: time.Now(),
 		Data: ChoreData{Chore: chore,
 			Username:    performer.Username,
 			DisplayName: performer.DisplayName,
 		},
+		labels:  labelIDs(chore),
+		choreID: chore.ID,
 	}
-	p.publishEvent(event)
+	p.publishEvent(ctx, chore.CircleID, event)
 }
 
 func (p *EventsProducer) ChoreSkipped(ctx context.Context, webhookURL *string, chore *chModel.Chore, performer *uModel.User) {
-	if webhookURL == nil {
-		p.logger.Debug("No Webhook URL for circle, skipping webhook")
-		return
-	}
 
 	event := Event{
 		Type:      EventTypeTaskSkipped,
-		URL:       *webhookURL,
+		URL:       urlOrEmpty(webhookURL),
 		Timestamp: time.Now(),
 		Data: ChoreData{Chore: chore,
 			Username:    performer.Username,
 			DisplayName: performer.DisplayName,
 		},
+		labels:  labelIDs(chore),
+		choreID: chore.ID,
 	}
-	p.publishEvent(event)
+	p.publishEvent(ctx, chore.CircleID, event)
 }
 
-func (p *EventsProducer) NotificationEvent(ctx context.Context, url string, event interface{}) {
+func (p *EventsProducer) NotificationEvent(ctx context.Context, circleID int, choreID int, url *string, event interface{}) {
 	// print the event and the url :
 	p.logger.Debug("Sending notification event")
 
-	p.publishEvent(Event{
-		URL:       url,
+	p.publishEvent(ctx, circleID, Event{
+		URL:       urlOrEmpty(url),
 		Type:      EventTypeTaskReminder,
 		Timestamp: time.Now(),
 		Data:      event,
+		choreID:   choreID,
 	})
 }
 
-func (p *EventsProducer) ThingsUpdated(ctx context.Context, url *string, data interface{}) {
-	if url == nil {
-		p.logger.Debug("No subscribers for circle, skipping webhook")
-		return
-	}
-	p.publishEvent(Event{
-		URL:       *url,
+func (p *EventsProducer) ThingsUpdated(ctx context.Context, circleID int, url *string, data interface{}) {
+	p.publishEvent(ctx, circleID, Event{
+		URL:       urlOrEmpty(url),
 		Type:      EventTypeThingChanged,
 		Timestamp: time.Now(),
 		Data:      data,
 	})
 }
 
-func (p *EventsProducer) SubtaskUpdated(ctx context.Context, url *string, data interface{}) {
-	if url == nil {
-		p.logger.Debug("No subscribers for circle, skipping webhook")
-		return
-	}
-	p.publishEvent(Event{
-		URL:       *url,
+func (p *EventsProducer) SubtaskUpdated(ctx context.Context, circleID int, choreID int, url *string, data interface{}) {
+	p.publishEvent(ctx, circleID, Event{
+		URL:       urlOrEmpty(url),
 		Type:      EventTypeSubTaskCompleted,
 		Timestamp: time.Now(),
 		Data:      data,
+		choreID:   choreID,
 	})
 }
@@ -0,0 +1,132 @@
+package events
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"donetick.com/core/config"
+	chModel "donetick.com/core/internal/chore/model"
+	cModel "donetick.com/core/internal/circle/model"
+	"donetick.com/core/internal/database/dbtest"
+	eModel "donetick.com/core/internal/events/model"
+	eRepo "donetick.com/core/internal/events/repo"
+	uModel "donetick.com/core/internal/user/model"
+	"donetick.com/core/logging"
+	"gorm.io/gorm"
+)
+
+func newTestProducer(t *testing.T, secret string) (*EventsProducer, *eRepo.WebhookDeliveryRepository, *gorm.DB) {
+	t.Helper()
+	db := dbtest.Open(t)
+	if err := db.Create(&cModel.Circle{ID: 1, Name: "Home", WebhookSecret: &secret}).Error; err != nil {
+		t.Fatalf("failed to create circle: %v", err)
+	}
+	repo := eRepo.NewWebhookDeliveryRepository(db)
+	// the test servers are on loopback
+	p := NewEventsProducer(&config.Config{WebhookConfig: config.WebhookConfig{Timeout: time.Second, QueueSize: 10,
+		AllowedPrivateNetworks: []string{"127.0.0.0/8"}}}, repo)
+	p.logger = logging.DefaultLogger()
+	return p, repo, db
+}
+
+func TestDeliverySigned(t *testing.T) {
+	var headers http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	p, repo, _ := newTestProducer(t, "whsec_test")
+	ctx := context.Background()
+	url := server.URL
+	p.ChoreCompleted(ctx, &url, &chModel.Chore{ID: 7, Name: "Dishes", CircleID: 1}, &uModel.User{Username: "alex"})
+
+	id := <-p.queue
+	delivery, err := repo.GetDelivery(ctx, id)
+	if err != nil {
+		t.Fatalf("GetDelivery() error = %v", err)
+	}
+	p.deliver(ctx, delivery)
+
+	delivery, _ = repo.GetDelivery(ctx, id)
+	if delivery.Status != eModel.DeliveryStatusDelivered || delivery.ResponseCode == nil || *delivery.ResponseCode != 200 {
+		t.Fatalf("delivery = %+v, want delivered with 200", delivery)
+	}
+	if headers.Get(HEAD_DELIVERY) != delivery.DeliveryID || headers.Get(HEAD_EVENT) != string(EventTypeTaskCompleted) {
+		t.Errorf("headers = %v", headers)
+	}
+	timestamp, _ := strconv.ParseInt(headers.Get(HEAD_TIMESTAMP), 10, 64)
+	if want := "sha256=" + Signature("whsec_test", timestamp, body); headers.Get(HEAD_SIGNATURE) != want {
+		t.Errorf("signature = %q, want %q", headers.Get(HEAD_SIGNATURE), want)
+	}
+	if string(body) != delivery.Payload {
+		t.Errorf("sent %s, stored %s", body, delivery.Payload)
+	}
+}
+
+func TestDeliveryRetriedAndRedelivered(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	p, repo, _ := newTestProducer(t, "whsec_test")
+	ctx := context.Background()
+	url := server.URL
+	p.ThingsUpdated(ctx, 1, &url, map[string]interface{}{"id": 1})
+	delivery, _ := repo.GetDelivery(ctx, <-p.queue)
+
+	for attempt := 1; attempt <= maxDeliveryAttempts; attempt++ {
+		p.deliver(ctx, delivery)
+		delivery, _ = repo.GetDelivery(ctx, delivery.ID)
+		if delivery.Attempts != attempt || delivery.ResponseCode == nil || *delivery.ResponseCode != status {
+			t.Fatalf("after attempt %d delivery = %+v", attempt, delivery)
+		}
+		if attempt < maxDeliveryAttempts && (delivery.Status != eModel.DeliveryStatusPending || delivery.NextAttemptAt == nil) {
+			t.Fatalf("after attempt %d delivery should be pending with a next attempt, got %+v", attempt, delivery)
+		}
+	}
+	if delivery.Status != eModel.DeliveryStatusFailed || delivery.NextAttemptAt != nil {
+		t.Fatalf("delivery = %+v, want failed", delivery)
+	}
+
+	status = http.StatusOK
+	redelivery, err := p.Redeliver(ctx, delivery, server.URL)
+	if err != nil {
+		t.Fatalf("Redeliver() error = %v", err)
+	}
+	if redelivery.DeliveryID == delivery.DeliveryID || redelivery.RedeliveryOf == nil || *redelivery.RedeliveryOf != delivery.ID {
+		t.Errorf("redelivery = %+v", redelivery)
+	}
+	// the queue lost the redelivery, the retry loop sends it once its lease is over
+	<-p.queue
+	expired := time.Now().UTC().Add(-time.Second)
+	redelivery.NextAttemptAt = &expired
+	repo.UpdateDeliveryAttempt(ctx, redelivery)
+	p.retryDueDeliveries(ctx)
+	redelivery, _ = repo.GetDelivery(ctx, redelivery.ID)
+	if redelivery.Status != eModel.DeliveryStatusDelivered || redelivery.Payload != delivery.Payload {
+		t.Errorf("redelivery = %+v, want delivered with the original payload", redelivery)
+	}
+}
+
+func TestDeliveryRetry(t *testing.T) {
+	now := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	if next, retry := deliveryRetry(1, now); !retry || next.Sub(now) != deliveryRetryBackoff {
+		t.Errorf("deliveryRetry(1) = %v, %v", next, retry)
+	}
+	if next, retry := deliveryRetry(3, now); !retry || next.Sub(now) != 4*deliveryRetryBackoff {
+		t.Errorf("deliveryRetry(3) = %v, %v", next, retry)
+	}
+	if _, retry := deliveryRetry(maxDeliveryAttempts, now); retry {
+		t.Errorf("deliveryRetry(%d) should give up", maxDeliveryAttempts)
+	}
+}
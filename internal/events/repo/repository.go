@@ -0,0 +1,154 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	cModel "donetick.com/core/internal/circle/model"
+	eModel "donetick.com/core/internal/events/model"
+	"gorm.io/gorm"
+)
+
+type WebhookDeliveryRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookDeliveryRepository(db *gorm.DB) *WebhookDeliveryRepository {
+	return &WebhookDeliveryRepository{db}
+}
+
+func (r *WebhookDeliveryRepository) CreateDelivery(c context.Context, delivery *eModel.WebhookDelivery) error {
+	return r.db.WithContext(c).Create(delivery).Error
+}
+
+func (r *WebhookDeliveryRepository) GetDelivery(c context.Context, id int) (*eModel.WebhookDelivery, error) {
+	var delivery eModel.WebhookDelivery
+	if err := r.db.WithContext(c).First(&delivery, id).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// GetDueDeliveries returns pending deliveries whose next attempt is due, oldest first.
+func (r *WebhookDeliveryRepository) GetDueDeliveries(c context.Context, now time.Time, limit int) ([]*eModel.WebhookDelivery, error) {
+	var deliveries []*eModel.WebhookDelivery
+	if err := r.db.WithContext(c).
+		Where("status = ? AND next_attempt_at <= ?", eModel.DeliveryStatusPending, now).
+		Order("next_attempt_at asc").
+		Limit(limit).
+		Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// UpdateDeliveryAttempt stores the outcome of a delivery attempt.
+func (r *WebhookDeliveryRepository) UpdateDeliveryAttempt(c context.Context, delivery *eModel.WebhookDelivery) error {
+	return r.db.WithContext(c).Model(&eModel.WebhookDelivery{}).Where("id = ?", delivery.ID).Updates(map[string]interface{}{
+		"status":          delivery.Status,
+		"attempts":        delivery.Attempts,
+		"response_code":   delivery.ResponseCode,
+		"last_error":      delivery.LastError,
+		"next_attempt_at": delivery.NextAttemptAt,
+		"delivered_at":    delivery.DeliveredAt,
+		"updated_at":      time.Now().UTC(),
+	}).Error
+}
+
+// GetCircleDeliveries returns the delivery log of a circle, newest first.
+func (r *WebhookDeliveryRepository) GetCircleDeliveries(c context.Context, circleID int, limit int) ([]*eModel.WebhookDelivery, error) {
+	var deliveries []*eModel.WebhookDelivery
+	if err := r.db.WithContext(c).
+		Where("circle_id = ?", circleID).
+		Order("created_at desc, id desc").
+		Limit(limit).
+		Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// GetWebhookSecret returns the secret deliveries of the circle are signed with, empty when it has none.
+func (r *WebhookDeliveryRepository) GetWebhookSecret(c context.Context, circleID int) (string, error) {
+	var circle cModel.Circle
+	if err := r.db.WithContext(c).Select("webhook_secret").First(&circle, circleID).Error; err != nil {
+		return "", err
+	}
+	if circle.WebhookSecret == nil {
+		return "", nil
+	}
+	return *circle.WebhookSecret, nil
+}
+
+// GetWebhookEventTypes returns the comma separated event types the circle subscribed to, empty for all.
+func (r *WebhookDeliveryRepository) GetWebhookEventTypes(c context.Context, circleID int) (string, error) {
+	var circle cModel.Circle
+	if err := r.db.WithContext(c).Select("webhook_events").First(&circle, circleID).Error; err != nil {
+		return "", err
+	}
+	if circle.WebhookEvents == nil {
+		return "", nil
+	}
+	return *circle.WebhookEvents, nil
+}
+
+func (r *WebhookDeliveryRepository) CreateSubscription(c context.Context, subscription *eModel.WebhookSubscription) error {
+	return r.db.WithContext(c).Create(subscription).Error
+}
+
+func (r *WebhookDeliveryRepository) GetSubscription(c context.Context, id int) (*eModel.WebhookSubscription, error) {
+	var subscription eModel.WebhookSubscription
+	if err := r.db.WithContext(c).First(&subscription, id).Error; err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+func (r *WebhookDeliveryRepository) GetCircleSubscriptions(c context.Context, circleID int) ([]*eModel.WebhookSubscription, error) {
+	var subscriptions []*eModel.WebhookSubscription
+	if err := r.db.WithContext(c).Where("circle_id = ?", circleID).Order("id asc").Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+func (r *WebhookDeliveryRepository) GetEnabledSubscriptions(c context.Context, circleID int) ([]*eModel.WebhookSubscription, error) {
+	var subscriptions []*eModel.WebhookSubscription
+	if err := r.db.WithContext(c).Where("circle_id = ? AND is_enabled = ?", circleID, true).Order("id asc").Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+func (r *WebhookDeliveryRepository) UpdateSubscription(c context.Context, subscription *eModel.WebhookSubscription) error {
+	subscription.UpdatedAt = time.Now().UTC()
+	return r.db.WithContext(c).Model(&eModel.WebhookSubscription{}).Where("id = ?", subscription.ID).Updates(map[string]interface{}{
+		"url":         subscription.URL,
+		"secret":      subscription.Secret,
+		"event_types": subscription.EventTypes,
+		"label_ids":   subscription.LabelIDs,
+		"is_enabled":  subscription.IsEnabled,
+		"updated_at":  subscription.UpdatedAt,
+	}).Error
+}
+
+// DeleteSubscription deletes a subscription and fails its pending deliveries, the delivery log is kept.
+func (r *WebhookDeliveryRepository) DeleteSubscription(c context.Context, id int) error {
+	return r.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&eModel.WebhookDelivery{}).
+			Where("subscription_id = ? AND status = ?", id, eModel.DeliveryStatusPending).
+			Updates(map[string]interface{}{
+				"status":          eModel.DeliveryStatusFailed,
+				"last_error":      "subscription was deleted",
+				"next_attempt_at": nil,
+				"updated_at":      time.Now().UTC(),
+			}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&eModel.WebhookSubscription{}, id).Error
+	})
+}
+
+func (r *WebhookDeliveryRepository) DeleteDeliveriesBefore(c context.Context, before time.Time) error {
+	return r.db.WithContext(c).Where("status != ? AND created_at < ?", eModel.DeliveryStatusPending, before).Delete(&eModel.WebhookDelivery{}).Error
+}
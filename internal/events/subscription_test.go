@@ -0,0 +1,93 @@
+package events
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	chModel "donetick.com/core/internal/chore/model"
+	eModel "donetick.com/core/internal/events/model"
+	uModel "donetick.com/core/internal/user/model"
+)
+
+func TestSubscriptionFanOut(t *testing.T) {
+	// path of each request to whether its signature matched the secret of the target
+	verified := map[string]bool{}
+	secrets := map[string]string{"/circle": "whsec_circle", "/completions": "whsec_completions", "/kitchen": "whsec_kitchen"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(HEAD_TIMESTAMP), 10, 64)
+		verified[r.URL.Path] = r.Header.Get(HEAD_SIGNATURE) == "sha256="+Signature(secrets[r.URL.Path], timestamp, body)
+	}))
+	defer server.Close()
+
+	p, repo, _ := newTestProducer(t, "whsec_circle")
+	ctx := context.Background()
+	subscriptions := map[string]*eModel.WebhookSubscription{
+		"completions": {CircleID: 1, URL: server.URL + "/completions", Secret: "whsec_completions", EventTypes: "task.completed", IsEnabled: true},
+		"kitchen":     {CircleID: 1, URL: server.URL + "/kitchen", Secret: "whsec_kitchen", LabelIDs: "3", IsEnabled: true},
+		"garden":      {CircleID: 1, URL: server.URL + "/garden", Secret: "whsec_garden", LabelIDs: "4,5", IsEnabled: true},
+		"skips":       {CircleID: 1, URL: server.URL + "/skips", Secret: "whsec_skips", EventTypes: "task.skipped", IsEnabled: true},
+		"disabled":    {CircleID: 1, URL: server.URL + "/disabled", Secret: "whsec_disabled", IsEnabled: false},
+		"other":       {CircleID: 2, URL: server.URL + "/other", Secret: "whsec_other", IsEnabled: true},
+	}
+	for name, subscription := range subscriptions {
+		if err := repo.CreateSubscription(ctx, subscription); err != nil {
+			t.Fatalf("CreateSubscription(%s) error = %v", name, err)
+		}
+	}
+
+	url := server.URL + "/circle"
+	chore := &chModel.Chore{ID: 7, Name: "Dishes", CircleID: 1, LabelsV2: &[]chModel.Label{{ID: 3, Name: "Kitchen"}}}
+	p.ChoreCompleted(ctx, &url, chore, &uModel.User{Username: "alex"})
+
+	sent := map[string]*eModel.WebhookDelivery{}
+	for len(p.queue) > 0 {
+		delivery, err := repo.GetDelivery(ctx, <-p.queue)
+		if err != nil {
+			t.Fatalf("GetDelivery() error = %v", err)
+		}
+		sent[delivery.URL[len(server.URL):]] = delivery
+		p.deliver(ctx, delivery)
+	}
+	if len(sent) != 3 || sent["/circle"] == nil || sent["/completions"] == nil || sent["/kitchen"] == nil {
+		t.Fatalf("sent to %v, want /circle, /completions and /kitchen", sent)
+	}
+	if sent["/circle"].SubscriptionID != nil || sent["/kitchen"].SubscriptionID == nil || *sent["/kitchen"].SubscriptionID != subscriptions["kitchen"].ID {
+		t.Errorf("deliveries are not linked to their subscriptions: %+v", sent)
+	}
+	if sent["/circle"].Payload != sent["/kitchen"].Payload {
+		t.Errorf("subscriptions got different payloads")
+	}
+
+	// each delivery is signed with the secret of its own target
+	for path := range secrets {
+		if !verified[path] {
+			t.Errorf("delivery to %s was not signed with its secret", path)
+		}
+	}
+}
+
+func TestHasLabel(t *testing.T) {
+	tests := []struct {
+		labelIDs string
+		labels   []int
+		want     bool
+	}{
+		{"", nil, true},
+		{"", []int{3}, true},
+		{"3", []int{3}, true},
+		{"4, 3", []int{1, 3}, true},
+		{"4", []int{3}, false},
+		{"13", []int{3}, false},
+		{"3", nil, false},
+	}
+	for _, tt := range tests {
+		if got := hasLabel(tt.labelIDs, tt.labels); got != tt.want {
+			t.Errorf("hasLabel(%q, %v) = %v, want %v", tt.labelIDs, tt.labels, got, tt.want)
+		}
+	}
+}
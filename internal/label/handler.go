@@ -4,8 +4,11 @@ import (
 	"strconv"
 
 	auth "donetick.com/core/internal/authorization"
+	"donetick.com/core/internal/events"
 	lModel "donetick.com/core/internal/label/model"
 	lRepo "donetick.com/core/internal/label/repo"
+	uModel "donetick.com/core/internal/user/model"
+	uRepo "donetick.com/core/internal/user/repo"
 	jwt "github.com/appleboy/gin-jwt/v2"
 	"github.com/gin-gonic/gin"
 )
@@ -21,12 +24,14 @@ type UpdateLabelReq struct {
 }
 
 type Handler struct {
-	lRepo *lRepo.LabelRepository
+	lRepo          *lRepo.LabelRepository
+	eventsProducer *events.EventsProducer
 }
 
-func NewHandler(lRepo *lRepo.LabelRepository) *Handler {
+func NewHandler(lRepo *lRepo.LabelRepository, ep *events.EventsProducer) *Handler {
 	return &Handler{
-		lRepo: lRepo,
+		lRepo:          lRepo,
+		eventsProducer: ep,
 	}
 }
 
@@ -81,6 +86,7 @@ func (h *Handler) createLabel(c *gin.Context) {
 		})
 		return
 	}
+	h.eventsProducer.LabelCreated(c, currentUser.CircleID, currentUser.WebhookURL, labelPayload(label), &currentUser.User)
 
 	c.JSON(200, gin.H{
 		"res": label,
@@ -115,6 +121,7 @@ func (h *Handler) updateLabel(c *gin.Context) {
 		})
 		return
 	}
+	h.eventsProducer.LabelUpdated(c, currentUser.CircleID, currentUser.WebhookURL, labelPayload(label), &currentUser.User)
 
 	c.JSON(200, gin.H{
 		"res": label,
@@ -148,6 +155,12 @@ func (h *Handler) deleteLabel(c *gin.Context) {
 		return
 	}
 
+	// loaded before deleting so the event can describe the label
+	deleted := events.LabelPayload{ID: labelID}
+	if labels, err := h.lRepo.GetLabelsByIDs(c, []int{labelID}); err == nil && len(labels) == 1 {
+		deleted = labelPayload(labels[0])
+	}
+
 	// unassociate label from all chores:
 	if err := h.lRepo.DeassignLabelFromAllChoreAndDelete(c, currentUser.ID, labelID); err != nil {
 		c.JSON(500, gin.H{
@@ -155,6 +168,7 @@ func (h *Handler) deleteLabel(c *gin.Context) {
 		})
 		return
 	}
+	h.eventsProducer.LabelDeleted(c, currentUser.CircleID, currentUser.WebhookURL, deleted, &currentUser.User)
 
 	c.JSON(200, gin.H{
 		"res": "Label deleted",
@@ -162,6 +176,10 @@ func (h *Handler) deleteLabel(c *gin.Context) {
 
 }
 
+func labelPayload(label *lModel.Label) events.LabelPayload {
+	return events.LabelPayload{ID: label.ID, Name: label.Name, Color: label.Color}
+}
+
 func Routes(r *gin.Engine, h *Handler, auth *jwt.GinJWTMiddleware) {
 
 	labelRoutes := r.Group("api/v1/labels")
@@ -174,3 +192,21 @@ func Routes(r *gin.Engine, h *Handler, auth *jwt.GinJWTMiddleware) {
 	}
 
 }
+
+// APIs exposes labels to integrations authenticated with an API token, changing them needs the chores:write
+// scope.
+func APIs(r *gin.Engine, h *Handler, userRepo *uRepo.UserRepository) {
+
+	labelAPI := r.Group("eapi/v1/labels")
+	labelAPI.Use(auth.APITokenMiddleware(userRepo))
+	{
+		read := auth.RequireAPIScope(uModel.APITokenScopeRead)
+		write := auth.RequireAPIScope(uModel.APITokenScopeChoresWrite)
+
+		labelAPI.GET("", read, h.getLabels)
+		labelAPI.POST("", write, h.createLabel)
+		labelAPI.PUT("", write, h.updateLabel)
+		labelAPI.DELETE("/:id", write, h.deleteLabel)
+	}
+
+}
@@ -1,11 +1,14 @@
 package model
 
+import "time"
+
 type Label struct {
-	ID        int    `json:"id" gorm:"primary_key"`
-	Name      string `json:"name" gorm:"column:name"`
-	Color     string `json:"color" gorm:"column:color"`
-	CircleID  *int   `json:"-" gorm:"column:circle_id"`
-	CreatedBy int    `json:"created_by" gorm:"column:created_by"`
+	ID        int        `json:"id" gorm:"primary_key"`
+	Name      string     `json:"name" gorm:"column:name"`
+	Color     string     `json:"color" gorm:"column:color"`
+	CircleID  *int       `json:"-" gorm:"column:circle_id"`
+	CreatedBy int        `json:"created_by" gorm:"column:created_by"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty" gorm:"column:updated_at"`
 }
 
 type LabelReq struct {
@@ -3,10 +3,12 @@ package chore
 import (
 	"context"
 	"errors"
+	"time"
 
 	config "donetick.com/core/config"
 	chModel "donetick.com/core/internal/chore/model"
 	lModel "donetick.com/core/internal/label/model"
+	oModel "donetick.com/core/internal/offline/model"
 	"donetick.com/core/logging"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -28,6 +30,20 @@ func (r *LabelRepository) GetUserLabels(ctx context.Context, userID int, circleI
 	return labels, nil
 }
 
+// GetUserLabelsUpdatedSince returns the labels of the user and their circle that changed after since, all of
+// them when since is nil.
+func (r *LabelRepository) GetUserLabelsUpdatedSince(ctx context.Context, userID int, circleID int, since *time.Time) ([]*lModel.Label, error) {
+	labels := []*lModel.Label{}
+	query := r.db.WithContext(ctx).Where("(created_by = ? OR circle_id = ?)", userID, circleID)
+	if since != nil {
+		query = query.Where("updated_at > ?", *since)
+	}
+	if err := query.Find(&labels).Error; err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
 func (r *LabelRepository) CreateLabels(ctx context.Context, labels []*lModel.Label) error {
 	if err := r.db.WithContext(ctx).Create(&labels).Error; err != nil {
 		return err
@@ -109,6 +125,10 @@ func (r *LabelRepository) DeassignLabelFromAllChoreAndDelete(ctx context.Context
 		if labelCount < 1 {
 			return errors.New("label is not owned by user")
 		}
+		var label lModel.Label
+		if err := tx.Select("id", "circle_id", "created_by").First(&label, labelID).Error; err != nil {
+			return err
+		}
 
 		if err := tx.Where("label_id = ?", labelID).Delete(&chModel.ChoreLabels{}).Error; err != nil {
 			log.Debug("Error deleting chore labels")
@@ -120,7 +140,13 @@ func (r *LabelRepository) DeassignLabelFromAllChoreAndDelete(ctx context.Context
 			return err
 		}
 
-		return nil
+		return tx.Create(&oModel.Tombstone{
+			EntityType: oModel.EntityTypeLabel,
+			EntityID:   labelID,
+			CircleID:   label.CircleID,
+			UserID:     &label.CreatedBy,
+			DeletedAt:  time.Now().UTC(),
+		}).Error
 	})
 }
 
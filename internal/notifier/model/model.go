@@ -19,6 +19,11 @@ type Notification struct {
 	ScheduledFor time.Time            `json:"scheduled_for" gorm:"column:scheduled_for;index"`
 	CreatedAt    time.Time            `json:"created_at" gorm:"column:created_at"`
 	RawEvent     JSONB                `json:"raw_event" gorm:"column:raw_event;type:jsonb"`
+	// failed deliveries are retried with a backoff until the attempts run out, then IsFailed dead-letters it
+	Attempts      int        `json:"attempts" gorm:"column:attempts;default:0"`
+	LastError     *string    `json:"last_error" gorm:"column:last_error"`
+	NextAttemptAt *time.Time `json:"next_attempt_at" gorm:"column:next_attempt_at"`
+	IsFailed      bool       `json:"is_failed" gorm:"column:is_failed;index;default:false"`
 }
 type NotificationDetails struct {
 	Notification
@@ -30,6 +35,18 @@ func (n *Notification) IsValid() bool {
 	return true
 }
 
+// ChorePriority returns the priority of the chore the notification was planned for, 0 when it has none.
+func (n *Notification) ChorePriority() int {
+	// numbers come back as float64 once the raw event went through the database
+	switch priority := n.RawEvent["priority"].(type) {
+	case float64:
+		return int(priority)
+	case int:
+		return priority
+	}
+	return 0
+}
+
 type NotificationPlatform int8
 
 const (
@@ -38,6 +55,10 @@ const (
 	NotificationPlatformPushover
 	NotificationPlatformWebhook
 	NotificationPlatformDiscord
+	NotificationPlatformNtfy
+	NotificationPlatformGotify
+	NotificationPlatformEmail
+	NotificationPlatformMatrix
 )
 
 type JSONB map[string]interface{}
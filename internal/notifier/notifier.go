@@ -2,68 +2,105 @@ package notifier
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"donetick.com/core/internal/events"
 	nModel "donetick.com/core/internal/notifier/model"
-	"donetick.com/core/internal/notifier/service/discord"
-	pushover "donetick.com/core/internal/notifier/service/pushover"
-	telegram "donetick.com/core/internal/notifier/service/telegram"
+	"go.uber.org/fx"
 
 	"donetick.com/core/logging"
 )
 
 type Notifier struct {
-	Telegram       *telegram.TelegramNotifier
-	Pushover       *pushover.Pushover
-	discord        *discord.DiscordNotifier
+	providers      map[nModel.NotificationPlatform]NotificationProvider
 	eventsProducer *events.EventsProducer
 }
 
-func NewNotifier(t *telegram.TelegramNotifier, p *pushover.Pushover, ep *events.EventsProducer, d *discord.DiscordNotifier) *Notifier {
+type NotifierParams struct {
+	fx.In
+
+	Providers      []NotificationProvider `group:"notification_providers"`
+	EventsProducer *events.EventsProducer
+}
+
+func NewNotifier(p NotifierParams) (*Notifier, error) {
+	log := logging.DefaultLogger()
+	providers := make(map[nModel.NotificationPlatform]NotificationProvider)
+	for _, provider := range p.Providers {
+		if _, ok := providers[provider.Platform()]; ok {
+			return nil, fmt.Errorf("notification provider for platform %d is registered twice", provider.Platform())
+		}
+		if err := provider.CheckConfig(); err != nil {
+			log.Warnw("Notification provider is not configured, skipping", "platform", provider.Platform(), "error", err)
+			continue
+		}
+		providers[provider.Platform()] = provider
+	}
 	return &Notifier{
-		Telegram:       t,
-		Pushover:       p,
-		eventsProducer: ep,
-		discord:        d,
+		providers:      providers,
+		eventsProducer: p.EventsProducer,
+	}, nil
+}
+
+// ValidateTarget checks the target ID with the provider of the platform.
+func (n *Notifier) ValidateTarget(platform nModel.NotificationPlatform, targetID string) error {
+	provider, ok := n.providers[platform]
+	if !ok {
+		if platform == nModel.NotificationPlatformWebhook {
+			// without a webhook provider the scheduler only posts the raw event to the circle webhook,
+			// so there is no target to validate
+			return nil
+		}
+		return ErrPlatformNotAvailable
 	}
+	return provider.ValidateTarget(targetID)
 }
 
 func (n *Notifier) SendNotification(c context.Context, notification *nModel.NotificationDetails) error {
 	log := logging.FromContext(c)
-	var err error
-	switch notification.TypeID {
-	case nModel.NotificationPlatformTelegram:
-		if n.Telegram == nil {
-			log.Error("Telegram bot is not initialized, Skipping sending message")
+	provider, ok := n.providers[notification.TypeID]
+	if !ok {
+		if notification.TypeID == nModel.NotificationPlatformWebhook {
+			// currently we have eventProducer to send events always as a webhook
+			// if NotificationPlatform is selected. this a case to catch
+			// when we only want to send a webhook
 			return nil
 		}
-		err = n.Telegram.SendNotification(c, notification)
-	case nModel.NotificationPlatformPushover:
-		if n.Pushover == nil {
-			log.Error("Pushover is not initialized, Skipping sending message")
-			return nil
-		}
-		err = n.Pushover.SendNotification(c, notification)
-	case nModel.NotificationPlatformDiscord:
-		if n.discord == nil {
-			log.Error("Discord is not initialized, Skipping sending message")
-			return nil
-		}
-		err = n.discord.SendNotification(c, notification)
-
-	case nModel.NotificationPlatformWebhook:
-		// TODO: Implement webhook notification
-		// currently we have eventProducer to send events always as a webhook
-		// if NotificationPlatform is selected. this a case to catch
-		// when we only want to send a webhook
-
-	default:
-		log.Error("Unknown notification type", "type", notification.TypeID)
-		return nil
+		log.Error("Notification platform is not available, Skipping sending message", "type", notification.TypeID)
+		return ErrPlatformNotAvailable
 	}
-	if err != nil {
+
+	if err := provider.SendNotification(c, notification); err != nil {
 		log.Error("Failed to send notification", "err", err)
+		return err
 	}
 
 	return nil
 }
+
+// SendTestNotification sends a sample reminder to the target so a user can check it before relying
+// on it. Unlike SendNotification the provider error is returned.
+func (n *Notifier) SendTestNotification(c context.Context, platform nModel.NotificationPlatform, targetID string, userID int, assignee string, assigneeUsername string) error {
+	provider, ok := n.providers[platform]
+	if !ok {
+		return ErrPlatformNotAvailable
+	}
+	return provider.SendNotification(c, &nModel.NotificationDetails{
+		Notification: nModel.Notification{
+			UserID:   userID,
+			TargetID: targetID,
+			TypeID:   platform,
+			Text:     fmt.Sprintf("🔔 This is a test notification from Donetick for %s.", assignee),
+			RawEvent: nModel.JSONB{
+				"id":                0,
+				"type":              "test",
+				"name":              "Test chore",
+				"priority":          0,
+				"due_date":          time.Now().UTC(),
+				"assignee":          assignee,
+				"assignee_username": assigneeUsername,
+			},
+		},
+	})
+}
@@ -0,0 +1,82 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	nModel "donetick.com/core/internal/notifier/model"
+)
+
+type fakeProvider struct {
+	platform  nModel.NotificationPlatform
+	configErr error
+	sent      []*nModel.NotificationDetails
+}
+
+func (f *fakeProvider) Platform() nModel.NotificationPlatform { return f.platform }
+func (f *fakeProvider) CheckConfig() error                    { return f.configErr }
+func (f *fakeProvider) ValidateTarget(targetID string) error {
+	if targetID == "" {
+		return errors.New("target is empty")
+	}
+	return nil
+}
+func (f *fakeProvider) SendNotification(c context.Context, notification *nModel.NotificationDetails) error {
+	f.sent = append(f.sent, notification)
+	return nil
+}
+
+func TestNotifierRegistry(t *testing.T) {
+	telegram := &fakeProvider{platform: nModel.NotificationPlatformTelegram}
+	pushover := &fakeProvider{platform: nModel.NotificationPlatformPushover, configErr: errors.New("pushover.token is not set")}
+
+	n, err := NewNotifier(NotifierParams{Providers: []NotificationProvider{telegram, pushover}})
+	if err != nil {
+		t.Fatalf("NewNotifier() error = %v", err)
+	}
+
+	notification := &nModel.NotificationDetails{Notification: nModel.Notification{TypeID: nModel.NotificationPlatformTelegram, TargetID: "1"}}
+	if err := n.SendNotification(context.Background(), notification); err != nil {
+		t.Fatalf("SendNotification() error = %v", err)
+	}
+	if len(telegram.sent) != 1 {
+		t.Errorf("telegram provider got %d notifications, want 1", len(telegram.sent))
+	}
+
+	notification.TypeID = nModel.NotificationPlatformPushover
+	if err := n.SendNotification(context.Background(), notification); !errors.Is(err, ErrPlatformNotAvailable) {
+		t.Fatalf("SendNotification() error = %v, want %v", err, ErrPlatformNotAvailable)
+	}
+	if len(pushover.sent) != 0 {
+		t.Errorf("unconfigured provider should not be registered")
+	}
+
+	if err := n.ValidateTarget(nModel.NotificationPlatformTelegram, ""); err == nil {
+		t.Errorf("ValidateTarget() should use the provider validation")
+	}
+	if err := n.ValidateTarget(nModel.NotificationPlatformPushover, "key"); !errors.Is(err, ErrPlatformNotAvailable) {
+		t.Errorf("ValidateTarget() error = %v, want %v", err, ErrPlatformNotAvailable)
+	}
+	if err := n.ValidateTarget(nModel.NotificationPlatformWebhook, ""); err != nil {
+		t.Errorf("ValidateTarget() for webhook without a provider error = %v", err)
+	}
+
+	if _, err := NewNotifier(NotifierParams{Providers: []NotificationProvider{telegram, &fakeProvider{platform: nModel.NotificationPlatformTelegram}}}); err == nil {
+		t.Errorf("NewNotifier() should reject two providers for the same platform")
+	}
+}
+
+func TestNotificationRetry(t *testing.T) {
+	now := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	for attempts, want := range map[int]time.Duration{1: 5 * time.Minute, 2: 10 * time.Minute, 3: 20 * time.Minute, 4: 40 * time.Minute} {
+		next, retry := notificationRetry(attempts, now)
+		if !retry || next.Sub(now) != want {
+			t.Errorf("notificationRetry(%d) = %v, %v, want retry in %v", attempts, next, retry, want)
+		}
+	}
+	if _, retry := notificationRetry(maxNotificationAttempts, now); retry {
+		t.Errorf("notificationRetry(%d) should dead-letter the notification", maxNotificationAttempts)
+	}
+}
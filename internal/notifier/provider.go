@@ -0,0 +1,35 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+
+	nModel "donetick.com/core/internal/notifier/model"
+	"go.uber.org/fx"
+)
+
+var ErrPlatformNotAvailable = errors.New("notification platform is not available")
+
+// NotificationProvider delivers notifications for a single NotificationPlatform.
+type NotificationProvider interface {
+	Platform() nModel.NotificationPlatform
+	// CheckConfig reports the missing or invalid configuration the provider needs to run,
+	// providers that fail it are not registered.
+	CheckConfig() error
+	// ValidateTarget checks the target ID a user sets for this platform before it is saved.
+	ValidateTarget(targetID string) error
+	SendNotification(c context.Context, notification *nModel.NotificationDetails) error
+}
+
+const providerGroup = `group:"notification_providers"`
+
+// AsProvider annotates a provider constructor so fx adds its result to the notifier registry:
+//
+//	fx.Provide(notifier.AsProvider(telegram.NewTelegramNotifier))
+func AsProvider(constructor interface{}) interface{} {
+	return fx.Annotate(
+		constructor,
+		fx.As(new(NotificationProvider)),
+		fx.ResultTags(providerGroup),
+	)
+}
@@ -17,7 +17,8 @@ func NewNotificationRepository(db *gorm.DB) *NotificationRepository {
 }
 
 func (r *NotificationRepository) DeleteAllChoreNotifications(choreID int) error {
-	return r.db.Where("chore_id = ?", choreID).Delete(&nModel.Notification{}).Error
+	// dead-lettered notifications stay around so admins can still see what failed
+	return r.db.Where("chore_id = ? AND is_failed = ?", choreID, false).Delete(&nModel.Notification{}).Error
 }
 
 func (r *NotificationRepository) BatchInsertNotifications(notifications []*nModel.Notification) error {
@@ -39,7 +40,35 @@ func (r *NotificationRepository) GetPendingNotificaiton(c context.Context, lookb
 	if err := r.db.Table("notifications").
 		Select("notifications.*, circles.webhook_url as webhook_url").
 		Joins("left join circles on circles.id = notifications.circle_id").
-		Where("notifications.is_sent = ? AND notifications.scheduled_for < ? AND notifications.scheduled_for > ?", false, end, start).
+		Where("notifications.is_sent = ? AND notifications.is_failed = ? AND notifications.scheduled_for < ?", false, false, end).
+		// retries keep going after the lookback window, they are only picked up once their backoff passed
+		Where("(notifications.next_attempt_at IS NULL AND notifications.scheduled_for > ?) OR notifications.next_attempt_at <= ?", start, end).
+		Find(&notifications).Error; err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+// MarkNotificationAttemptFailed records a failed delivery, the notification is retried at nextAttemptAt
+// or dead-lettered when failed is set.
+func (r *NotificationRepository) MarkNotificationAttemptFailed(c context.Context, notification *nModel.NotificationDetails, lastError string, nextAttemptAt *time.Time, failed bool) error {
+	return r.db.WithContext(c).Model(&nModel.Notification{}).Where("id = ?", notification.ID).Updates(map[string]interface{}{
+		"attempts":        notification.Attempts,
+		"last_error":      lastError,
+		"next_attempt_at": nextAttemptAt,
+		"is_failed":       failed,
+	}).Error
+}
+
+// GetFailedNotifications returns the dead-lettered notifications of the chores of a circle, newest first.
+func (r *NotificationRepository) GetFailedNotifications(c context.Context, circleID int) ([]*nModel.Notification, error) {
+	var notifications []*nModel.Notification
+	// group notifications don't store the circle of the chore in circle_id, so go through the chore
+	if err := r.db.WithContext(c).Table("notifications").
+		Select("notifications.*").
+		Joins("join chores on chores.id = notifications.chore_id").
+		Where("chores.circle_id = ? AND notifications.is_failed = ?", circleID, true).
+		Order("notifications.scheduled_for desc").
 		Find(&notifications).Error; err != nil {
 		return nil, err
 	}
@@ -47,5 +76,5 @@ func (r *NotificationRepository) GetPendingNotificaiton(c context.Context, lookb
 }
 
 func (r *NotificationRepository) DeleteSentNotifications(c context.Context, since time.Time) error {
-	return r.db.WithContext(c).Where("is_sent = ? AND scheduled_for < ?", true, since).Delete(&nModel.Notification{}).Error
+	return r.db.WithContext(c).Where("(is_sent = ? OR is_failed = ?) AND scheduled_for < ?", true, true, since).Delete(&nModel.Notification{}).Error
 }
@@ -2,12 +2,14 @@ package notifier
 
 import (
 	"context"
+	"errors"
 	"log"
 	"time"
 
 	"donetick.com/core/config"
 	chRepo "donetick.com/core/internal/chore/repo"
 	"donetick.com/core/internal/events"
+	nModel "donetick.com/core/internal/notifier/model"
 	nRepo "donetick.com/core/internal/notifier/repo"
 	uRepo "donetick.com/core/internal/user/repo"
 	"donetick.com/core/logging"
@@ -19,6 +21,12 @@ const (
 	SchedulerKey keyType = "scheduler"
 )
 
+const (
+	// with a 5 minute backoff a notification is retried after 5, 10, 20 and 40 minutes before it is dead-lettered
+	maxNotificationAttempts  = 5
+	notificationRetryBackoff = 5 * time.Minute
+)
+
 type Scheduler struct {
 	choreRepo        *chRepo.ChoreRepository
 	userRepo         *uRepo.UserRepository
@@ -69,23 +77,62 @@ func (s *Scheduler) loadAndSendNotificationJob(c context.Context) (time.Duration
 		return time.Since(startTime), err
 	}
 
+	sent := make([]*nModel.NotificationDetails, 0, len(getAllPendingNotifications))
 	for _, notification := range getAllPendingNotifications {
 		err := s.notifier.SendNotification(c, notification)
 		if err != nil {
-			log.Error("Error sending notification", err)
+			s.recordFailedAttempt(c, notification, err)
 			continue
 		}
-		if notification.RawEvent != nil && notification.WebhookURL != nil {
-			// if we have a webhook url, we should send the event to the webhook
-			s.eventsProducer.NotificationEvent(c, *notification.WebhookURL, notification.RawEvent)
+		if notification.RawEvent != nil {
+			// sent to the circle webhook URL and the webhook subscriptions of the circle
+			s.eventsProducer.NotificationEvent(c, notification.CircleID, notification.ChoreID, notification.WebhookURL, notification.RawEvent)
 		}
 
 		notification.IsSent = true
+		sent = append(sent, notification)
 	}
 
-	s.notificationRepo.MarkNotificationsAsSent(getAllPendingNotifications)
+	if len(sent) > 0 {
+		if err := s.notificationRepo.MarkNotificationsAsSent(sent); err != nil {
+			log.Error("Error marking notifications as sent", err)
+			return time.Since(startTime), err
+		}
+	}
 	return time.Since(startTime), nil
 }
+
+// recordFailedAttempt schedules the next delivery attempt of a notification, or dead-letters it once
+// it ran out of attempts or its platform is not available at all.
+func (s *Scheduler) recordFailedAttempt(c context.Context, notification *nModel.NotificationDetails, sendErr error) {
+	log := logging.FromContext(c)
+	notification.Attempts++
+	nextAttemptAt, retry := notificationRetry(notification.Attempts, time.Now().UTC())
+	if errors.Is(sendErr, ErrPlatformNotAvailable) {
+		retry = false
+	}
+	if retry {
+		log.Warnw("Error sending notification, will retry", "id", notification.ID, "attempts", notification.Attempts, "next_attempt_at", nextAttemptAt, "err", sendErr)
+		err := s.notificationRepo.MarkNotificationAttemptFailed(c, notification, sendErr.Error(), &nextAttemptAt, false)
+		if err != nil {
+			log.Error("Error recording failed notification attempt", err)
+		}
+		return
+	}
+	log.Errorw("Error sending notification, giving up", "id", notification.ID, "attempts", notification.Attempts, "err", sendErr)
+	if err := s.notificationRepo.MarkNotificationAttemptFailed(c, notification, sendErr.Error(), nil, true); err != nil {
+		log.Error("Error recording failed notification attempt", err)
+	}
+}
+
+// notificationRetry returns when to retry a notification after its attempts-th failed delivery, the
+// backoff doubles with every attempt. It returns false when no attempts are left.
+func notificationRetry(attempts int, now time.Time) (time.Time, bool) {
+	if attempts >= maxNotificationAttempts {
+		return time.Time{}, false
+	}
+	return now.Add(notificationRetryBackoff << (attempts - 1)), true
+}
 func (s *Scheduler) runScheduler(c context.Context, jobName string, job func(c context.Context) (time.Duration, error), interval time.Duration) {
 
 	for {
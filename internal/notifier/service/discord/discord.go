@@ -7,19 +7,51 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 
 	"donetick.com/core/config"
 	chModel "donetick.com/core/internal/chore/model"
 	nModel "donetick.com/core/internal/notifier/model"
 	uModel "donetick.com/core/internal/user/model"
+	"donetick.com/core/internal/utils"
 	"donetick.com/core/logging"
 )
 
 type DiscordNotifier struct {
+	client *http.Client
+	egress *utils.EgressPolicy
 }
 
 func NewDiscordNotifier(config *config.Config) *DiscordNotifier {
-	return &DiscordNotifier{}
+	egress := utils.NewEgressPolicy(config)
+	return &DiscordNotifier{
+		client: egress.NewHTTPClient(0),
+		egress: egress,
+	}
+}
+
+func (dn *DiscordNotifier) Platform() nModel.NotificationPlatform {
+	return nModel.NotificationPlatformDiscord
+}
+
+// CheckConfig always passes, the webhook URL is the target so there is nothing to configure.
+func (dn *DiscordNotifier) CheckConfig() error {
+	return nil
+}
+
+func (dn *DiscordNotifier) ValidateTarget(targetID string) error {
+	webhookURL, err := url.Parse(targetID)
+	if err != nil || webhookURL.Scheme != "https" {
+		return errors.New("discord target must be an https webhook URL")
+	}
+	if !strings.HasPrefix(webhookURL.Path, "/api/webhooks/") {
+		return errors.New("discord target must be a webhook URL, e.g. https://discord.com/api/webhooks/...")
+	}
+	if err := dn.egress.CheckHost(webhookURL.Hostname()); err != nil {
+		return errors.New("discord target must not be a loopback or private address")
+	}
+	return nil
 }
 
 func (dn *DiscordNotifier) SendChoreCompletion(c context.Context, chore *chModel.Chore, user *uModel.User) {
@@ -63,7 +95,7 @@ func (dn *DiscordNotifier) sendMessage(c context.Context, webhookURL string, mes
 		return err
 	}
 
-	resp, err := http.Post(webhookURL, "application/json", bytes.NewBuffer(jsonData))
+	resp, err := dn.client.Post(webhookURL, "application/json", bytes.NewBuffer(jsonData))
 	if err != nil {
 		log.Error("Error sending message to Discord:", err)
 		return err
@@ -0,0 +1,48 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"donetick.com/core/config"
+	nModel "donetick.com/core/internal/notifier/model"
+)
+
+func TestSendNotification(t *testing.T) {
+	var got map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("invalid body: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	dn := &DiscordNotifier{client: server.Client()}
+	err := dn.SendNotification(context.Background(), &nModel.NotificationDetails{
+		Notification: nModel.Notification{TargetID: server.URL + "/api/webhooks/1/token", Text: "Dishes are due"},
+	})
+	if err != nil {
+		t.Fatalf("SendNotification() error = %v", err)
+	}
+	if got["content"] != "Dishes are due" {
+		t.Errorf("content = %q", got["content"])
+	}
+}
+
+func TestValidateTarget(t *testing.T) {
+	dn := NewDiscordNotifier(&config.Config{})
+	for target, valid := range map[string]bool{
+		"https://discord.com/api/webhooks/123/abc": true,
+		"http://discord.com/api/webhooks/123/abc":  false,
+		"https://discord.com/channels/123":         false,
+		"123456":                                   false,
+	} {
+		if err := dn.ValidateTarget(target); (err == nil) != valid {
+			t.Errorf("ValidateTarget(%q) error = %v, want valid %v", target, err, valid)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package gotify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"donetick.com/core/config"
+	nModel "donetick.com/core/internal/notifier/model"
+	"donetick.com/core/logging"
+)
+
+type Gotify struct {
+	serverURL string
+	token     string
+	publicURL string
+	client    *http.Client
+}
+
+type message struct {
+	Title    string                 `json:"title"`
+	Message  string                 `json:"message"`
+	Priority int                    `json:"priority"`
+	Extras   map[string]interface{} `json:"extras"`
+}
+
+func NewGotify(cfg *config.Config) *Gotify {
+	return &Gotify{
+		serverURL: strings.TrimRight(cfg.Gotify.ServerURL, "/"),
+		token:     cfg.Gotify.Token,
+		publicURL: strings.TrimRight(cfg.Server.PublicURL, "/"),
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (g *Gotify) Platform() nModel.NotificationPlatform {
+	return nModel.NotificationPlatformGotify
+}
+
+func (g *Gotify) CheckConfig() error {
+	if g.serverURL == "" {
+		return errors.New("gotify.server_url is not set")
+	}
+	return nil
+}
+
+// ValidateTarget accepts the user's application token, or no target when a default token is configured.
+func (g *Gotify) ValidateTarget(targetID string) error {
+	if targetID == "" {
+		if g.token == "" {
+			return errors.New("gotify application token is required")
+		}
+		return nil
+	}
+	if strings.ContainsAny(targetID, " /?#") {
+		return errors.New("gotify target must be an application token")
+	}
+	return nil
+}
+
+func (g *Gotify) SendNotification(c context.Context, notification *nModel.NotificationDetails) error {
+	token := notification.TargetID
+	if token == "" {
+		token = g.token
+	}
+	if token == "" {
+		return errors.New("unable to send notification, targetID is empty")
+	}
+	log := logging.FromContext(c)
+
+	msg := message{
+		Title:    "Donetick",
+		Message:  notification.Text,
+		Priority: priority(notification.ChorePriority()),
+		Extras: map[string]interface{}{
+			"client::display": map[string]string{"contentType": "text/markdown"},
+		},
+	}
+	if g.publicURL != "" && notification.ChoreID != 0 {
+		msg.Extras["client::notification"] = map[string]interface{}{
+			"click": map[string]string{"url": fmt.Sprintf("%s/chores/%d", g.publicURL, notification.ChoreID)},
+		}
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(c, http.MethodPost, g.serverURL+"/message", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gotify-Key", token)
+	resp, err := g.client.Do(req)
+	if err != nil {
+		log.Debug("Error sending gotify notification", err)
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("gotify server returned unexpected status: %s", resp.Status)
+	}
+	return nil
+}
+
+// priority maps the chore priority (1 is the highest, 0 is none) to gotify's 0-10 scale, the
+// android app only shows a notification from 4 up and treats 8 and above as high priority.
+func priority(chorePriority int) int {
+	switch chorePriority {
+	case 1:
+		return 8
+	case 2:
+		return 6
+	case 4:
+		return 4
+	default:
+		return 5
+	}
+}
@@ -0,0 +1,78 @@
+package gotify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"donetick.com/core/config"
+	nModel "donetick.com/core/internal/notifier/model"
+)
+
+func TestSendNotification(t *testing.T) {
+	var got map[string]interface{}
+	var key, path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, path = r.Header.Get("X-Gotify-Key"), r.URL.Path
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("invalid body: %v", err)
+		}
+		w.Write([]byte(`{"id":1}`))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		Gotify: config.GotifyConfig{ServerURL: server.URL, Token: "AdefaultToken"},
+		Server: config.ServerConfig{PublicURL: "https://donetick.example.com"},
+	}
+	g := NewGotify(cfg)
+	g.client = server.Client()
+	if err := g.CheckConfig(); err != nil {
+		t.Fatalf("CheckConfig() error = %v", err)
+	}
+
+	err := g.SendNotification(context.Background(), &nModel.NotificationDetails{
+		Notification: nModel.Notification{
+			ChoreID:  12,
+			TargetID: "AuserToken",
+			Text:     "Dishes are due",
+			RawEvent: nModel.JSONB{"priority": float64(2)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SendNotification() error = %v", err)
+	}
+	if path != "/message" || key != "AuserToken" {
+		t.Errorf("sent to %q with key %q", path, key)
+	}
+	if got["message"] != "Dishes are due" || got["priority"] != float64(6) {
+		t.Errorf("sent %v", got)
+	}
+	extras := got["extras"].(map[string]interface{})
+	click := extras["client::notification"].(map[string]interface{})["click"].(map[string]interface{})
+	if click["url"] != "https://donetick.example.com/chores/12" {
+		t.Errorf("click url = %v", click["url"])
+	}
+
+	// without a target the configured token is used
+	if err := g.SendNotification(context.Background(), &nModel.NotificationDetails{
+		Notification: nModel.Notification{Text: "Dishes are due"},
+	}); err != nil {
+		t.Fatalf("SendNotification() error = %v", err)
+	}
+	if key != "AdefaultToken" {
+		t.Errorf("key = %q, want the configured token", key)
+	}
+}
+
+func TestCheckConfig(t *testing.T) {
+	g := NewGotify(&config.Config{})
+	if err := g.CheckConfig(); err == nil {
+		t.Errorf("CheckConfig() should fail without a server URL")
+	}
+	if err := g.ValidateTarget(""); err == nil {
+		t.Errorf("ValidateTarget() should require a token when none is configured")
+	}
+}
@@ -0,0 +1,126 @@
+package matrix
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"donetick.com/core/config"
+	nModel "donetick.com/core/internal/notifier/model"
+	"donetick.com/core/logging"
+)
+
+// room IDs look like !opaque:server, aliases can't be used to send messages
+var roomIDPattern = regexp.MustCompile(`^![^:\s]+:\S+$`)
+
+// the *bold* and _italic_ markdown used by the notification texts written for Telegram
+var (
+	boldPattern   = regexp.MustCompile(`\*([^*\n]+)\*`)
+	italicPattern = regexp.MustCompile(`\b_([^_\n]+)_\b`)
+)
+
+type Matrix struct {
+	homeserverURL string
+	accessToken   string
+	client        *http.Client
+	txnCounter    atomic.Uint64
+}
+
+type message struct {
+	MsgType       string `json:"msgtype"`
+	Body          string `json:"body"`
+	Format        string `json:"format"`
+	FormattedBody string `json:"formatted_body"`
+}
+
+func NewMatrix(cfg *config.Config) *Matrix {
+	return &Matrix{
+		homeserverURL: strings.TrimRight(cfg.Matrix.HomeserverURL, "/"),
+		accessToken:   cfg.Matrix.AccessToken,
+		client:        &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (m *Matrix) Platform() nModel.NotificationPlatform {
+	return nModel.NotificationPlatformMatrix
+}
+
+func (m *Matrix) CheckConfig() error {
+	if m.homeserverURL == "" || m.accessToken == "" {
+		return errors.New("matrix.homeserver_url and matrix.access_token are not set")
+	}
+	return nil
+}
+
+func (m *Matrix) ValidateTarget(targetID string) error {
+	if !roomIDPattern.MatchString(targetID) {
+		return errors.New("matrix target must be a room ID like !room:example.org")
+	}
+	return nil
+}
+
+func (m *Matrix) SendNotification(c context.Context, notification *nModel.NotificationDetails) error {
+	if notification.TargetID == "" {
+		return errors.New("unable to send notification, targetID is empty")
+	}
+	log := logging.FromContext(c)
+
+	body, err := json.Marshal(message{
+		MsgType:       "m.text",
+		Body:          plainText(notification.Text),
+		Format:        "org.matrix.custom.html",
+		FormattedBody: formatHTML(notification.Text),
+	})
+	if err != nil {
+		return err
+	}
+
+	// the transaction ID only has to be unique for the access token, retries of the same request reuse it
+	txnID := fmt.Sprintf("donetick-%d-%d", time.Now().UnixNano(), m.txnCounter.Add(1))
+	endpoint := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s",
+		m.homeserverURL, url.PathEscape(notification.TargetID), url.PathEscape(txnID))
+	req, err := http.NewRequestWithContext(c, http.MethodPut, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+m.accessToken)
+	resp, err := m.client.Do(req)
+	if err != nil {
+		log.Debug("Error sending matrix notification", err)
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var matrixErr struct {
+			ErrCode string `json:"errcode"`
+			Error   string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&matrixErr)
+		return fmt.Errorf("matrix homeserver returned %s: %s %s", resp.Status, matrixErr.ErrCode, matrixErr.Error)
+	}
+	return nil
+}
+
+// formatHTML renders the markdown of a notification text as the HTML subset Matrix clients display.
+func formatHTML(text string) string {
+	escaped := html.EscapeString(text)
+	escaped = boldPattern.ReplaceAllString(escaped, "<strong>$1</strong>")
+	escaped = italicPattern.ReplaceAllString(escaped, "<em>$1</em>")
+	return strings.ReplaceAll(escaped, "\n", "<br>")
+}
+
+// plainText is the fallback body for clients without HTML support.
+func plainText(text string) string {
+	text = boldPattern.ReplaceAllString(text, "$1")
+	return italicPattern.ReplaceAllString(text, "$1")
+}
@@ -0,0 +1,92 @@
+package matrix
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"donetick.com/core/config"
+	nModel "donetick.com/core/internal/notifier/model"
+)
+
+func TestSendNotification(t *testing.T) {
+	var got message
+	var auth, path, method string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth, path, method = r.Header.Get("Authorization"), r.URL.EscapedPath(), r.Method
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("invalid body: %v", err)
+		}
+		w.Write([]byte(`{"event_id":"$abc"}`))
+	}))
+	defer server.Close()
+
+	m := NewMatrix(&config.Config{Matrix: config.MatrixConfig{HomeserverURL: server.URL + "/", AccessToken: "syt_secret"}})
+	m.client = server.Client()
+	if err := m.CheckConfig(); err != nil {
+		t.Fatalf("CheckConfig() error = %v", err)
+	}
+
+	err := m.SendNotification(context.Background(), &nModel.NotificationDetails{
+		Notification: nModel.Notification{
+			TargetID: "!family:example.org",
+			Text:     "📅 Reminder: *Dishes & pans* is due today and assigned to Alex.",
+		},
+	})
+	if err != nil {
+		t.Fatalf("SendNotification() error = %v", err)
+	}
+	if method != http.MethodPut || !strings.HasPrefix(path, "/_matrix/client/v3/rooms/%21family:example.org/send/m.room.message/donetick-") {
+		t.Errorf("sent %s %s", method, path)
+	}
+	if auth != "Bearer syt_secret" {
+		t.Errorf("Authorization = %q", auth)
+	}
+	want := message{
+		MsgType:       "m.text",
+		Body:          "📅 Reminder: Dishes & pans is due today and assigned to Alex.",
+		Format:        "org.matrix.custom.html",
+		FormattedBody: "📅 Reminder: <strong>Dishes &amp; pans</strong> is due today and assigned to Alex.",
+	}
+	if got != want {
+		t.Errorf("sent %+v, want %+v", got, want)
+	}
+}
+
+func TestSendNotificationError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"errcode":"M_FORBIDDEN","error":"User not in room"}`))
+	}))
+	defer server.Close()
+
+	m := NewMatrix(&config.Config{Matrix: config.MatrixConfig{HomeserverURL: server.URL, AccessToken: "syt_secret"}})
+	m.client = server.Client()
+	err := m.SendNotification(context.Background(), &nModel.NotificationDetails{
+		Notification: nModel.Notification{TargetID: "!family:example.org", Text: "Dishes"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "M_FORBIDDEN") {
+		t.Errorf("SendNotification() error = %v, want the homeserver error", err)
+	}
+}
+
+func TestValidateTarget(t *testing.T) {
+	m := NewMatrix(&config.Config{})
+	if err := m.CheckConfig(); err == nil {
+		t.Errorf("CheckConfig() should fail without a homeserver")
+	}
+	for target, valid := range map[string]bool{
+		"!family:example.org":      true,
+		"!family:example.org:8448": true,
+		"#family:example.org":      false,
+		"family":                   false,
+		"":                         false,
+	} {
+		if err := m.ValidateTarget(target); (err == nil) != valid {
+			t.Errorf("ValidateTarget(%q) error = %v, want valid %v", target, err, valid)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package ntfy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"donetick.com/core/config"
+	nModel "donetick.com/core/internal/notifier/model"
+	"donetick.com/core/logging"
+)
+
+const defaultServerURL = "https://ntfy.sh"
+
+// same rule ntfy applies to topic names
+var topicPattern = regexp.MustCompile(`^[-_A-Za-z0-9]{1,64}$`)
+
+type Ntfy struct {
+	serverURL string
+	token     string
+	publicURL string
+	client    *http.Client
+}
+
+type message struct {
+	Topic    string `json:"topic"`
+	Title    string `json:"title"`
+	Message  string `json:"message"`
+	Priority int    `json:"priority,omitempty"`
+	Click    string `json:"click,omitempty"`
+	Markdown bool   `json:"markdown"`
+}
+
+func NewNtfy(cfg *config.Config) *Ntfy {
+	serverURL := cfg.Ntfy.ServerURL
+	if serverURL == "" {
+		serverURL = defaultServerURL
+	}
+	return &Ntfy{
+		serverURL: strings.TrimRight(serverURL, "/"),
+		token:     cfg.Ntfy.Token,
+		publicURL: strings.TrimRight(cfg.Server.PublicURL, "/"),
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (n *Ntfy) Platform() nModel.NotificationPlatform {
+	return nModel.NotificationPlatformNtfy
+}
+
+// CheckConfig always passes, without a server URL the public ntfy.sh server is used.
+func (n *Ntfy) CheckConfig() error {
+	return nil
+}
+
+func (n *Ntfy) ValidateTarget(targetID string) error {
+	if !topicPattern.MatchString(targetID) {
+		return errors.New("ntfy topic can only contain letters, digits, - and _ and be up to 64 characters")
+	}
+	return nil
+}
+
+func (n *Ntfy) SendNotification(c context.Context, notification *nModel.NotificationDetails) error {
+	if notification.TargetID == "" {
+		return errors.New("unable to send notification, targetID is empty")
+	}
+	log := logging.FromContext(c)
+
+	msg := message{
+		Topic:    notification.TargetID,
+		Title:    "Donetick",
+		Message:  notification.Text,
+		Priority: priority(notification.ChorePriority()),
+		Markdown: true,
+	}
+	if n.publicURL != "" && notification.ChoreID != 0 {
+		msg.Click = fmt.Sprintf("%s/chores/%d", n.publicURL, notification.ChoreID)
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	// publishing as JSON to the root URL keeps emoji and markdown out of HTTP headers
+	req, err := http.NewRequestWithContext(c, http.MethodPost, n.serverURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.token)
+	}
+	resp, err := n.client.Do(req)
+	if err != nil {
+		log.Debug("Error sending ntfy notification", err)
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ntfy server returned unexpected status: %s", resp.Status)
+	}
+	return nil
+}
+
+// priority maps the chore priority (1 is the highest, 0 is none) to ntfy's 1-5 scale where 3 is the default.
+func priority(chorePriority int) int {
+	switch chorePriority {
+	case 1:
+		return 5
+	case 2:
+		return 4
+	case 4:
+		return 2
+	default:
+		return 3
+	}
+}
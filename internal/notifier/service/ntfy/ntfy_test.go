@@ -0,0 +1,88 @@
+package ntfy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"donetick.com/core/config"
+	nModel "donetick.com/core/internal/notifier/model"
+)
+
+func TestSendNotification(t *testing.T) {
+	var got message
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("invalid body: %v", err)
+		}
+		w.Write([]byte(`{"id":"sPs71M8A2T"}`))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		Ntfy:   config.NtfyConfig{ServerURL: server.URL + "/", Token: "tk_secret"},
+		Server: config.ServerConfig{PublicURL: "https://donetick.example.com/"},
+	}
+	n := NewNtfy(cfg)
+	n.client = server.Client()
+
+	err := n.SendNotification(context.Background(), &nModel.NotificationDetails{
+		Notification: nModel.Notification{
+			ChoreID:  12,
+			TargetID: "chores-home",
+			Text:     "📅 Reminder: *Dishes* is due today",
+			RawEvent: nModel.JSONB{"priority": float64(1)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SendNotification() error = %v", err)
+	}
+	want := message{
+		Topic:    "chores-home",
+		Title:    "Donetick",
+		Message:  "📅 Reminder: *Dishes* is due today",
+		Priority: 5,
+		Click:    "https://donetick.example.com/chores/12",
+		Markdown: true,
+	}
+	if got != want {
+		t.Errorf("sent %+v, want %+v", got, want)
+	}
+	if auth != "Bearer tk_secret" {
+		t.Errorf("Authorization = %q", auth)
+	}
+}
+
+func TestSendNotificationError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	n := NewNtfy(&config.Config{Ntfy: config.NtfyConfig{ServerURL: server.URL}})
+	n.client = server.Client()
+	err := n.SendNotification(context.Background(), &nModel.NotificationDetails{
+		Notification: nModel.Notification{TargetID: "chores-home", Text: "Dishes"},
+	})
+	if err == nil {
+		t.Errorf("SendNotification() should fail when the server rejects the message")
+	}
+}
+
+func TestValidateTarget(t *testing.T) {
+	n := NewNtfy(&config.Config{})
+	for target, valid := range map[string]bool{
+		"chores-home_1":        true,
+		"":                     false,
+		"chores/home":          false,
+		"https://ntfy.sh/home": false,
+	} {
+		if err := n.ValidateTarget(target); (err == nil) != valid {
+			t.Errorf("ValidateTarget(%q) error = %v, want valid %v", target, err, valid)
+		}
+	}
+}
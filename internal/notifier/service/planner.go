@@ -81,7 +81,9 @@ func generateDueNotifications(chore *chModel.Chore, assignedUser *cModel.UserCir
 		Text:     fmt.Sprintf("📅 Reminder: *%s* is due today and assigned to %s.", chore.Name, assignedUser.DisplayName),
 		RawEvent: map[string]interface{}{
 			"id":                chore.ID,
+			"type":              EventTypeDue,
 			"name":              chore.Name,
+			"priority":          chore.Priority,
 			"due_date":          chore.NextDueDate,
 			"assignee":          assignedUser.DisplayName,
 			"assignee_username": assignedUser.Username,
@@ -107,7 +109,9 @@ func generatePreDueNotifications(chore *chModel.Chore, assignedUser *cModel.User
 
 		RawEvent: map[string]interface{}{
 			"id":                chore.ID,
+			"type":              EventTypePreDue,
 			"name":              chore.Name,
+			"priority":          chore.Priority,
 			"due_date":          chore.NextDueDate,
 			"assignee":          assignedUser.DisplayName,
 			"assignee_username": assignedUser.Username,
@@ -136,6 +140,7 @@ func generateOverdueNotifications(chore *chModel.Chore, assignedUser *cModel.Use
 				"id":                chore.ID,
 				"type":              EventTypeOverdue,
 				"name":              chore.Name,
+				"priority":          chore.Priority,
 				"due_date":          chore.NextDueDate,
 				"assignee":          assignedUser.DisplayName,
 				"assignee_username": assignedUser.Username,
@@ -150,7 +155,13 @@ func generateOverdueNotifications(chore *chModel.Chore, assignedUser *cModel.Use
 
 func generateCircleGroupNotifications(chore *chModel.Chore, mt *chModel.NotificationMetadata) []*nModel.Notification {
 	var notifications []*nModel.Notification
-	if !mt.CircleGroup || mt.CircleGroupID == nil || *mt.CircleGroupID == 0 {
+	if !mt.CircleGroup {
+		return notifications
+	}
+	if mt.CircleGroupRoomID != "" {
+		notifications = append(notifications, generateMatrixGroupNotifications(chore, mt)...)
+	}
+	if mt.CircleGroupID == nil || *mt.CircleGroupID == 0 {
 		return notifications
 	}
 	if mt.DueDate {
@@ -159,7 +170,7 @@ func generateCircleGroupNotifications(chore *chModel.Chore, mt *chModel.Notifica
 			IsSent:       false,
 			ScheduledFor: *chore.NextDueDate,
 			CreatedAt:    time.Now().UTC(),
-			TypeID:       1,
+			TypeID:       nModel.NotificationPlatformTelegram,
 			CircleID:     int(*mt.CircleGroupID),
 			TargetID:     fmt.Sprint(*mt.CircleGroupID),
 			Text:         fmt.Sprintf("📅 Reminder: *%s* is due today.", chore.Name),
@@ -167,6 +178,7 @@ func generateCircleGroupNotifications(chore *chModel.Chore, mt *chModel.Notifica
 				"id":       chore.ID,
 				"type":     EventTypeDue,
 				"name":     chore.Name,
+				"priority": chore.Priority,
 				"due_date": chore.NextDueDate.Format("January 2nd"),
 			},
 		}
@@ -181,7 +193,7 @@ func generateCircleGroupNotifications(chore *chModel.Chore, mt *chModel.Notifica
 			IsSent:       false,
 			ScheduledFor: *chore.NextDueDate,
 			CreatedAt:    time.Now().UTC().Add(-time.Hour * 3),
-			TypeID:       3,
+			TypeID:       nModel.NotificationPlatformTelegram,
 			CircleID:     int(*mt.CircleGroupID),
 			TargetID:     fmt.Sprint(*mt.CircleGroupID),
 			Text:         fmt.Sprintf("📢 Heads up! *%s* is due soon (on %s).", chore.Name, chore.NextDueDate.Format("January 2nd")),
@@ -189,6 +201,7 @@ func generateCircleGroupNotifications(chore *chModel.Chore, mt *chModel.Notifica
 				"id":       chore.ID,
 				"type":     EventTypePreDue,
 				"name":     chore.Name,
+				"priority": chore.Priority,
 				"due_date": chore.NextDueDate.Format("January 2nd"),
 			},
 		}
@@ -205,7 +218,7 @@ func generateCircleGroupNotifications(chore *chModel.Chore, mt *chModel.Notifica
 				IsSent:       false,
 				ScheduledFor: scheduleTime,
 				CreatedAt:    time.Now().UTC(),
-				TypeID:       2,
+				TypeID:       nModel.NotificationPlatformTelegram,
 				CircleID:     int(*mt.CircleGroupID),
 				TargetID:     fmt.Sprint(*mt.CircleGroupID),
 				Text:         fmt.Sprintf("🚨 *%s* is now %d hours overdue. Please complete it as soon as possible.", chore.Name, hours),
@@ -213,6 +226,7 @@ func generateCircleGroupNotifications(chore *chModel.Chore, mt *chModel.Notifica
 					"id":       chore.ID,
 					"type":     EventTypeOverdue,
 					"name":     chore.Name,
+					"priority": chore.Priority,
 					"due_date": chore.NextDueDate.Format("January 2nd"),
 				},
 			}
@@ -225,6 +239,45 @@ func generateCircleGroupNotifications(chore *chModel.Chore, mt *chModel.Notifica
 	return notifications
 }
 
+// generateMatrixGroupNotifications plans the circle group reminders for the Matrix room of the chore.
+func generateMatrixGroupNotifications(chore *chModel.Chore, mt *chModel.NotificationMetadata) []*nModel.Notification {
+	var notifications []*nModel.Notification
+	newNotification := func(eventType EventType, scheduledFor time.Time, createdAt time.Time, text string) *nModel.Notification {
+		return &nModel.Notification{
+			ChoreID:      chore.ID,
+			IsSent:       false,
+			ScheduledFor: scheduledFor,
+			CreatedAt:    createdAt,
+			TypeID:       nModel.NotificationPlatformMatrix,
+			CircleID:     chore.CircleID,
+			TargetID:     mt.CircleGroupRoomID,
+			Text:         text,
+			RawEvent: map[string]interface{}{
+				"id":       chore.ID,
+				"type":     eventType,
+				"name":     chore.Name,
+				"priority": chore.Priority,
+				"due_date": chore.NextDueDate.Format("January 2nd"),
+			},
+		}
+	}
+	if mt.DueDate {
+		notifications = append(notifications, newNotification(EventTypeDue, *chore.NextDueDate, time.Now().UTC(),
+			fmt.Sprintf("📅 Reminder: *%s* is due today.", chore.Name)))
+	}
+	if mt.PreDue {
+		notifications = append(notifications, newNotification(EventTypePreDue, *chore.NextDueDate, time.Now().UTC().Add(-time.Hour*3),
+			fmt.Sprintf("📢 Heads up! *%s* is due soon (on %s).", chore.Name, chore.NextDueDate.Format("January 2nd"))))
+	}
+	if mt.Nagging {
+		for _, hours := range []int{24, 48, 72} {
+			notifications = append(notifications, newNotification(EventTypeOverdue, chore.NextDueDate.Add(time.Hour*time.Duration(hours)), time.Now().UTC(),
+				fmt.Sprintf("🚨 *%s* is now %d hours overdue. Please complete it as soon as possible.", chore.Name, hours)))
+		}
+	}
+	return notifications
+}
+
 type EventType string
 
 const (
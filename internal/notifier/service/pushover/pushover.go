@@ -3,6 +3,7 @@ package pushover
 import (
 	"context"
 	"errors"
+	"regexp"
 
 	"donetick.com/core/config"
 	nModel "donetick.com/core/internal/notifier/model"
@@ -10,7 +11,11 @@ import (
 	"github.com/gregdel/pushover"
 )
 
+// user and group keys are 30 character alphanumeric strings
+var targetPattern = regexp.MustCompile(`^[A-Za-z0-9]{30}$`)
+
 type Pushover struct {
+	token    string
 	pushover *pushover.Pushover
 }
 
@@ -19,10 +24,29 @@ func NewPushover(cfg *config.Config) *Pushover {
 	pushoverApp := pushover.New(cfg.Pushover.Token)
 
 	return &Pushover{
+		token:    cfg.Pushover.Token,
 		pushover: pushoverApp,
 	}
 }
 
+func (p *Pushover) Platform() nModel.NotificationPlatform {
+	return nModel.NotificationPlatformPushover
+}
+
+func (p *Pushover) CheckConfig() error {
+	if p.token == "" {
+		return errors.New("pushover.token is not set")
+	}
+	return nil
+}
+
+func (p *Pushover) ValidateTarget(targetID string) error {
+	if !targetPattern.MatchString(targetID) {
+		return errors.New("pushover user key must be 30 letters and digits")
+	}
+	return nil
+}
+
 func (p *Pushover) SendNotification(c context.Context, notification *nModel.NotificationDetails) error {
 	if notification.TargetID == "" {
 		return errors.New("unable to send notification, targetID is empty")
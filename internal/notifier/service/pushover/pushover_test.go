@@ -0,0 +1,54 @@
+package pushover
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"donetick.com/core/config"
+	nModel "donetick.com/core/internal/notifier/model"
+	"github.com/gregdel/pushover"
+)
+
+func TestSendNotification(t *testing.T) {
+	const userKey = "uQiRzpo4DXghDmr9QzzfQu27cmVRsG"
+	var user, message string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseMultipartForm(1 << 20)
+		user, message = r.FormValue("user"), r.FormValue("message")
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Limit-App-Limit", "10000")
+		w.Header().Set("X-Limit-App-Remaining", "9999")
+		w.Header().Set("X-Limit-App-Reset", "1393653600")
+		w.Write([]byte(`{"status":1,"request":"e460545a8b333d0da2f3602aff3133d6"}`))
+	}))
+	defer server.Close()
+
+	// the pushover client only talks to the package level endpoint
+	endpoint := pushover.APIEndpoint
+	pushover.APIEndpoint = server.URL
+	defer func() { pushover.APIEndpoint = endpoint }()
+
+	p := NewPushover(&config.Config{Pushover: config.PushoverConfig{Token: "azGDORePK8gMaC0QOYAMyEEuzJnyUi"}})
+	if err := p.CheckConfig(); err != nil {
+		t.Fatalf("CheckConfig() error = %v", err)
+	}
+	if err := p.ValidateTarget(userKey); err != nil {
+		t.Fatalf("ValidateTarget() error = %v", err)
+	}
+
+	err := p.SendNotification(context.Background(), &nModel.NotificationDetails{
+		Notification: nModel.Notification{TargetID: userKey, Text: "Dishes are due"},
+	})
+	if err != nil {
+		t.Fatalf("SendNotification() error = %v", err)
+	}
+	if user != userKey || message != "Dishes are due" {
+		t.Errorf("sent user %q message %q", user, message)
+	}
+
+	if err := NewPushover(&config.Config{}).CheckConfig(); err == nil {
+		t.Errorf("CheckConfig() should fail without a token")
+	}
+}
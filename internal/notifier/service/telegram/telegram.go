@@ -19,10 +19,13 @@ type TelegramNotifier struct {
 }
 
 func NewTelegramNotifier(config *config.Config) *TelegramNotifier {
+	if config.Telegram.Token == "" {
+		return &TelegramNotifier{}
+	}
 	bot, err := tgbotapi.NewBotAPI(config.Telegram.Token)
 	if err != nil {
 		fmt.Println("Error creating bot: ", err)
-		return nil
+		return &TelegramNotifier{}
 	}
 
 	return &TelegramNotifier{
@@ -30,10 +33,28 @@ func NewTelegramNotifier(config *config.Config) *TelegramNotifier {
 	}
 }
 
+func (tn *TelegramNotifier) Platform() nModel.NotificationPlatform {
+	return nModel.NotificationPlatformTelegram
+}
+
+func (tn *TelegramNotifier) CheckConfig() error {
+	if tn.bot == nil {
+		return errors.New("telegram.token is not set or the bot could not be created")
+	}
+	return nil
+}
+
+func (tn *TelegramNotifier) ValidateTarget(targetID string) error {
+	if _, err := strconv.ParseInt(targetID, 10, 64); err != nil {
+		return errors.New("telegram target must be a numeric chat ID")
+	}
+	return nil
+}
+
 func (tn *TelegramNotifier) SendChoreCompletion(c context.Context, chore *chModel.Chore, user *uModel.User) {
 
 	log := logging.FromContext(c)
-	if tn == nil {
+	if tn == nil || tn.bot == nil {
 		log.Error("Telegram bot is not initialized, Skipping sending message")
 		return
 	}
@@ -0,0 +1,56 @@
+package telegram
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	nModel "donetick.com/core/internal/notifier/model"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestSendNotification(t *testing.T) {
+	var chatID, text string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/getMe"):
+			w.Write([]byte(`{"ok":true,"result":{"id":1,"is_bot":true,"username":"donetick_bot"}}`))
+		case strings.HasSuffix(r.URL.Path, "/sendMessage"):
+			r.ParseForm()
+			chatID, text = r.FormValue("chat_id"), r.FormValue("text")
+			w.Write([]byte(`{"ok":true,"result":{"message_id":1,"date":0,"chat":{"id":42}}}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	bot, err := tgbotapi.NewBotAPIWithClient("token", server.URL+"/bot%s/%s", server.Client())
+	if err != nil {
+		t.Fatalf("NewBotAPIWithClient() error = %v", err)
+	}
+	tn := &TelegramNotifier{bot: bot}
+	if err := tn.CheckConfig(); err != nil {
+		t.Fatalf("CheckConfig() error = %v", err)
+	}
+
+	err = tn.SendNotification(context.Background(), &nModel.NotificationDetails{
+		Notification: nModel.Notification{TargetID: "42", Text: "Dishes are due"},
+	})
+	if err != nil {
+		t.Fatalf("SendNotification() error = %v", err)
+	}
+	if chatID != "42" || text != "Dishes are due" {
+		t.Errorf("sent chat_id %q text %q", chatID, text)
+	}
+
+	if err := tn.ValidateTarget("not-a-chat"); err == nil {
+		t.Errorf("ValidateTarget() should reject a non numeric chat ID")
+	}
+	if err := (&TelegramNotifier{}).CheckConfig(); err == nil {
+		t.Errorf("CheckConfig() should fail without a bot")
+	}
+}
@@ -0,0 +1,200 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"donetick.com/core/config"
+	nModel "donetick.com/core/internal/notifier/model"
+	"donetick.com/core/internal/utils"
+	"donetick.com/core/logging"
+)
+
+// Target is the webhook a user sends their notifications to, stored as JSON in the notification
+// target ID. Body and header values are Go templates executed with the fields of the notification
+// raw event plus `text`, the message other platforms send.
+type Target struct {
+	URL     string            `json:"url"`
+	Method  string            `json:"method,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+var templateFuncs = template.FuncMap{
+	// json renders a value as JSON so it can be embedded in a JSON body without breaking it
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// sample data used to check templates render before a target is saved
+var validationEvent = nModel.JSONB{
+	"id":                1,
+	"type":              "due",
+	"name":              "Take out the trash",
+	"priority":          1,
+	"due_date":          time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC),
+	"assignee":          "Alex",
+	"assignee_username": "alex",
+}
+
+type Webhook struct {
+	client *http.Client
+	egress *utils.EgressPolicy
+}
+
+func NewWebhook(cfg *config.Config) *Webhook {
+	egress := utils.NewEgressPolicy(cfg)
+	return &Webhook{
+		client: egress.NewHTTPClient(cfg.WebhookConfig.Timeout),
+		egress: egress,
+	}
+}
+
+func (w *Webhook) Platform() nModel.NotificationPlatform {
+	return nModel.NotificationPlatformWebhook
+}
+
+// CheckConfig always passes, everything the webhook needs is in the target.
+func (w *Webhook) CheckConfig() error {
+	return nil
+}
+
+// ValidateTarget parses the target and renders its templates against sample data. An empty
+// target is allowed, the raw event then only goes to the circle webhook. The URL can't be a
+// loopback or private address outside the allowed networks, the client also refuses to connect to one.
+func (w *Webhook) ValidateTarget(targetID string) error {
+	if targetID == "" {
+		return nil
+	}
+	target, err := parseTarget(targetID)
+	if err != nil {
+		return err
+	}
+	if err := w.egress.CheckHost(target.host()); err != nil {
+		return errors.New("webhook url must not be a loopback or private address")
+	}
+	_, err = target.request(context.Background(), templateData(&nModel.NotificationDetails{
+		Notification: nModel.Notification{Text: "📅 Reminder: *Take out the trash* is due today and assigned to Alex.", RawEvent: validationEvent},
+	}))
+	return err
+}
+
+func (w *Webhook) SendNotification(c context.Context, notification *nModel.NotificationDetails) error {
+	if !strings.HasPrefix(strings.TrimSpace(notification.TargetID), "{") {
+		// webhook selected without a target, or with one saved before targets were JSON, the scheduler
+		// posts the raw event to the circle webhook
+		return nil
+	}
+	log := logging.FromContext(c)
+	target, err := parseTarget(notification.TargetID)
+	if err != nil {
+		return err
+	}
+	req, err := target.request(c, templateData(notification))
+	if err != nil {
+		return err
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		log.Debug("Error sending webhook notification", err)
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned unexpected status: %s", resp.Status)
+	}
+	return nil
+}
+
+func parseTarget(targetID string) (*Target, error) {
+	var target Target
+	if err := json.Unmarshal([]byte(targetID), &target); err != nil {
+		return nil, errors.New("webhook target must be a JSON object with url, method, headers and body")
+	}
+	u, err := url.Parse(target.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.New("webhook url must be an http or https URL")
+	}
+	switch strings.ToUpper(target.Method) {
+	case "":
+		target.Method = http.MethodPost
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodGet:
+		target.Method = strings.ToUpper(target.Method)
+	default:
+		return nil, fmt.Errorf("webhook method %s is not supported", target.Method)
+	}
+	return &target, nil
+}
+
+func (t *Target) host() string {
+	u, _ := url.Parse(t.URL)
+	return u.Hostname()
+}
+
+func templateData(notification *nModel.NotificationDetails) map[string]interface{} {
+	data := make(map[string]interface{}, len(notification.RawEvent)+1)
+	for key, value := range notification.RawEvent {
+		data[key] = value
+	}
+	data["text"] = notification.Text
+	return data
+}
+
+func (t *Target) request(c context.Context, data map[string]interface{}) (*http.Request, error) {
+	var body []byte
+	if t.Body == "" {
+		// without a template the raw event is sent as is
+		var err error
+		if body, err = json.Marshal(data); err != nil {
+			return nil, err
+		}
+	} else {
+		rendered, err := render("body", t.Body, data)
+		if err != nil {
+			return nil, err
+		}
+		body = []byte(rendered)
+	}
+
+	var reader io.Reader
+	if t.Method != http.MethodGet {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(c, t.Method, t.URL, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range t.Headers {
+		rendered, err := render("header "+name, value, data)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set(name, rendered)
+	}
+	return req, nil
+}
+
+func render(name string, text string, data map[string]interface{}) (string, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid webhook %s template: %w", name, err)
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("invalid webhook %s template: %w", name, err)
+	}
+	return out.String(), nil
+}
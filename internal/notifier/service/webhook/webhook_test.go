@@ -0,0 +1,137 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"donetick.com/core/config"
+	nModel "donetick.com/core/internal/notifier/model"
+)
+
+func TestSendNotification(t *testing.T) {
+	var body, auth, method string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body, auth, method = string(b), r.Header.Get("Authorization"), r.Method
+	}))
+	defer server.Close()
+
+	w := NewWebhook(&config.Config{})
+	w.client = server.Client()
+	target := `{"url":"` + server.URL + `","method":"put","headers":{"Authorization":"Bearer {{.assignee_username}}"},"body":"{\"title\":{{json .name}},\"body\":{{json .text}}}"}`
+	err := w.SendNotification(context.Background(), &nModel.NotificationDetails{
+		Notification: nModel.Notification{
+			TargetID: target,
+			Text:     "Dishes \"now\"",
+			RawEvent: nModel.JSONB{"name": "Dishes", "assignee_username": "alex"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SendNotification() error = %v", err)
+	}
+	if method != http.MethodPut || auth != "Bearer alex" {
+		t.Errorf("sent %s with Authorization %q", method, auth)
+	}
+	if want := `{"title":"Dishes","body":"Dishes \"now\""}`; body != want {
+		t.Errorf("body = %s, want %s", body, want)
+	}
+}
+
+func TestSendNotificationDefaultBody(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	w := NewWebhook(&config.Config{})
+	w.client = server.Client()
+	err := w.SendNotification(context.Background(), &nModel.NotificationDetails{
+		Notification: nModel.Notification{
+			TargetID: `{"url":"` + server.URL + `"}`,
+			Text:     "Dishes",
+			RawEvent: nModel.JSONB{"name": "Dishes"},
+		},
+	})
+	if err == nil {
+		t.Errorf("SendNotification() should fail when the endpoint returns an error")
+	}
+	if want := `{"name":"Dishes","text":"Dishes"}`; body != want {
+		t.Errorf("body = %s, want %s", body, want)
+	}
+}
+
+func TestValidateTarget(t *testing.T) {
+	w := NewWebhook(&config.Config{})
+	for target, valid := range map[string]bool{
+		"": true,
+		`{"url":"https://example.com/hook","body":"{{.name}}"}`:          true,
+		`{"url":"https://example.com/hook","method":"DELETE"}`:           false,
+		`{"url":"ftp://example.com/hook"}`:                               false,
+		`{"url":"https://example.com/hook","body":"{{.name"}`:            false,
+		`{"url":"https://example.com/hook","body":"{{call .name}}"}`:     false,
+		`{"url":"https://example.com/hook","headers":{"X":"{{bogus}}"}}`: false,
+		"https://example.com/hook":                                       false,
+		`{"url":"http://127.0.0.1:8123/api/webhook/x"}`:                  false,
+		`{"url":"http://localhost/hook"}`:                                false,
+		`{"url":"http://192.168.1.10/hook"}`:                             false,
+		`{"url":"http://[::1]/hook"}`:                                    false,
+	} {
+		if err := w.ValidateTarget(target); (err == nil) != valid {
+			t.Errorf("ValidateTarget(%q) error = %v, want valid %v", target, err, valid)
+		}
+	}
+	// the private networks the operator allows, e.g. Home Assistant on the LAN
+	w = NewWebhook(&config.Config{WebhookConfig: config.WebhookConfig{AllowedPrivateNetworks: []string{"127.0.0.0/8", "192.168.0.0/16"}}})
+	for target, valid := range map[string]bool{
+		`{"url":"http://192.168.1.10:8123/api/webhook/x"}`: true,
+		`{"url":"http://localhost/hook"}`:                  true,
+		`{"url":"http://10.0.0.2/hook"}`:                   false,
+		`{"url":"http://[::1]/hook"}`:                      false,
+	} {
+		if err := w.ValidateTarget(target); (err == nil) != valid {
+			t.Errorf("ValidateTarget(%q) with allowed networks error = %v, want valid %v", target, err, valid)
+		}
+	}
+}
+
+func TestSendNotificationWithoutTarget(t *testing.T) {
+	w := NewWebhook(&config.Config{})
+	// targets saved before they were JSON only go to the circle webhook
+	for _, target := range []string{"", "-1001234567890", "https://example.com/hook"} {
+		err := w.SendNotification(context.Background(), &nModel.NotificationDetails{
+			Notification: nModel.Notification{TargetID: target, Text: "Dishes"},
+		})
+		if err != nil {
+			t.Errorf("SendNotification() with target %q error = %v, want nil", target, err)
+		}
+	}
+}
+
+func TestSendNotificationToPrivateAddress(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	// the default client refuses to connect to the server's own network
+	err := NewWebhook(&config.Config{}).SendNotification(context.Background(), &nModel.NotificationDetails{
+		Notification: nModel.Notification{TargetID: `{"url":"` + server.URL + `"}`, Text: "Dishes"},
+	})
+	if err == nil || called {
+		t.Errorf("SendNotification() to %s error = %v, want refused", server.URL, err)
+	}
+	allowed := NewWebhook(&config.Config{WebhookConfig: config.WebhookConfig{AllowedPrivateNetworks: []string{"127.0.0.0/8"}}})
+	err = allowed.SendNotification(context.Background(), &nModel.NotificationDetails{
+		Notification: nModel.Notification{TargetID: `{"url":"` + server.URL + `"}`, Text: "Dishes"},
+	})
+	if err != nil || !called {
+		t.Errorf("SendNotification() to an allowed network error = %v, want sent", err)
+	}
+}
@@ -0,0 +1,330 @@
+package offline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	chModel "donetick.com/core/internal/chore/model"
+	oModel "donetick.com/core/internal/offline/model"
+	"gorm.io/gorm"
+)
+
+const maxBatchOperations = 100
+
+type OperationType string
+
+const (
+	OperationChoreCreate    OperationType = "chore.create"
+	OperationChoreUpdate    OperationType = "chore.update"
+	OperationChoreComplete  OperationType = "chore.complete"
+	OperationChoreSkip      OperationType = "chore.skip"
+	OperationChoreArchive   OperationType = "chore.archive"
+	OperationChoreUnarchive OperationType = "chore.unarchive"
+	OperationChoreDelete    OperationType = "chore.delete"
+	OperationChorePriority  OperationType = "chore.priority"
+	OperationSubtaskUpdate  OperationType = "subtask.update"
+	OperationLabelCreate    OperationType = "label.create"
+	OperationLabelUpdate    OperationType = "label.update"
+	OperationLabelDelete    OperationType = "label.delete"
+)
+
+type ResultStatus string
+
+const (
+	ResultApplied  ResultStatus = "applied"
+	ResultConflict ResultStatus = "conflict" // the chore changed on the server after the client last synced it
+	ResultRejected ResultStatus = "rejected" // the operation is invalid or not allowed, sending it again won't help
+	ResultFailed   ResultStatus = "failed"   // something went wrong on the server, the operation can be sent again
+)
+
+// Operation is a change made offline. It is applied with the endpoint the app would have called, the payload
+// is the body of that request. ChoreID of a chore.create is the temporary negative id the client gave the
+// chore, later operations of the batch can refer to the chore with it.
+type Operation struct {
+	ID            string          `json:"id"` // unique per user, an operation sent again is not applied twice
+	Type          OperationType   `json:"type"`
+	ChoreID       int             `json:"choreId,omitempty"`
+	LabelID       int             `json:"labelId,omitempty"`
+	BaseUpdatedAt *time.Time      `json:"baseUpdatedAt,omitempty"` // updatedAt of the chore the change was made on
+	PerformedAt   *time.Time      `json:"performedAt,omitempty"`   // when a chore was completed
+	Payload       json.RawMessage `json:"payload,omitempty"`
+}
+
+type BatchReq struct {
+	Operations []Operation `json:"operations" binding:"required"`
+}
+
+// OperationResult is what happened to an operation. Result is what the endpoint answered, Current is the
+// chore as it is on the server when there was a conflict. Conflicts are the fields of a chore.update that
+// could not be merged with the changes made on the server.
+type OperationResult struct {
+	ID        string                  `json:"id"`
+	Status    ResultStatus            `json:"status"`
+	ChoreID   int                     `json:"choreId,omitempty"` // of the chore a chore.create created
+	Result    json.RawMessage         `json:"result,omitempty"`
+	Error     string                  `json:"error,omitempty"`
+	Conflicts []chModel.FieldConflict `json:"conflicts,omitempty"`
+	Current   *chModel.Chore          `json:"current,omitempty"`
+}
+
+type operationTarget int
+
+const (
+	targetNone operationTarget = iota
+	targetChore
+	targetLabel
+)
+
+type operationRoute struct {
+	method string
+	path   string // formatted with the id of the target
+	target operationTarget
+}
+
+var operationRoutes = map[OperationType]operationRoute{
+	OperationChoreCreate:    {http.MethodPost, "/api/v1/chores/", targetNone},
+	OperationChoreUpdate:    {http.MethodPut, "/api/v1/chores/", targetChore},
+	OperationChoreComplete:  {http.MethodPost, "/api/v1/chores/%d/do", targetChore},
+	OperationChoreSkip:      {http.MethodPost, "/api/v1/chores/%d/skip", targetChore},
+	OperationChoreArchive:   {http.MethodPut, "/api/v1/chores/%d/archive", targetChore},
+	OperationChoreUnarchive: {http.MethodPut, "/api/v1/chores/%d/unarchive", targetChore},
+	OperationChoreDelete:    {http.MethodDelete, "/api/v1/chores/%d", targetChore},
+	OperationChorePriority:  {http.MethodPut, "/api/v1/chores/%d/priority", targetChore},
+	OperationSubtaskUpdate:  {http.MethodPut, "/api/v1/chores/%d/subtask", targetChore},
+	OperationLabelCreate:    {http.MethodPost, "/api/v1/labels", targetNone},
+	OperationLabelUpdate:    {http.MethodPut, "/api/v1/labels", targetNone},
+	OperationLabelDelete:    {http.MethodDelete, "/api/v1/labels/%d", targetLabel},
+}
+
+// applyBatch applies the operations one after the other through the router, with the authorization of the
+// batch request, so they are checked and have the same effects as when the client is online.
+func (h *Handler) applyBatch(c context.Context, userID int, circleID int, authorization string, operations []Operation) ([]OperationResult, error) {
+	results := make([]OperationResult, 0, len(operations))
+	createdChores := make(map[int]int) // temporary id -> id
+	for _, op := range operations {
+		if op.ID == "" {
+			results = append(results, OperationResult{Status: ResultRejected, Error: "Operation id is required"})
+			continue
+		}
+		applied, err := h.offlineRepo.GetAppliedOperation(c, userID, op.ID)
+		if err != nil {
+			return nil, err
+		}
+		if applied != nil {
+			var result OperationResult
+			if err := json.Unmarshal([]byte(applied.Result), &result); err != nil {
+				return nil, err
+			}
+			if op.Type == OperationChoreCreate && op.ChoreID < 0 && result.ChoreID > 0 {
+				createdChores[op.ChoreID] = result.ChoreID
+			}
+			results = append(results, result)
+			continue
+		}
+
+		result := h.applyOperation(c, userID, circleID, authorization, op, createdChores)
+		result.ID = op.ID
+		if result.Status == ResultFailed {
+			results = append(results, result)
+			continue
+		}
+		if op.Type == OperationChoreCreate && op.ChoreID < 0 && result.ChoreID > 0 {
+			createdChores[op.ChoreID] = result.ChoreID
+		}
+		stored, err := json.Marshal(result)
+		if err != nil {
+			return nil, err
+		}
+		if err := h.offlineRepo.SaveAppliedOperation(c, &oModel.AppliedOperation{
+			UserID:      userID,
+			OperationID: op.ID,
+			Result:      string(stored),
+			CreatedAt:   time.Now().UTC(),
+		}); err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (h *Handler) applyOperation(c context.Context, userID int, circleID int, authorization string, op Operation, createdChores map[int]int) OperationResult {
+	route, ok := operationRoutes[op.Type]
+	if !ok {
+		return OperationResult{Status: ResultRejected, Error: fmt.Sprintf("Unknown operation type %q", op.Type)}
+	}
+
+	choreID := op.ChoreID
+	path := route.path
+	switch route.target {
+	case targetChore:
+		if choreID < 0 {
+			if choreID, ok = createdChores[op.ChoreID]; !ok {
+				return OperationResult{Status: ResultRejected, Error: "Unknown temporary chore id"}
+			}
+		}
+		if choreID == 0 {
+			return OperationResult{Status: ResultRejected, Error: "Chore id is required"}
+		}
+		if result, done := h.checkChore(c, userID, circleID, op, choreID); done {
+			return result
+		}
+		if strings.Contains(route.path, "%d") {
+			path = fmt.Sprintf(route.path, choreID)
+		}
+	case targetLabel:
+		if op.LabelID <= 0 {
+			return OperationResult{Status: ResultRejected, Error: "Label id is required"}
+		}
+		path = fmt.Sprintf(route.path, op.LabelID)
+	}
+
+	body := []byte(op.Payload)
+	if op.Type == OperationChoreUpdate {
+		var err error
+		if body, err = updatePayload(op.Payload, choreID, op.BaseUpdatedAt); err != nil {
+			return OperationResult{Status: ResultRejected, Error: "Invalid payload"}
+		}
+	}
+	query := url.Values{}
+	if op.Type == OperationChoreComplete && op.PerformedAt != nil {
+		query.Set("completedDate", op.PerformedAt.UTC().Format(time.RFC3339))
+	}
+
+	result := h.dispatch(c, authorization, route.method, path, query, body)
+	if op.Type == OperationChoreCreate && result.Status == ResultApplied {
+		var id int
+		if err := json.Unmarshal(result.Result, &id); err == nil {
+			result.ChoreID = id
+		}
+	}
+	if op.Type == OperationChoreUpdate && result.Status == ResultConflict {
+		if chore, err := h.choreRepo.GetChore(c, choreID); err == nil && canView(chore, userID) {
+			result.Current = chore
+		}
+	}
+	return result
+}
+
+// checkChore finds out if the operation can't be applied to the chore because it was deleted or changed after
+// the client synced it. An update is not checked for changes, the edit endpoint merges it with them.
+func (h *Handler) checkChore(c context.Context, userID int, circleID int, op Operation, choreID int) (OperationResult, bool) {
+	chore, err := h.choreRepo.GetChore(c, choreID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && chore.CircleID != circleID) {
+		if op.Type == OperationChoreDelete {
+			return OperationResult{Status: ResultApplied}, true
+		}
+		return OperationResult{Status: ResultRejected, Error: "Chore not found"}, true
+	}
+	if err != nil {
+		return OperationResult{Status: ResultFailed, Error: "Error getting chore"}, true
+	}
+	if op.Type != OperationChoreUpdate && op.BaseUpdatedAt != nil && chore.UpdatedAt.After(*op.BaseUpdatedAt) {
+		result := OperationResult{Status: ResultConflict, Error: "Chore was changed after it was synced"}
+		if canView(chore, userID) {
+			result.Current = chore
+		}
+		return result, true
+	}
+	return OperationResult{}, false
+}
+
+func canView(chore *chModel.Chore, userID int) bool {
+	if chore.CreatedBy == userID {
+		return true
+	}
+	for _, assignee := range chore.Assignees {
+		if assignee.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// updatePayload sets the id of the chore, which may have been created in the same batch, and the updatedAt
+// the edit is checked against.
+func updatePayload(payload json.RawMessage, choreID int, baseUpdatedAt *time.Time) ([]byte, error) {
+	fields := map[string]interface{}{}
+	if len(payload) > 0 {
+		if err := json.Unmarshal(payload, &fields); err != nil {
+			return nil, err
+		}
+	}
+	fields["id"] = choreID
+	if baseUpdatedAt != nil {
+		fields["updatedAt"] = baseUpdatedAt
+	}
+	return json.Marshal(fields)
+}
+
+// dispatch serves the request of an operation and turns the response into its result.
+func (h *Handler) dispatch(c context.Context, authorization string, method string, path string, query url.Values, body []byte) OperationResult {
+	target := path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(c, method, target, bytes.NewReader(body))
+	if err != nil {
+		return OperationResult{Status: ResultFailed, Error: err.Error()}
+	}
+	req.Header.Set("Authorization", authorization)
+	if len(body) > 0 {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rec := newOperationResponse()
+	h.router.ServeHTTP(rec, req)
+
+	var response struct {
+		Res       json.RawMessage         `json:"res"`
+		Error     string                  `json:"error"`
+		Conflicts []chModel.FieldConflict `json:"conflicts"`
+	}
+	_ = json.Unmarshal(rec.body.Bytes(), &response)
+
+	result := OperationResult{Result: response.Res, Error: response.Error, Conflicts: response.Conflicts}
+	switch {
+	case rec.code >= 200 && rec.code < 300:
+		result.Status = ResultApplied
+	case rec.code == http.StatusConflict:
+		result.Status = ResultConflict
+	case rec.code >= 400 && rec.code < 500:
+		result.Status = ResultRejected
+	default:
+		result.Status = ResultFailed
+	}
+	if result.Status != ResultApplied && result.Error == "" {
+		result.Error = http.StatusText(rec.code)
+	}
+	return result
+}
+
+// operationResponse is the http.ResponseWriter an operation is served with, it keeps the status and body
+// the endpoint answered.
+type operationResponse struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func newOperationResponse() *operationResponse {
+	return &operationResponse{header: http.Header{}, code: http.StatusOK}
+}
+
+func (w *operationResponse) Header() http.Header {
+	return w.header
+}
+
+func (w *operationResponse) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func (w *operationResponse) WriteHeader(code int) {
+	w.code = code
+}
@@ -0,0 +1,161 @@
+package offline
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"donetick.com/core/config"
+	chModel "donetick.com/core/internal/chore/model"
+	chRepo "donetick.com/core/internal/chore/repo"
+	"donetick.com/core/internal/database/dbtest"
+	oRepo "donetick.com/core/internal/offline/repo"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// newTestHandler applies operations to a router that stands in for the chore endpoints, it records the
+// requests it gets.
+func newTestHandler(t *testing.T) (*Handler, *gorm.DB, *[]*http.Request) {
+	t.Helper()
+	db := dbtest.Open(t)
+
+	var requests []*http.Request
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		requests = append(requests, c.Request)
+	})
+	r.POST("/api/v1/chores/", func(c *gin.Context) {
+		chore := &chModel.Chore{Name: "created offline", CircleID: 1, CreatedBy: 1, IsActive: true}
+		db.Create(chore)
+		c.JSON(200, gin.H{"res": chore.ID})
+	})
+	r.PUT("/api/v1/chores/", func(c *gin.Context) {
+		c.JSON(409, gin.H{
+			"error":     "Chore was changed by someone else, resolve the conflicts and send it again",
+			"conflicts": []chModel.FieldConflict{{Field: "name", Base: "changed", Yours: "renamed", Theirs: "changed again"}},
+		})
+	})
+	r.POST("/api/v1/chores/:id/do", func(c *gin.Context) {
+		c.JSON(200, gin.H{"res": gin.H{"id": c.Param("id")}})
+	})
+	r.POST("/api/v1/chores/:id/skip", func(c *gin.Context) {
+		c.JSON(500, gin.H{"error": "Error skipping chore"})
+	})
+	r.PUT("/api/v1/chores/:id/archive", func(c *gin.Context) {
+		c.JSON(403, gin.H{"error": "You are not allowed to archive this chore"})
+	})
+
+	h := &Handler{
+		router:      r,
+		choreRepo:   chRepo.NewChoreRepository(db, &config.Config{}),
+		offlineRepo: oRepo.NewOfflineRepository(db),
+	}
+	return h, db, &requests
+}
+
+func TestApplyBatch(t *testing.T) {
+	h, db, requests := newTestHandler(t)
+	ctx := context.Background()
+
+	synced := time.Now().UTC().Add(-time.Hour)
+	changed := &chModel.Chore{Name: "changed", CircleID: 1, CreatedBy: 1, IsActive: true}
+	other := &chModel.Chore{Name: "other circle", CircleID: 2, CreatedBy: 2, IsActive: true}
+	db.Create(changed)
+	db.Create(other)
+
+	completedAt := time.Date(2026, 10, 17, 18, 30, 0, 0, time.UTC)
+	operations := []Operation{
+		{ID: "create", Type: OperationChoreCreate, ChoreID: -1, Payload: json.RawMessage(`{"name":"created offline"}`)},
+		{ID: "complete", Type: OperationChoreComplete, ChoreID: -1, PerformedAt: &completedAt},
+		{ID: "update", Type: OperationChoreUpdate, ChoreID: changed.ID, BaseUpdatedAt: &synced, Payload: json.RawMessage(`{"name":"renamed"}`)},
+		{ID: "delete", Type: OperationChoreDelete, ChoreID: 1000},
+		{ID: "other", Type: OperationChoreComplete, ChoreID: other.ID},
+		{ID: "archive", Type: OperationChoreArchive, ChoreID: changed.ID},
+		{ID: "skip", Type: OperationChoreSkip, ChoreID: changed.ID},
+		{ID: "unknown", Type: "chore.rename", ChoreID: changed.ID},
+		{ID: "missing", Type: OperationChoreComplete, ChoreID: -2},
+	}
+	results, err := h.applyBatch(ctx, 1, 1, "Bearer token", operations)
+	if err != nil {
+		t.Fatalf("applyBatch() error = %v", err)
+	}
+
+	want := map[string]ResultStatus{
+		"create":   ResultApplied,
+		"complete": ResultApplied,
+		"update":   ResultConflict,
+		"delete":   ResultApplied,
+		"other":    ResultRejected,
+		"archive":  ResultRejected,
+		"skip":     ResultFailed,
+		"unknown":  ResultRejected,
+		"missing":  ResultRejected,
+	}
+	if len(results) != len(operations) {
+		t.Fatalf("got %d results, want %d", len(results), len(operations))
+	}
+	for _, result := range results {
+		if result.Status != want[result.ID] {
+			t.Errorf("%s: status %s (%s), want %s", result.ID, result.Status, result.Error, want[result.ID])
+		}
+	}
+	created := results[0].ChoreID
+	if created == 0 {
+		t.Fatal("create did not return the id of the chore")
+	}
+	if results[2].Current == nil || results[2].Current.ID != changed.ID {
+		t.Errorf("conflict current = %+v, want the chore on the server", results[2].Current)
+	}
+
+	if len(results[2].Conflicts) != 1 || results[2].Conflicts[0].Field != "name" {
+		t.Errorf("conflicts = %+v, want the ones of the edit endpoint", results[2].Conflicts)
+	}
+
+	// create, complete, update, archive and skip reach the router
+	if len(*requests) != 5 {
+		t.Fatalf("router got %d requests, want 5", len(*requests))
+	}
+	complete := (*requests)[1]
+	if complete.URL.Path != "/api/v1/chores/"+strconv.Itoa(created)+"/do" {
+		t.Errorf("complete went to %s, want the chore created in the batch", complete.URL.Path)
+	}
+	if got := complete.URL.Query().Get("completedDate"); got != completedAt.Format(time.RFC3339) {
+		t.Errorf("completedDate = %q, want when it was completed offline", got)
+	}
+	if got := complete.Header.Get("Authorization"); got != "Bearer token" {
+		t.Errorf("Authorization = %q, want the one of the batch", got)
+	}
+
+	// sending the batch again only retries what failed
+	*requests = nil
+	again, err := h.applyBatch(ctx, 1, 1, "Bearer token", operations)
+	if err != nil {
+		t.Fatalf("applyBatch() again error = %v", err)
+	}
+	if len(*requests) != 1 || (*requests)[0].URL.Path != "/api/v1/chores/"+strconv.Itoa(changed.ID)+"/skip" {
+		t.Errorf("batch sent again made %d requests, want only the failed skip", len(*requests))
+	}
+	if again[0].ChoreID != created || again[1].Status != ResultApplied {
+		t.Errorf("batch sent again = %+v, want the first results", again[:2])
+	}
+}
+
+func TestUpdatePayload(t *testing.T) {
+	synced := time.Date(2026, 10, 17, 8, 0, 0, 0, time.UTC)
+	body, err := updatePayload(json.RawMessage(`{"id":-1,"name":"renamed"}`), 7, &synced)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var req chModel.ChoreReq
+	if err := json.Unmarshal(body, &req); err != nil {
+		t.Fatal(err)
+	}
+	if req.ID != 7 || req.Name != "renamed" || req.UpdatedAt == nil || !req.UpdatedAt.Equal(synced) {
+		t.Errorf("updatePayload() = %s, want the id of the chore and the time it was synced", body)
+	}
+}
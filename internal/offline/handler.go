@@ -0,0 +1,185 @@
+package offline
+
+import (
+	"net/http"
+	"time"
+
+	"donetick.com/core/config"
+	auth "donetick.com/core/internal/authorization"
+	chModel "donetick.com/core/internal/chore/model"
+	chRepo "donetick.com/core/internal/chore/repo"
+	lModel "donetick.com/core/internal/label/model"
+	lRepo "donetick.com/core/internal/label/repo"
+	oModel "donetick.com/core/internal/offline/model"
+	oRepo "donetick.com/core/internal/offline/repo"
+	stModel "donetick.com/core/internal/subtask/model"
+	stRepo "donetick.com/core/internal/subtask/repo"
+	"donetick.com/core/logging"
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// cursorOverlap moves the cursor back so changes committed while a sync was reading are sent by the next
+	// one, clients get some changes twice and apply them as upserts.
+	cursorOverlap = 5 * time.Second
+
+	defaultTombstoneRetention = 30 * 24 * time.Hour
+)
+
+type Handler struct {
+	router      http.Handler // serves the operations of a batch like the requests of the app
+	choreRepo   *chRepo.ChoreRepository
+	labelRepo   *lRepo.LabelRepository
+	stRepo      *stRepo.SubTasksRepository
+	offlineRepo *oRepo.OfflineRepository
+	retention   time.Duration
+}
+
+func NewHandler(cfg *config.Config, router *gin.Engine, cr *chRepo.ChoreRepository, lr *lRepo.LabelRepository,
+	str *stRepo.SubTasksRepository, or *oRepo.OfflineRepository) *Handler {
+	retention := cfg.Sync.TombstoneRetention
+	if retention <= 0 {
+		retention = defaultTombstoneRetention
+	}
+	return &Handler{
+		router:      router,
+		choreRepo:   cr,
+		labelRepo:   lr,
+		stRepo:      str,
+		offlineRepo: or,
+		retention:   retention,
+	}
+}
+
+// SubtaskChange is a subtask with the chore it belongs to, which subtasks don't have in their JSON.
+type SubtaskChange struct {
+	stModel.SubTask
+	ChoreID int `json:"choreId"`
+}
+
+// SyncResponse has what changed since the cursor a client sent. When Full is true it has everything instead
+// and the client replaces what it has. ChoreIDs are all the chores the user can see, chores the client has
+// that are not in it were deleted or are no longer shared with the user.
+type SyncResponse struct {
+	Cursor     time.Time               `json:"cursor"`
+	Full       bool                    `json:"full"`
+	ChoreIDs   []int                   `json:"choreIds"`
+	Chores     []*chModel.Chore        `json:"chores"`
+	History    []*chModel.ChoreHistory `json:"history"`
+	Labels     []*lModel.Label         `json:"labels"`
+	Subtasks   []SubtaskChange         `json:"subtasks"`
+	Tombstones []*oModel.Tombstone     `json:"tombstones"`
+}
+
+// GetChanges returns the chores, history, labels and subtasks that changed after the since cursor and the
+// tombstones of what was deleted. The cursor of the response is the since of the next sync.
+func (h *Handler) GetChanges(c *gin.Context) {
+	log := logging.FromContext(c)
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{"error": "Error getting current user"})
+		return
+	}
+
+	now := time.Now().UTC()
+	var since *time.Time
+	if raw := c.Query("since"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid since, it has to be an RFC 3339 time"})
+			return
+		}
+		// tombstones older than the retention are gone, the client can't know what was deleted
+		if parsed.After(now.Add(-h.retention)) {
+			parsed = parsed.UTC()
+			since = &parsed
+		}
+	}
+
+	res := SyncResponse{
+		Cursor:     now.Add(-cursorOverlap),
+		Full:       since == nil,
+		Subtasks:   []SubtaskChange{},
+		Tombstones: []*oModel.Tombstone{},
+	}
+	var err error
+	if res.ChoreIDs, err = h.choreRepo.GetVisibleChoreIDs(c, currentUser.CircleID, currentUser.ID); err != nil {
+		log.Errorw("Failed to get chores for sync", "error", err)
+		c.JSON(500, gin.H{"error": "Error getting chores"})
+		return
+	}
+	if res.Chores, err = h.choreRepo.GetChoresUpdatedSince(c, res.ChoreIDs, since); err != nil {
+		log.Errorw("Failed to get chores for sync", "error", err)
+		c.JSON(500, gin.H{"error": "Error getting chores"})
+		return
+	}
+	if res.History, err = h.choreRepo.GetChoresHistoryUpdatedSince(c, res.ChoreIDs, since); err != nil {
+		log.Errorw("Failed to get chore history for sync", "error", err)
+		c.JSON(500, gin.H{"error": "Error getting chore history"})
+		return
+	}
+	if res.Labels, err = h.labelRepo.GetUserLabelsUpdatedSince(c, currentUser.ID, currentUser.CircleID, since); err != nil {
+		log.Errorw("Failed to get labels for sync", "error", err)
+		c.JSON(500, gin.H{"error": "Error getting labels"})
+		return
+	}
+	subtasks, err := h.stRepo.GetSubtasksUpdatedSince(c, res.ChoreIDs, since)
+	if err != nil {
+		log.Errorw("Failed to get subtasks for sync", "error", err)
+		c.JSON(500, gin.H{"error": "Error getting subtasks"})
+		return
+	}
+	for _, subtask := range subtasks {
+		res.Subtasks = append(res.Subtasks, SubtaskChange{SubTask: *subtask, ChoreID: subtask.ChoreID})
+	}
+	if since != nil {
+		if res.Tombstones, err = h.offlineRepo.GetTombstones(c, currentUser.CircleID, currentUser.ID, *since); err != nil {
+			log.Errorw("Failed to get tombstones for sync", "error", err)
+			c.JSON(500, gin.H{"error": "Error getting deletions"})
+			return
+		}
+	}
+
+	c.JSON(200, gin.H{
+		"res": res,
+	})
+}
+
+// ApplyBatch applies the operations a client queued while offline, in order, and answers with the result of
+// each one.
+func (h *Handler) ApplyBatch(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{"error": "Error getting current user"})
+		return
+	}
+	var req BatchReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request"})
+		return
+	}
+	if len(req.Operations) > maxBatchOperations {
+		c.JSON(400, gin.H{"error": "Too many operations in one batch"})
+		return
+	}
+
+	results, err := h.applyBatch(c, currentUser.ID, currentUser.CircleID, c.GetHeader("Authorization"), req.Operations)
+	if err != nil {
+		logging.FromContext(c).Errorw("Failed to apply offline operations", "error", err)
+		c.JSON(500, gin.H{"error": "Error applying operations"})
+		return
+	}
+	c.JSON(200, gin.H{
+		"res": results,
+	})
+}
+
+func Routes(router *gin.Engine, h *Handler, auth *jwt.GinJWTMiddleware) {
+	syncRoutes := router.Group("api/v1/sync")
+	syncRoutes.Use(auth.MiddlewareFunc())
+	{
+		syncRoutes.GET("", h.GetChanges)
+		syncRoutes.POST("/batch", h.ApplyBatch)
+	}
+}
@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+type EntityType string
+
+const (
+	EntityTypeChore        EntityType = "chore"
+	EntityTypeChoreHistory EntityType = "history"
+	EntityTypeLabel        EntityType = "label"
+	EntityTypeSubtask      EntityType = "subtask"
+)
+
+// Tombstone records the deletion of something offline clients keep a copy of, so a delta sync can tell them
+// to drop it. The subtasks and history of a deleted chore go with it and get no tombstone of their own.
+type Tombstone struct {
+	ID         int        `json:"-" gorm:"primaryKey"`
+	EntityType EntityType `json:"type" gorm:"column:entity_type"`
+	EntityID   int        `json:"id" gorm:"column:entity_id"`
+	ChoreID    *int       `json:"choreId,omitempty" gorm:"column:chore_id"` // of a deleted subtask or history entry
+	CircleID   *int       `json:"-" gorm:"column:circle_id;index"`
+	UserID     *int       `json:"-" gorm:"column:user_id;index"` // owner of a deleted label, labels are not always shared with the circle
+	DeletedAt  time.Time  `json:"deletedAt" gorm:"column:deleted_at;index"`
+}
+
+// AppliedOperation is the result of an offline operation of a batch, kept so a batch sent again after a lost
+// response does not apply it twice.
+type AppliedOperation struct {
+	ID          int       `json:"-" gorm:"primaryKey"`
+	UserID      int       `json:"-" gorm:"column:user_id;uniqueIndex:idx_applied_operation"`
+	OperationID string    `json:"-" gorm:"column:operation_id;uniqueIndex:idx_applied_operation"`
+	Result      string    `json:"-" gorm:"column:result;type:text"` // JSON of the result sent back
+	CreatedAt   time.Time `json:"-" gorm:"column:created_at;index"`
+}
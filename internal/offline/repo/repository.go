@@ -0,0 +1,56 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	oModel "donetick.com/core/internal/offline/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OfflineRepository struct {
+	db *gorm.DB
+}
+
+func NewOfflineRepository(db *gorm.DB) *OfflineRepository {
+	return &OfflineRepository{db}
+}
+
+// GetTombstones returns the deletions after since in the circle, and of the labels of the user.
+func (r *OfflineRepository) GetTombstones(c context.Context, circleID int, userID int, since time.Time) ([]*oModel.Tombstone, error) {
+	var tombstones []*oModel.Tombstone
+	if err := r.db.WithContext(c).
+		Where("deleted_at > ? AND (circle_id = ? OR user_id = ?)", since, circleID, userID).
+		Order("deleted_at asc").
+		Find(&tombstones).Error; err != nil {
+		return nil, err
+	}
+	return tombstones, nil
+}
+
+func (r *OfflineRepository) DeleteTombstonesBefore(c context.Context, before time.Time) error {
+	return r.db.WithContext(c).Where("deleted_at < ?", before).Delete(&oModel.Tombstone{}).Error
+}
+
+// GetAppliedOperation returns the operation of the user with the id, nil when it was never applied.
+func (r *OfflineRepository) GetAppliedOperation(c context.Context, userID int, operationID string) (*oModel.AppliedOperation, error) {
+	var operation oModel.AppliedOperation
+	err := r.db.WithContext(c).Where("user_id = ? AND operation_id = ?", userID, operationID).First(&operation).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &operation, nil
+}
+
+func (r *OfflineRepository) SaveAppliedOperation(c context.Context, operation *oModel.AppliedOperation) error {
+	return r.db.WithContext(c).Clauses(clause.OnConflict{DoNothing: true}).Create(operation).Error
+}
+
+func (r *OfflineRepository) DeleteAppliedOperationsBefore(c context.Context, before time.Time) error {
+	return r.db.WithContext(c).Where("created_at < ?", before).Delete(&oModel.AppliedOperation{}).Error
+}
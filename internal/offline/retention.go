@@ -0,0 +1,62 @@
+package offline
+
+import (
+	"context"
+	"time"
+
+	"donetick.com/core/config"
+	oRepo "donetick.com/core/internal/offline/repo"
+	"donetick.com/core/logging"
+)
+
+// Retention deletes tombstones and applied operations once they are older than the tombstone retention,
+// clients that sync after that get everything again.
+type Retention struct {
+	offlineRepo *oRepo.OfflineRepository
+	retention   time.Duration
+	ticker      *time.Ticker
+	done        chan bool
+}
+
+func NewRetention(cfg *config.Config, or *oRepo.OfflineRepository) *Retention {
+	retention := cfg.Sync.TombstoneRetention
+	if retention <= 0 {
+		retention = defaultTombstoneRetention
+	}
+	return &Retention{
+		offlineRepo: or,
+		retention:   retention,
+		ticker:      time.NewTicker(time.Hour),
+		done:        make(chan bool),
+	}
+}
+
+func (s *Retention) Start(ctx context.Context) {
+	logger := logging.FromContext(ctx)
+
+	go func() {
+		for {
+			select {
+			case <-s.done:
+				return
+			case <-s.ticker.C:
+				if err := s.Run(ctx, time.Now().UTC()); err != nil {
+					logger.Errorw("Failed to delete old tombstones", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+func (s *Retention) Stop() {
+	s.ticker.Stop()
+	s.done <- true
+}
+
+func (s *Retention) Run(ctx context.Context, now time.Time) error {
+	before := now.Add(-s.retention)
+	if err := s.offlineRepo.DeleteTombstonesBefore(ctx, before); err != nil {
+		return err
+	}
+	return s.offlineRepo.DeleteAppliedOperationsBefore(ctx, before)
+}
@@ -0,0 +1,43 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"donetick.com/core/config"
+	"github.com/gin-gonic/gin"
+)
+
+// Handler serves the document of the routes registered on the engine.
+type Handler struct {
+	info   Info
+	routes func() gin.RoutesInfo
+
+	once     sync.Once
+	document []byte
+	err      error
+}
+
+func NewHandler(cfg *config.Config) *Handler {
+	return &Handler{
+		info: Info{Title: "Donetick API", Version: cfg.Info.Version},
+	}
+}
+
+func (h *Handler) getDocument(c *gin.Context) {
+	// every route is registered by the time the first request comes in
+	h.once.Do(func() {
+		h.document, h.err = json.Marshal(Build(h.info, h.routes(), Operations))
+	})
+	if h.err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate the API specification"})
+		return
+	}
+	c.Data(http.StatusOK, "application/json", h.document)
+}
+
+func Routes(r *gin.Engine, h *Handler) {
+	h.routes = r.Routes
+	r.GET("/api/v1/openapi.json", h.getDocument)
+}
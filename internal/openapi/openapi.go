@@ -0,0 +1,418 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	uModel "donetick.com/core/internal/user/model"
+	"github.com/gin-gonic/gin"
+)
+
+// Operation describes a route. Request and response bodies are given as values of the Go types the handler
+// binds and returns, their schemas are generated from the json tags of the types.
+type Operation struct {
+	Method      string
+	Path        string // as registered with gin, path parameters are taken from it
+	Summary     string
+	Description string
+	Query       []Param
+	Body        any                  // the JSON request body, nil when there is none
+	Form        []Param              // fields of a multipart/form-data request body
+	Response    any                  // what the handler returns under "res", nil for an empty object
+	Raw         bool                 // the response is not wrapped in {"res": ...}
+	ContentType string               // of the response when it is not JSON
+	Status      int                  // of a successful response, 200 by default
+	Public      bool                 // no authentication needed
+	Scope       uModel.APITokenScope // scope an API token needs for an eapi route
+}
+
+// Param is a query parameter or a form field.
+type Param struct {
+	Name        string
+	Type        string // string by default
+	Format      string
+	Description string
+	Required    bool
+}
+
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Paths      map[string]map[string]*operation `json:"paths"`
+	Components components                       `json:"components"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*securityScheme `json:"securitySchemes"`
+}
+
+type securityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+type operation struct {
+	Tags        []string              `json:"tags,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Parameters  []*parameter          `json:"parameters,omitempty"`
+	RequestBody *requestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Scope       uModel.APITokenScope  `json:"x-token-scope,omitempty"`
+}
+
+type parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type requestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*mediaType `json:"content"`
+}
+
+type response struct {
+	Description string                `json:"description"`
+	Content     map[string]*mediaType `json:"content,omitempty"`
+}
+
+type mediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema is the subset of JSON schema the generated document uses.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// Documented tells if a route is part of the API the document covers.
+func Documented(path string) bool {
+	return strings.HasPrefix(path, "/api/v1/") || strings.HasPrefix(path, "/eapi/v1/")
+}
+
+// Check returns the documented routes without an operation and the operations without a route, both as
+// "METHOD path".
+func Check(routes gin.RoutesInfo, operations []Operation) (missing []string, stale []string) {
+	byRoute := indexOperations(operations)
+	registered := map[string]bool{}
+	for _, route := range routes {
+		if !Documented(route.Path) {
+			continue
+		}
+		key := route.Method + " " + route.Path
+		registered[key] = true
+		if _, ok := byRoute[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	for key := range byRoute {
+		if !registered[key] {
+			stale = append(stale, key)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(stale)
+	return missing, stale
+}
+
+// Build generates the document of the registered routes that have an operation.
+func Build(info Info, routes gin.RoutesInfo, operations []Operation) *Document {
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info:    info,
+		Paths:   map[string]map[string]*operation{},
+		Components: components{
+			Schemas: map[string]*Schema{},
+			SecuritySchemes: map[string]*securityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT", Description: "Token returned by /api/v1/auth/login"},
+				"apiToken":   {Type: "apiKey", In: "header", Name: "secretkey", Description: "API token created with /api/v1/users/tokens"},
+			},
+		},
+	}
+	schemas := newSchemaBuilder(doc.Components.Schemas)
+	doc.Components.Schemas["Error"] = &Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{"error": {Type: "string"}},
+		Required:   []string{"error"},
+	}
+
+	byRoute := indexOperations(operations)
+	for _, route := range routes {
+		op, ok := byRoute[route.Method+" "+route.Path]
+		if !ok || !Documented(route.Path) {
+			continue
+		}
+		path, params := openAPIPath(route.Path)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]*operation{}
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = op.document(schemas, params)
+	}
+	return doc
+}
+
+func indexOperations(operations []Operation) map[string]Operation {
+	byRoute := make(map[string]Operation, len(operations))
+	for _, op := range operations {
+		byRoute[op.Method+" "+op.Path] = op
+	}
+	return byRoute
+}
+
+func (op Operation) document(schemas *schemaBuilder, pathParams []*parameter) *operation {
+	doc := &operation{
+		Tags:        []string{tag(op.Path)},
+		Summary:     op.Summary,
+		Description: op.Description,
+		Parameters:  pathParams,
+		Responses:   map[string]*response{},
+		Scope:       op.Scope,
+	}
+	for _, q := range op.Query {
+		doc.Parameters = append(doc.Parameters, &parameter{
+			Name:        q.Name,
+			In:          "query",
+			Description: q.Description,
+			Required:    q.Required,
+			Schema:      q.schema(),
+		})
+	}
+
+	switch {
+	case op.Body != nil:
+		doc.RequestBody = &requestBody{
+			Required: true,
+			Content:  map[string]*mediaType{"application/json": {Schema: schemas.schema(reflect.TypeOf(op.Body))}},
+		}
+	case len(op.Form) > 0:
+		form := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		for _, f := range op.Form {
+			form.Properties[f.Name] = f.schema()
+			if f.Required {
+				form.Required = append(form.Required, f.Name)
+			}
+		}
+		doc.RequestBody = &requestBody{
+			Required: true,
+			Content:  map[string]*mediaType{"multipart/form-data": {Schema: form}},
+		}
+	}
+
+	ok := &response{Description: "OK"}
+	switch {
+	case op.ContentType != "":
+		ok.Content = map[string]*mediaType{op.ContentType: {Schema: &Schema{Type: "string", Format: "binary"}}}
+	case op.Raw:
+		ok.Content = map[string]*mediaType{"application/json": {Schema: schemas.schema(reflect.TypeOf(op.Response))}}
+	default:
+		body := &Schema{Type: "object"}
+		if op.Response != nil {
+			body.Properties = map[string]*Schema{"res": schemas.schema(reflect.TypeOf(op.Response))}
+		}
+		ok.Content = map[string]*mediaType{"application/json": {Schema: body}}
+	}
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	doc.Responses[strconv.Itoa(status)] = ok
+	errorContent := map[string]*mediaType{"application/json": {Schema: &Schema{Ref: "#/components/schemas/Error"}}}
+	doc.Responses["4XX"] = &response{Description: "The request is invalid, unauthorized or refers to something that does not exist", Content: errorContent}
+	doc.Responses["5XX"] = &response{Description: "The server failed to handle the request", Content: errorContent}
+
+	switch {
+	case op.Public:
+	case strings.HasPrefix(op.Path, "/eapi/"):
+		doc.Security = []map[string][]string{{"apiToken": {}}}
+		if op.Scope != "" {
+			doc.Description = strings.TrimSpace(doc.Description + "\n\nNeeds an API token with the " + string(op.Scope) + " scope.")
+		}
+	default:
+		doc.Security = []map[string][]string{{"bearerAuth": {}}}
+	}
+	return doc
+}
+
+func (p Param) schema() *Schema {
+	typ := p.Type
+	if typ == "" {
+		typ = "string"
+	}
+	return &Schema{Type: typ, Format: p.Format}
+}
+
+// openAPIPath turns the :name and *name parameters of a gin path into {name} and describes them.
+func openAPIPath(ginPath string) (string, []*parameter) {
+	segments := strings.Split(ginPath, "/")
+	var params []*parameter
+	for i, segment := range segments {
+		if segment == "" || (segment[0] != ':' && segment[0] != '*') {
+			continue
+		}
+		name := segment[1:]
+		schema := &Schema{Type: "string"}
+		if strings.HasSuffix(strings.ToLower(name), "id") {
+			schema = &Schema{Type: "integer"}
+		}
+		segments[i] = "{" + name + "}"
+		params = append(params, &parameter{Name: name, In: "path", Required: true, Schema: schema})
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// tag groups operations by the resource after the version, external API routes apart from the app ones.
+func tag(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) < 3 {
+		return parts[0]
+	}
+	if parts[0] == "eapi" {
+		return "external " + parts[2]
+	}
+	return parts[2]
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// schemaBuilder generates schemas of Go types, named struct types become components that are referenced.
+type schemaBuilder struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+}
+
+func newSchemaBuilder(components map[string]*Schema) *schemaBuilder {
+	return &schemaBuilder{components: components, names: map[reflect.Type]string{}}
+}
+
+func (b *schemaBuilder) schema(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{Type: "object"}
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+	if t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType) {
+		// encoded however the type chooses to
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: b.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.object(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + b.component(t)}
+	}
+	return &Schema{}
+}
+
+func (b *schemaBuilder) component(t reflect.Type) string {
+	if name, ok := b.names[t]; ok {
+		return name
+	}
+	name := exported(t.Name())
+	if _, taken := b.components[name]; taken {
+		pkg := t.PkgPath()
+		name = exported(pkg[strings.LastIndex(pkg, "/")+1:]) + name
+	}
+	b.names[t] = name
+	// registered before its fields so types referring to themselves end
+	b.components[name] = &Schema{}
+	*b.components[name] = *b.object(t)
+	return name
+}
+
+func (b *schemaBuilder) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	b.addFields(s, t)
+	return s
+}
+
+func (b *schemaBuilder) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			// embedded fields are encoded as fields of the struct
+			b.addFields(s, fieldType)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		s.Properties[name] = b.schema(field.Type)
+		if strings.Contains(field.Tag.Get("binding"), "required") {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+func exported(name string) string {
+	if name == "" {
+		return name
+	}
+	r := []rune(name)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
@@ -0,0 +1,113 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"donetick.com/core/config"
+	"donetick.com/core/internal/openapi"
+	"donetick.com/core/internal/router"
+	"github.com/gin-gonic/gin"
+)
+
+// newTestEngine registers the routes main registers, the handlers are never called so every registration gets
+// the zero value of its dependencies.
+func newTestEngine() (*gin.Engine, *openapi.Handler) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	cfg := &config.Config{}
+	h := openapi.NewHandler(cfg)
+	given := map[reflect.Type]reflect.Value{
+		reflect.TypeOf(r):   reflect.ValueOf(r),
+		reflect.TypeOf(cfg): reflect.ValueOf(cfg),
+		reflect.TypeOf(h):   reflect.ValueOf(h),
+	}
+	for _, register := range router.Registrations() {
+		fn := reflect.ValueOf(register)
+		args := make([]reflect.Value, fn.Type().NumIn())
+		for i := range args {
+			in := fn.Type().In(i)
+			if arg, ok := given[in]; ok {
+				args[i] = arg
+			} else if in.Kind() == reflect.Ptr && in.Elem().Kind() == reflect.Struct {
+				args[i] = reflect.New(in.Elem())
+			} else {
+				args[i] = reflect.Zero(in)
+			}
+		}
+		fn.Call(args)
+	}
+	return r, h
+}
+
+func TestEveryRouteIsDocumented(t *testing.T) {
+	r, _ := newTestEngine()
+	missing, stale := openapi.Check(r.Routes(), openapi.Operations)
+	for _, route := range missing {
+		t.Errorf("%s is registered without an operation in Operations", route)
+	}
+	for _, route := range stale {
+		t.Errorf("%s is documented but not registered", route)
+	}
+}
+
+func TestDocument(t *testing.T) {
+	r, _ := newTestEngine()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /api/v1/openapi.json = %d", w.Code)
+	}
+	var doc openapi.Document
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid document: %v", err)
+	}
+
+	op := doc.Paths["/api/v1/chores/{id}/history/{history_id}"]["put"]
+	if op == nil {
+		t.Fatalf("no operation to modify chore history, paths: %v", doc.Paths)
+	}
+	if len(op.Parameters) != 2 || op.Parameters[0].Name != "id" || op.Parameters[0].Schema.Type != "integer" {
+		t.Errorf("parameters = %+v, want id and history_id", op.Parameters)
+	}
+	if op.Security[0]["bearerAuth"] == nil {
+		t.Errorf("security = %v, want the JWT", op.Security)
+	}
+
+	complete := doc.Paths["/eapi/v1/chore/{id}/complete"]["post"]
+	if complete == nil || complete.Security[0]["apiToken"] == nil || complete.Scope != "chores:write" {
+		t.Errorf("eapi operation = %+v, want the chores:write API token", complete)
+	}
+
+	choreReq := doc.Components.Schemas["ChoreReq"]
+	if choreReq == nil || choreReq.Properties["frequencyType"] == nil || !contains(choreReq.Required, "name") {
+		t.Errorf("ChoreReq = %+v, want its fields with name required", choreReq)
+	}
+	if ref := choreReq.Properties["thingTrigger"].Ref; ref != "#/components/schemas/ThingTrigger" {
+		t.Errorf("thingTrigger = %q, want a reference", ref)
+	}
+	// the label request of the handler and of the model have the same name
+	if doc.Components.Schemas["LabelReq"] == nil || doc.Components.Schemas["LabelLabelReq"] == nil {
+		t.Errorf("label requests are not both in the components")
+	}
+	for name, schema := range doc.Components.Schemas {
+		for property, s := range schema.Properties {
+			if ref := strings.TrimPrefix(s.Ref, "#/components/schemas/"); ref != "" && doc.Components.Schemas[ref] == nil {
+				t.Errorf("%s.%s refers to missing %s", name, property, ref)
+			}
+		}
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,372 @@
+package openapi
+
+import (
+	"time"
+
+	"donetick.com/core/internal/chore"
+	chModel "donetick.com/core/internal/chore/model"
+	cModel "donetick.com/core/internal/circle/model"
+	eModel "donetick.com/core/internal/events/model"
+	"donetick.com/core/internal/label"
+	lModel "donetick.com/core/internal/label/model"
+	nModel "donetick.com/core/internal/notifier/model"
+	"donetick.com/core/internal/offline"
+	"donetick.com/core/internal/resource"
+	"donetick.com/core/internal/thing"
+	tModel "donetick.com/core/internal/thing/model"
+	uModel "donetick.com/core/internal/user/model"
+)
+
+// Operations documents every route of api/v1 and eapi/v1, a route registered without one fails the tests.
+var Operations = []Operation{
+	// chores
+	{Method: "GET", Path: "/api/v1/chores/", Summary: "List the chores of the user", Response: []*chModel.Chore{},
+		Query: []Param{{Name: "includeArchived", Type: "boolean"}}},
+	{Method: "GET", Path: "/api/v1/chores/archived", Summary: "List the archived chores of the user", Response: []*chModel.Chore{}},
+	{Method: "GET", Path: "/api/v1/chores/trash", Summary: "List the deleted chores of the user that are still in the trash", Response: []*chModel.Chore{}},
+	{Method: "DELETE", Path: "/api/v1/chores/trash/:id", Summary: "Delete a chore in the trash for good", Response: message{}, Raw: true},
+	{Method: "GET", Path: "/api/v1/chores/history", Summary: "List recent completions of the user or their circle", Response: []*chModel.ChoreHistory{},
+		Query: []Param{{Name: "limit", Type: "integer", Description: "days of history"}, {Name: "members", Type: "boolean", Description: "include the completions of the circle"}}},
+	{Method: "GET", Path: "/api/v1/chores/forecast", Summary: "Forecast the occurrences of chores in the coming days", Response: chore.Forecast{},
+		Query: []Param{{Name: "days", Type: "integer"}}},
+	{Method: "POST", Path: "/api/v1/chores/", Summary: "Create a chore", Body: chModel.ChoreReq{}, Response: 0},
+	{Method: "PUT", Path: "/api/v1/chores/", Summary: "Edit a chore", Body: chModel.ChoreReq{}, Response: message{}, Raw: true,
+		Description: "An edit with the updatedAt of an older version of the chore is merged with what changed since. When both changed a field the response is a 409 with the conflicts field by field and the updatedAt to send the resolved edit with."},
+	{Method: "GET", Path: "/api/v1/chores/:id", Summary: "Get a chore", Response: chModel.Chore{}},
+	{Method: "GET", Path: "/api/v1/chores/:id/details", Summary: "Get a chore with statistics of its history", Response: chModel.ChoreDetail{}},
+	{Method: "GET", Path: "/api/v1/chores/:id/history", Summary: "List the history of a chore", Response: []*chModel.ChoreHistory{}},
+	{Method: "GET", Path: "/api/v1/chores/:id/revisions", Summary: "List the revisions of a chore with what each changed, the latest first", Response: []*chModel.ChoreRevision{}},
+	{Method: "POST", Path: "/api/v1/chores/:id/revisions/:revision_id/restore", Summary: "Restore a chore to one of its revisions", Response: message{}, Raw: true},
+	{Method: "PUT", Path: "/api/v1/chores/:id/history/:history_id", Summary: "Modify an entry of the history of a chore", Body: modifyHistoryReq{}, Response: chModel.ChoreHistory{}},
+	{Method: "DELETE", Path: "/api/v1/chores/:id/history/:history_id", Summary: "Delete an entry of the history of a chore", Response: message{}, Raw: true},
+	{Method: "PUT", Path: "/api/v1/chores/:id/priority", Summary: "Set the priority of a chore", Body: priorityReq{}, Response: message{}, Raw: true},
+	{Method: "PUT", Path: "/api/v1/chores/:id/subtask", Summary: "Complete or reopen a subtask", Body: subtaskReq{}},
+	{Method: "POST", Path: "/api/v1/chores/:id/do", Summary: "Complete a chore", Body: completeChoreReq{}, Response: chModel.Chore{},
+		Query: []Param{{Name: "completedDate", Format: "date-time"}}},
+	{Method: "POST", Path: "/api/v1/chores/:id/skip", Summary: "Skip a chore to its next due date", Response: chModel.Chore{}},
+	{Method: "PUT", Path: "/api/v1/chores/:id/status", Summary: "Start or pause a chore", Body: statusReq{}},
+	{Method: "PUT", Path: "/api/v1/chores/:id/assignee", Summary: "Assign a chore", Body: assigneeReq{}, Response: chModel.Chore{}},
+	{Method: "PUT", Path: "/api/v1/chores/:id/dueDate", Summary: "Set the due date of a chore", Body: dueDateReq{}, Response: chModel.Chore{}},
+	{Method: "PUT", Path: "/api/v1/chores/:id/archive", Summary: "Archive a chore", Response: message{}, Raw: true},
+	{Method: "PUT", Path: "/api/v1/chores/:id/unarchive", Summary: "Unarchive a chore", Response: message{}, Raw: true},
+	{Method: "DELETE", Path: "/api/v1/chores/:id", Summary: "Move a chore to the trash", Response: message{}, Raw: true,
+		Description: "The chore can be restored from the trash with its history until the trash retention passes, then it is deleted for good."},
+	{Method: "POST", Path: "/api/v1/chores/:id/restore", Summary: "Restore a chore from the trash", Response: chModel.Chore{}},
+
+	// labels
+	{Method: "GET", Path: "/api/v1/labels", Summary: "List the labels of the user and their circle", Response: []*lModel.Label{}, Raw: true},
+	{Method: "POST", Path: "/api/v1/labels", Summary: "Create a label", Body: label.LabelReq{}, Response: lModel.Label{}},
+	{Method: "PUT", Path: "/api/v1/labels", Summary: "Update a label", Body: label.UpdateLabelReq{}, Response: lModel.Label{}},
+	{Method: "DELETE", Path: "/api/v1/labels/:id", Summary: "Delete a label", Response: ""},
+
+	// things
+	{Method: "GET", Path: "/api/v1/things", Summary: "List the things of the circle the user has a role on", Response: []*tModel.Thing{}},
+	{Method: "POST", Path: "/api/v1/things", Summary: "Create a thing", Body: thing.ThingRequest{}, Response: tModel.Thing{}, Status: 201},
+	{Method: "PUT", Path: "/api/v1/things", Summary: "Update a thing", Body: thing.ThingRequest{}, Response: tModel.Thing{}},
+	{Method: "PUT", Path: "/api/v1/things/:id/state", Summary: "Set the state of a thing", Response: tModel.Thing{},
+		Query: []Param{{Name: "value", Required: true}}},
+	{Method: "GET", Path: "/api/v1/things/:id/history", Summary: "List the state changes of a thing", Response: []*tModel.ThingHistory{},
+		Query: []Param{{Name: "offset", Type: "integer"}}},
+	{Method: "GET", Path: "/api/v1/things/:id/history/aggregates", Summary: "Get the hourly or daily min, max and average of a number thing", Response: historyAggregates{},
+		Query: []Param{{Name: "resolution", Description: "hour or day"}, {Name: "from", Format: "date-time"}, {Name: "to", Format: "date-time"}}},
+	{Method: "DELETE", Path: "/api/v1/things/:id", Summary: "Delete a thing"},
+	{Method: "GET", Path: "/api/v1/things/:id/members", Summary: "List the roles set on a thing", Response: []*tModel.ThingMember{}},
+	{Method: "PUT", Path: "/api/v1/things/:id/members/:userId", Summary: "Set the role of a circle member on a thing", Body: thingMemberReq{}, Response: tModel.ThingMember{}},
+	{Method: "DELETE", Path: "/api/v1/things/:id/members/:userId", Summary: "Reset the role of a circle member on a thing to editor"},
+	{Method: "POST", Path: "/api/v1/things/rules/dry-run", Summary: "Replay a thing rule over recent history", Body: dryRunReq{}, Response: thing.DryRunResult{}},
+
+	// circles
+	{Method: "GET", Path: "/api/v1/circles/", Summary: "List the circles of the user", Response: []*cModel.CircleDetail{}},
+	{Method: "GET", Path: "/api/v1/circles/stream", Summary: "Stream the events of the circle as server-sent events", ContentType: "text/event-stream",
+		Query:       []Param{{Name: "lastEventId", Description: "id of the last event received, when the Last-Event-ID header can't be sent"}},
+		Description: "Every event has the id, the type of the event and its webhook payload as data. A reset event means the missed events can't be replayed and the client has to reload."},
+	{Method: "GET", Path: "/api/v1/circles/members", Summary: "List the members of the circle", Response: []*cModel.UserCircleDetail{}},
+	{Method: "GET", Path: "/api/v1/circles/members/requests", Summary: "List the requests to join the circle", Response: []*cModel.UserCircleDetail{}},
+	{Method: "PUT", Path: "/api/v1/circles/members/requests/accept", Summary: "Accept a request to join the circle", Response: "",
+		Query: []Param{{Name: "requestId", Type: "integer", Required: true}}},
+	{Method: "PUT", Path: "/api/v1/circles/members/role", Summary: "Change the role of a circle member", Body: changeRoleReq{}, Response: ""},
+	{Method: "POST", Path: "/api/v1/circles/join", Summary: "Request to join a circle", Response: "",
+		Query: []Param{{Name: "invite_code", Required: true}}},
+	{Method: "DELETE", Path: "/api/v1/circles/leave", Summary: "Leave a circle", Response: "",
+		Query: []Param{{Name: "circle_id", Type: "integer", Required: true}}},
+	{Method: "DELETE", Path: "/api/v1/circles/:id/members/delete", Summary: "Remove a member from the circle", Response: "",
+		Query: []Param{{Name: "member_id", Type: "integer", Required: true}}},
+	{Method: "POST", Path: "/api/v1/circles/:id/members/points/redeem", Summary: "Redeem points of a circle member", Body: redeemPointsReq{}, Response: ""},
+	{Method: "GET", Path: "/api/v1/circles/notifications/failed", Summary: "List notifications of the circle that failed to send", Response: []*nModel.Notification{}},
+	{Method: "GET", Path: "/api/v1/circles/webhook/deliveries", Summary: "List recent webhook deliveries of the circle", Response: []*eModel.WebhookDelivery{},
+		Query: []Param{{Name: "limit", Type: "integer"}}},
+	{Method: "POST", Path: "/api/v1/circles/webhook/deliveries/:id/redeliver", Summary: "Send a webhook delivery again", Response: eModel.WebhookDelivery{}},
+	{Method: "GET", Path: "/api/v1/circles/webhook/subscriptions", Summary: "List the webhook subscriptions of the circle", Response: []webhookSubscription{}},
+	{Method: "POST", Path: "/api/v1/circles/webhook/subscriptions", Summary: "Subscribe a URL to events of the circle", Body: webhookSubscriptionReq{}, Response: webhookSubscription{}, Status: 201},
+	{Method: "PUT", Path: "/api/v1/circles/webhook/subscriptions/:id", Summary: "Update a webhook subscription", Body: webhookSubscriptionReq{}, Response: webhookSubscription{}},
+	{Method: "DELETE", Path: "/api/v1/circles/webhook/subscriptions/:id", Summary: "Delete a webhook subscription"},
+
+	// offline sync
+	{Method: "GET", Path: "/api/v1/sync", Summary: "Get what changed since the last sync", Response: offline.SyncResponse{},
+		Query:       []Param{{Name: "since", Format: "date-time", Description: "cursor of the last sync, everything is returned without it"}},
+		Description: "When full is true the response has everything and replaces what the client has, this happens without since or when the last sync is older than the tombstone retention."},
+	{Method: "POST", Path: "/api/v1/sync/batch", Summary: "Apply operations queued while offline", Body: offline.BatchReq{}, Response: []offline.OperationResult{},
+		Description: "Operations are applied in order with the endpoint of their type and answered with a result each. An operation whose id was already applied is answered with its first result."},
+
+	// users
+	{Method: "GET", Path: "/api/v1/users/", Summary: "List the users of the circle", Response: []*uModel.User{}},
+	{Method: "GET", Path: "/api/v1/users/profile", Summary: "Get the profile of the user", Response: uModel.User{}},
+	{Method: "PUT", Path: "/api/v1/users", Summary: "Update the profile of the user", Body: updateUserReq{}, Response: uModel.User{}, Raw: true},
+	{Method: "POST", Path: "/api/v1/users/tokens", Summary: "Create an API token", Body: createTokenReq{}, Response: uModel.APIToken{}},
+	{Method: "GET", Path: "/api/v1/users/tokens", Summary: "List the API tokens of the user", Response: []*uModel.APIToken{}},
+	{Method: "DELETE", Path: "/api/v1/users/tokens/:id", Summary: "Delete an API token"},
+	{Method: "PUT", Path: "/api/v1/users/webhook", Summary: "Set the webhook of the circle", Body: webhookReq{}, Response: webhook{}},
+	{Method: "PUT", Path: "/api/v1/users/targets", Summary: "Set where the user is notified", Body: notificationTargetReq{},
+		Description: "An email address is only set once the link sent to it is opened, the response is then 202."},
+	{Method: "POST", Path: "/api/v1/users/targets/test", Summary: "Send a test notification", Body: notificationTargetReq{}},
+	{Method: "PUT", Path: "/api/v1/users/change_password", Summary: "Change the password of the user", Body: passwordReq{}},
+	{Method: "POST", Path: "/api/v1/users/profile_photo", Summary: "Upload a profile photo", Response: signedURL{}, Raw: true,
+		Form: []Param{{Name: "file", Format: "binary", Required: true}}},
+	{Method: "GET", Path: "/api/v1/users/storage", Summary: "Get the storage used by the user", Response: storageUsage{}},
+	{Method: "GET", Path: "/api/v1/users/mfa/status", Summary: "Tell if MFA is enabled", Response: mfaStatus{}, Raw: true},
+	{Method: "POST", Path: "/api/v1/users/mfa/setup", Summary: "Start setting up MFA", Response: uModel.MFASetupResponse{}, Raw: true},
+	{Method: "POST", Path: "/api/v1/users/mfa/confirm", Summary: "Enable MFA with a code from the authenticator", Body: confirmMFAReq{}, Response: message{}, Raw: true},
+	{Method: "POST", Path: "/api/v1/users/mfa/disable", Summary: "Disable MFA", Body: uModel.MFAVerifyRequest{}, Response: message{}, Raw: true},
+
+	// authentication
+	{Method: "POST", Path: "/api/v1/auth/", Summary: "Sign up", Body: signUpReq{}, Status: 201, Public: true},
+	{Method: "POST", Path: "/api/v1/auth/login", Summary: "Log in", Body: loginReq{}, Response: loginResp{}, Raw: true, Public: true,
+		Description: "Answers with mfaRequired and a session token to pass to /api/v1/auth/mfa/verify when the user has MFA enabled."},
+	{Method: "GET", Path: "/api/v1/auth/refresh", Summary: "Refresh the token of the user", Response: loginResp{}, Raw: true},
+	{Method: "POST", Path: "/api/v1/auth/:provider/callback", Summary: "Log in with google or an OAuth2 provider", Body: oauthReq{}, Response: loginResp{}, Raw: true, Public: true},
+	{Method: "POST", Path: "/api/v1/auth/reset", Summary: "Send a link to reset the password", Body: resetPasswordReq{}, Public: true},
+	{Method: "POST", Path: "/api/v1/auth/password", Summary: "Reset the password with the code of the link", Body: passwordReq{}, Public: true,
+		Query: []Param{{Name: "c", Required: true, Description: "code from the reset link"}}},
+	{Method: "POST", Path: "/api/v1/auth/mfa/verify", Summary: "Finish logging in with an MFA code", Body: uModel.MFAVerifyRequest{}, Response: loginResp{}, Raw: true, Public: true},
+	{Method: "GET", Path: "/api/v1/auth/unsubscribe", Summary: "Unsubscribe from emails from a link", ContentType: "text/html", Public: true,
+		Query: []Param{{Name: "t", Required: true, Description: "token from the email"}}},
+	{Method: "POST", Path: "/api/v1/auth/unsubscribe", Summary: "Unsubscribe from emails with one click", Public: true,
+		Query: []Param{{Name: "t", Required: true, Description: "token from the email"}}},
+	{Method: "GET", Path: "/api/v1/auth/confirm_email_target", Summary: "Confirm the email notification target from a link", ContentType: "text/html", Public: true,
+		Query: []Param{{Name: "t", Required: true, Description: "token from the email"}}},
+
+	// assets and resources
+	{Method: "POST", Path: "/api/v1/assets/chore", Summary: "Upload a file for the description of a chore", Response: uploadedAsset{}, Raw: true,
+		Form: []Param{{Name: "file", Format: "binary", Required: true}, {Name: "entityType"}, {Name: "entityId", Type: "integer"}}},
+	{Method: "GET", Path: "/api/v1/assets/*filepath", Summary: "Download an uploaded file with a signed URL", ContentType: "application/octet-stream", Public: true,
+		Query: []Param{{Name: "sig", Required: true}}},
+	{Method: "GET", Path: "/api/v1/resource", Summary: "Get the version and the identity provider of the server", Response: resource.Resource{}, Raw: true, Public: true},
+	{Method: "GET", Path: "/api/v1/openapi.json", Summary: "Get this document", Raw: true, Public: true},
+
+	// external API
+	{Method: "GET", Path: "/eapi/v1/chore", Summary: "List the chores of the token owner", Response: []*chModel.Chore{}, Raw: true, Scope: uModel.APITokenScopeRead},
+	{Method: "POST", Path: "/eapi/v1/chore", Summary: "Create a chore assigned to the token owner", Body: chModel.ChoreReq{}, Response: chModel.Chore{}, Raw: true, Scope: uModel.APITokenScopeChoresWrite},
+	{Method: "PUT", Path: "/eapi/v1/chore", Summary: "Edit a chore", Body: chModel.ChoreReq{}, Response: message{}, Raw: true, Scope: uModel.APITokenScopeChoresWrite},
+	{Method: "GET", Path: "/eapi/v1/chore/:id", Summary: "Get a chore", Response: chModel.Chore{}, Scope: uModel.APITokenScopeRead},
+	{Method: "GET", Path: "/eapi/v1/chore/:id/history", Summary: "List the history of a chore", Response: []*chModel.ChoreHistory{}, Scope: uModel.APITokenScopeRead},
+	{Method: "POST", Path: "/eapi/v1/chore/:id/complete", Summary: "Complete a chore", Response: chModel.Chore{}, Raw: true, Scope: uModel.APITokenScopeChoresWrite},
+	{Method: "POST", Path: "/eapi/v1/chore/:id/skip", Summary: "Skip a chore to its next due date", Response: chModel.Chore{}, Scope: uModel.APITokenScopeChoresWrite},
+	{Method: "PUT", Path: "/eapi/v1/chore/:id/archive", Summary: "Archive a chore", Response: message{}, Raw: true, Scope: uModel.APITokenScopeChoresWrite},
+	{Method: "PUT", Path: "/eapi/v1/chore/:id/unarchive", Summary: "Unarchive a chore", Response: message{}, Raw: true, Scope: uModel.APITokenScopeChoresWrite},
+	{Method: "PUT", Path: "/eapi/v1/chore/:id/subtask", Summary: "Complete or reopen a subtask", Body: subtaskReq{}, Scope: uModel.APITokenScopeChoresWrite},
+	{Method: "GET", Path: "/eapi/v1/labels", Summary: "List the labels of the token owner", Response: []*lModel.Label{}, Raw: true, Scope: uModel.APITokenScopeRead},
+	{Method: "POST", Path: "/eapi/v1/labels", Summary: "Create a label", Body: label.LabelReq{}, Response: lModel.Label{}, Scope: uModel.APITokenScopeChoresWrite},
+	{Method: "PUT", Path: "/eapi/v1/labels", Summary: "Update a label", Body: label.UpdateLabelReq{}, Response: lModel.Label{}, Scope: uModel.APITokenScopeChoresWrite},
+	{Method: "DELETE", Path: "/eapi/v1/labels/:id", Summary: "Delete a label", Response: "", Scope: uModel.APITokenScopeChoresWrite},
+	{Method: "GET", Path: "/eapi/v1/things", Summary: "List the things the token owner has a role on", Response: []*tModel.Thing{}, Scope: uModel.APITokenScopeRead},
+	{Method: "POST", Path: "/eapi/v1/things", Summary: "Create a thing", Body: thing.ThingRequest{}, Response: tModel.Thing{}, Status: 201, Scope: uModel.APITokenScopeThingsWrite},
+	{Method: "PUT", Path: "/eapi/v1/things", Summary: "Update a thing", Body: thing.ThingRequest{}, Response: tModel.Thing{}, Scope: uModel.APITokenScopeThingsWrite},
+	{Method: "DELETE", Path: "/eapi/v1/things/:id", Summary: "Delete a thing", Scope: uModel.APITokenScopeThingsWrite},
+	{Method: "PUT", Path: "/eapi/v1/things/:id/state", Summary: "Set the state of a thing", Response: tModel.Thing{}, Scope: uModel.APITokenScopeThingsWrite,
+		Query: []Param{{Name: "value", Required: true}}},
+	{Method: "GET", Path: "/eapi/v1/things/:id/state", Summary: "Set the state of a thing from a plain URL", Scope: uModel.APITokenScopeThingsWrite,
+		Query: []Param{{Name: "state", Required: true}}},
+	{Method: "GET", Path: "/eapi/v1/things/:id/state/change", Summary: "Add to or set the state of a thing from a plain URL", Response: thingState{}, Raw: true, Scope: uModel.APITokenScopeThingsWrite,
+		Query: []Param{{Name: "op", Type: "number", Description: "added to the state of a number thing"}, {Name: "set"}}},
+	{Method: "GET", Path: "/eapi/v1/things/:id/history", Summary: "List the state changes of a thing", Response: []*tModel.ThingHistory{}, Scope: uModel.APITokenScopeRead,
+		Query: []Param{{Name: "offset", Type: "integer"}}},
+	{Method: "GET", Path: "/eapi/v1/things/:id/history/aggregates", Summary: "Get the hourly or daily min, max and average of a number thing", Response: historyAggregates{}, Scope: uModel.APITokenScopeRead,
+		Query: []Param{{Name: "resolution", Description: "hour or day"}, {Name: "from", Format: "date-time"}, {Name: "to", Format: "date-time"}}},
+	{Method: "GET", Path: "/eapi/v1/calendar/user.ics", Summary: "Subscribe to the chores of the token owner", ContentType: "text/calendar", Query: calendarQuery,
+		Description: "Calendar apps that can't send headers pass the token as the token query parameter."},
+	{Method: "GET", Path: "/eapi/v1/calendar/circle.ics", Summary: "Subscribe to the chores of the circle of the token owner", ContentType: "text/calendar", Query: calendarQuery,
+		Description: "Only for admins of the circle. Calendar apps that can't send headers pass the token as the token query parameter."},
+}
+
+var calendarQuery = []Param{
+	{Name: "token", Description: "API token when it can't be sent as a header"},
+	{Name: "mine", Type: "boolean", Description: "only chores assigned to the token owner"},
+	{Name: "label", Type: "integer"},
+	{Name: "days", Type: "integer", Description: "how far ahead occurrences are listed"},
+}
+
+// The types below mirror request and response bodies the handlers declare inline.
+
+type message struct {
+	Message string `json:"message"`
+}
+
+type modifyHistoryReq struct {
+	PerformedAt *time.Time `json:"performedAt"`
+	DueDate     *time.Time `json:"dueDate"`
+	Notes       *string    `json:"notes"`
+}
+
+type priorityReq struct {
+	Priority *int `json:"priority" binding:"required"`
+}
+
+type subtaskReq struct {
+	ID          int        `json:"id"`
+	ChoreID     int        `json:"choreId"`
+	CompletedAt *time.Time `json:"completedAt"`
+}
+
+type completeChoreReq struct {
+	Note        string `json:"note"`
+	CompletedBy *int   `json:"completedBy"` // admins only
+}
+
+type statusReq struct {
+	Status *chModel.Status `json:"status" binding:"required"`
+}
+
+type assigneeReq struct {
+	Assignee int `json:"assignee" binding:"required"`
+}
+
+type dueDateReq struct {
+	DueDate string `json:"dueDate" binding:"required"`
+}
+
+type historyAggregates struct {
+	ThingID     int                     `json:"thingId"`
+	Resolution  tModel.TimeResolution   `json:"resolution"`
+	From        time.Time               `json:"from"`
+	To          time.Time               `json:"to"`
+	Points      []thing.HistoryPoint    `json:"points"`
+	Completions []*chModel.ChoreHistory `json:"completions"`
+}
+
+type thingMemberReq struct {
+	Role tModel.ThingRole `json:"role" binding:"required"`
+}
+
+type dryRunReq struct {
+	Rule    *tModel.ThingRule `json:"rule" binding:"required"`
+	ChoreID int               `json:"choreId"`
+	Since   *time.Time        `json:"since"`
+}
+
+type thingState struct {
+	State string `json:"state"`
+}
+
+type changeRoleReq struct {
+	MemberID int         `json:"memberId"`
+	Role     cModel.Role `json:"role"`
+}
+
+type redeemPointsReq struct {
+	Points int `json:"points"`
+	UserID int `json:"userId"`
+}
+
+type webhookSubscriptionReq struct {
+	URL          string   `json:"url" binding:"required"`
+	Events       []string `json:"events"`
+	Labels       []int    `json:"labels"`
+	IsEnabled    *bool    `json:"isEnabled"`
+	RotateSecret bool     `json:"rotateSecret"`
+}
+
+type webhookSubscription struct {
+	*eModel.WebhookSubscription
+	Events []string `json:"events"`
+	Labels []int    `json:"labels"`
+	Secret string   `json:"secret,omitempty"`
+}
+
+type updateUserReq struct {
+	DisplayName *string `json:"displayName"`
+	ChatID      *int64  `json:"chatID"`
+	Image       *string `json:"image"`
+	Timezone    *string `json:"timezone"`
+}
+
+type createTokenReq struct {
+	Name      string                `json:"name" binding:"required"`
+	MFACode   string                `json:"mfaCode"`
+	Scopes    uModel.APITokenScopes `json:"scopes"`
+	ExpiresAt *time.Time            `json:"expiresAt"`
+}
+
+type webhookReq struct {
+	URL          *string   `json:"url"`
+	RotateSecret bool      `json:"rotateSecret"`
+	Events       *[]string `json:"events"`
+}
+
+type webhook struct {
+	URL    *string  `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+}
+
+type notificationTargetReq struct {
+	Type   nModel.NotificationPlatform `json:"type"`
+	Target string                      `json:"target"`
+}
+
+type passwordReq struct {
+	Password string `json:"password" binding:"required"`
+}
+
+type signedURL struct {
+	Sign string `json:"sign"`
+}
+
+type uploadedAsset struct {
+	Path string `json:"path"`
+	Sign string `json:"sign"`
+}
+
+type storageUsage struct {
+	Used  int64 `json:"used"`
+	Total int64 `json:"total"`
+}
+
+type mfaStatus struct {
+	MFAEnabled bool `json:"mfaEnabled"`
+}
+
+type confirmMFAReq struct {
+	Secret      string   `json:"secret" binding:"required"`
+	Code        string   `json:"code" binding:"required"`
+	BackupCodes []string `json:"backupCodes" binding:"required"`
+}
+
+type signUpReq struct {
+	Username    string `json:"username" binding:"required"`
+	Password    string `json:"password" binding:"required"`
+	Email       string `json:"email" binding:"required"`
+	DisplayName string `json:"displayName"`
+}
+
+type loginReq struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type loginResp struct {
+	Code         int       `json:"code"`
+	Token        string    `json:"token"`
+	Expire       time.Time `json:"expire"`
+	MFARequired  bool      `json:"mfaRequired,omitempty"`
+	SessionToken string    `json:"sessionToken,omitempty"`
+}
+
+type oauthReq struct {
+	Token    string `json:"token"`    // google
+	Provider string `json:"provider"` // google
+	Code     string `json:"code"`     // OAuth2
+}
+
+type resetPasswordReq struct {
+	Email string `json:"email" binding:"required"`
+}
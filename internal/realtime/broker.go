@@ -0,0 +1,236 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	chRepo "donetick.com/core/internal/chore/repo"
+	"donetick.com/core/internal/events"
+	"donetick.com/core/logging"
+)
+
+const (
+	// backlogSize is how many events of a circle are kept to resume a stream from.
+	backlogSize = 256
+	// subscriberBuffer is how many events a stream can fall behind before it is closed, the client
+	// reconnects with the id of the last event it got and gets the rest from the backlog.
+	subscriberBuffer = 64
+)
+
+// Message is an event of a circle as it is sent on a stream.
+type Message struct {
+	ID   string
+	Type events.EventType
+	Data []byte
+
+	seq     uint64
+	viewers map[int]bool // users who can view the chore of the event, nil when the event is not about a chore
+}
+
+// visibleTo tells if the user can get the message, events about a chore only go to the users who can view it
+// the same way the API only shows a chore to its creator and its assignees.
+func (m Message) visibleTo(userID int) bool {
+	return m.viewers == nil || m.viewers[userID]
+}
+
+// Subscription gets the events of a circle until it is closed, C is closed when the broker drops it.
+type Subscription struct {
+	C <-chan Message
+
+	c        chan Message
+	circleID int
+	userID   int
+	closed   bool
+}
+
+type circleStream struct {
+	backlog     []Message
+	evictedSeq  uint64 // seq of the last event that fell out of the backlog
+	subscribers map[*Subscription]struct{}
+}
+
+// Broker keeps the recent events of every circle and fans them out to the open streams. Event ids are
+// "<epoch>-<seq>", the epoch changes on every start so an id from before a restart is never mistaken
+// for one of this run.
+type Broker struct {
+	choreRepo *chRepo.ChoreRepository
+
+	mu      sync.Mutex
+	epoch   string
+	seq     uint64
+	circles map[int]*circleStream
+	stopped bool
+}
+
+func NewBroker(ep *events.EventsProducer, cr *chRepo.ChoreRepository) *Broker {
+	b := &Broker{
+		choreRepo: cr,
+		epoch:     strconv.FormatInt(time.Now().UnixMilli(), 36),
+		circles:   make(map[int]*circleStream),
+	}
+	ep.AddSink(b)
+	return b
+}
+
+// PublishEvent keeps the event in the backlog of the circle and sends it to its streams. A stream that
+// is too far behind is closed rather than blocking the request that published the event.
+func (b *Broker) PublishEvent(ctx context.Context, circleID int, eventType events.EventType, choreID int, payload []byte) {
+	var viewers map[int]bool
+	if choreID != 0 {
+		viewers = b.choreViewers(ctx, choreID)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.stopped {
+		return
+	}
+
+	b.seq++
+	msg := Message{
+		ID:      fmt.Sprintf("%s-%d", b.epoch, b.seq),
+		Type:    eventType,
+		Data:    payload,
+		seq:     b.seq,
+		viewers: viewers,
+	}
+	stream := b.circle(circleID)
+	if len(stream.backlog) == backlogSize {
+		stream.evictedSeq = stream.backlog[0].seq
+		stream.backlog = append(stream.backlog[:0:0], stream.backlog[1:]...)
+	}
+	stream.backlog = append(stream.backlog, msg)
+
+	removedUserID := removedMember(eventType, payload)
+	for sub := range stream.subscribers {
+		if removedUserID != 0 && sub.userID == removedUserID {
+			b.closeLocked(sub)
+			continue
+		}
+		if !msg.visibleTo(sub.userID) {
+			continue
+		}
+		select {
+		case sub.c <- msg:
+		default:
+			b.closeLocked(sub)
+		}
+	}
+}
+
+// Subscribe opens a stream of the events of a circle. With the id of the last event the client got,
+// the events after it are returned to be sent first, reset is true when they can't all be replayed
+// because the id is from another run or too old and the client has to reload instead.
+func (b *Broker) Subscribe(circleID int, userID int, lastEventID string) (sub *Subscription, replay []Message, reset bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := make(chan Message, subscriberBuffer)
+	sub = &Subscription{C: c, c: c, circleID: circleID, userID: userID}
+	if b.stopped {
+		sub.closed = true
+		close(c)
+		return sub, nil, false
+	}
+	stream := b.circle(circleID)
+	stream.subscribers[sub] = struct{}{}
+
+	if lastEventID == "" {
+		return sub, nil, false
+	}
+	lastSeq, ok := b.parseID(lastEventID)
+	if !ok || lastSeq > b.seq || lastSeq < stream.evictedSeq {
+		return sub, nil, true
+	}
+	for _, msg := range stream.backlog {
+		if msg.seq > lastSeq && msg.visibleTo(userID) {
+			replay = append(replay, msg)
+		}
+	}
+	return sub, replay, false
+}
+
+// Unsubscribe closes a stream, it can be called more than once.
+func (b *Broker) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closeLocked(sub)
+}
+
+// Stop closes every stream so the server can shut down, open requests would otherwise never finish.
+func (b *Broker) Stop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.stopped = true
+	for _, stream := range b.circles {
+		for sub := range stream.subscribers {
+			b.closeLocked(sub)
+		}
+	}
+}
+
+// choreViewers returns the users who can view the chore, nobody when they can't be loaded.
+func (b *Broker) choreViewers(ctx context.Context, choreID int) map[int]bool {
+	viewers := map[int]bool{}
+	userIDs, err := b.choreRepo.GetChoreViewers(ctx, choreID)
+	if err != nil {
+		logging.FromContext(ctx).Warnw("Failed to get the users who can view the chore of an event", "chore", choreID, "error", err)
+		return viewers
+	}
+	for _, userID := range userIDs {
+		viewers[userID] = true
+	}
+	return viewers
+}
+
+func (b *Broker) circle(circleID int) *circleStream {
+	stream, ok := b.circles[circleID]
+	if !ok {
+		stream = &circleStream{subscribers: make(map[*Subscription]struct{})}
+		b.circles[circleID] = stream
+	}
+	return stream
+}
+
+func (b *Broker) closeLocked(sub *Subscription) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	close(sub.c)
+	if stream, ok := b.circles[sub.circleID]; ok {
+		delete(stream.subscribers, sub)
+	}
+}
+
+func (b *Broker) parseID(id string) (uint64, bool) {
+	epoch, rawSeq, ok := strings.Cut(id, "-")
+	if !ok || epoch != b.epoch {
+		return 0, false
+	}
+	seq, err := strconv.ParseUint(rawSeq, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return seq, true
+}
+
+// removedMember returns the user that is no longer a member of the circle after the event, their
+// streams are closed so they stop getting its events.
+func removedMember(eventType events.EventType, payload []byte) int {
+	if eventType != events.EventTypeMemberLeft && eventType != events.EventTypeMemberRemoved {
+		return 0
+	}
+	var event struct {
+		Data events.MemberEventData `json:"data"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return 0
+	}
+	return event.Data.Member.ID
+}
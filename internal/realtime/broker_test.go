@@ -0,0 +1,151 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"donetick.com/core/config"
+	chModel "donetick.com/core/internal/chore/model"
+	chRepo "donetick.com/core/internal/chore/repo"
+	"donetick.com/core/internal/database/dbtest"
+	"donetick.com/core/internal/events"
+)
+
+func publish(t *testing.T, b *Broker, circleID int, eventType events.EventType, data interface{}) {
+	t.Helper()
+	publishChoreEvent(t, b, circleID, eventType, 0, data)
+}
+
+func publishChoreEvent(t *testing.T, b *Broker, circleID int, eventType events.EventType, choreID int, data interface{}) {
+	t.Helper()
+	payload, err := json.Marshal(events.Event{Type: eventType, Data: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.PublishEvent(context.Background(), circleID, eventType, choreID, payload)
+}
+
+func TestBrokerResume(t *testing.T) {
+	b := NewBroker(&events.EventsProducer{}, nil)
+
+	publish(t, b, 1, events.EventTypeTaskCreated, nil)
+	publish(t, b, 2, events.EventTypeTaskCreated, nil)
+	first, _, _ := b.Subscribe(1, 1, "")
+	publish(t, b, 1, events.EventTypeTaskUpdated, nil)
+	publish(t, b, 1, events.EventTypeTaskDeleted, nil)
+
+	lastSeen := <-first.C
+	if lastSeen.Type != events.EventTypeTaskUpdated {
+		t.Fatalf("got %s, want the event published after subscribing", lastSeen.Type)
+	}
+	b.Unsubscribe(first)
+	for range first.C {
+		// the events sent before unsubscribing are still there, then the stream is closed
+	}
+
+	publish(t, b, 1, events.EventTypeTaskArchived, nil)
+	resumed, replay, reset := b.Subscribe(1, 1, lastSeen.ID)
+	defer b.Unsubscribe(resumed)
+	if reset {
+		t.Fatal("resuming from a kept event asked for a reset")
+	}
+	var types []events.EventType
+	for _, msg := range replay {
+		types = append(types, msg.Type)
+	}
+	if len(types) != 2 || types[0] != events.EventTypeTaskDeleted || types[1] != events.EventTypeTaskArchived {
+		t.Fatalf("replayed %v, want the events of the circle after the last one seen", types)
+	}
+}
+
+func TestBrokerReset(t *testing.T) {
+	b := NewBroker(&events.EventsProducer{}, nil)
+	publish(t, b, 1, events.EventTypeTaskCreated, nil)
+	oldest, _, _ := b.Subscribe(1, 1, "")
+	publish(t, b, 1, events.EventTypeTaskCreated, nil)
+	evicted := <-oldest.C
+	b.Unsubscribe(oldest)
+	// the event after the last one seen falls out of the backlog
+	for i := 0; i < backlogSize+1; i++ {
+		publish(t, b, 1, events.EventTypeTaskUpdated, nil)
+	}
+
+	for name, lastEventID := range map[string]string{
+		"evicted":     evicted.ID,
+		"other epoch": "0-1",
+		"future":      b.epoch + "-100000",
+		"malformed":   "abc",
+	} {
+		sub, replay, reset := b.Subscribe(1, 1, lastEventID)
+		b.Unsubscribe(sub)
+		if !reset || len(replay) != 0 {
+			t.Errorf("%s: reset %v with %d replayed, want a reset", name, reset, len(replay))
+		}
+	}
+}
+
+func TestBrokerClosesStreams(t *testing.T) {
+	b := NewBroker(&events.EventsProducer{}, nil)
+
+	slow, _, _ := b.Subscribe(1, 1, "")
+	for i := 0; i < subscriberBuffer+1; i++ {
+		publish(t, b, 1, events.EventTypeTaskUpdated, nil)
+	}
+	received := 0
+	for range slow.C {
+		received++
+	}
+	if received != subscriberBuffer {
+		t.Fatalf("slow stream got %d events before being closed, want %d", received, subscriberBuffer)
+	}
+
+	other, _, _ := b.Subscribe(1, 3, "")
+	removed, _, _ := b.Subscribe(1, 2, "")
+	publish(t, b, 1, events.EventTypeMemberRemoved, events.MemberEventData{CircleID: 1, Member: events.UserPayload{ID: 2}})
+	if _, ok := <-removed.C; ok {
+		t.Fatal("stream of a removed member got the event")
+	}
+	if msg := <-other.C; msg.Type != events.EventTypeMemberRemoved {
+		t.Fatalf("got %s, want the member removed event", msg.Type)
+	}
+
+	b.Stop()
+	if _, ok := <-other.C; ok {
+		t.Fatal("stream is still open after stop")
+	}
+}
+
+func TestBrokerChoreEventsOnlyGoToViewers(t *testing.T) {
+	db := dbtest.Open(t)
+	chore := &chModel.Chore{Name: "Birthday present", CircleID: 1, CreatedBy: 1, AssignedTo: 2, IsActive: true}
+	if err := db.Create(chore).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&chModel.ChoreAssignees{ChoreID: chore.ID, UserID: 2}).Error; err != nil {
+		t.Fatal(err)
+	}
+	b := NewBroker(&events.EventsProducer{}, chRepo.NewChoreRepository(db, &config.Config{}))
+
+	creator, _, _ := b.Subscribe(1, 1, "")
+	assignee, _, _ := b.Subscribe(1, 2, "")
+	member, _, _ := b.Subscribe(1, 3, "")
+	defer b.Stop()
+	publishChoreEvent(t, b, 1, events.EventTypeTaskUpdated, chore.ID, events.ChoreEventData{Chore: events.NewChorePayload(chore)})
+	publish(t, b, 1, events.EventTypeLabelCreated, nil)
+
+	for name, sub := range map[string]*Subscription{"creator": creator, "assignee": assignee} {
+		if msg := <-sub.C; msg.Type != events.EventTypeTaskUpdated {
+			t.Errorf("%s got %s, want the chore event", name, msg.Type)
+		}
+	}
+	first := <-member.C
+	if first.Type != events.EventTypeLabelCreated {
+		t.Fatalf("member who can't view the chore got %s, want only the label event", first.Type)
+	}
+
+	_, replay, _ := b.Subscribe(1, 3, b.epoch+"-0")
+	if len(replay) != 1 || replay[0].Type != events.EventTypeLabelCreated {
+		t.Errorf("replayed %d events to the member, want only the label event", len(replay))
+	}
+}
@@ -0,0 +1,110 @@
+package realtime
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	auth "donetick.com/core/internal/authorization"
+	"donetick.com/core/logging"
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// heartbeatInterval keeps proxies from closing an idle stream.
+	heartbeatInterval = 25 * time.Second
+	// writeTimeout replaces the write timeout of the server, which is for the whole response, for every
+	// write of a stream.
+	writeTimeout = 10 * time.Second
+	// retryMillis is how long clients wait before reconnecting.
+	retryMillis = 3000
+)
+
+// resetEvent tells the client that the events it missed can't be replayed and it has to reload.
+const resetEvent = "reset"
+
+type Handler struct {
+	broker *Broker
+}
+
+func NewHandler(broker *Broker) *Handler {
+	return &Handler{broker: broker}
+}
+
+// Stream sends the events of the circle of the user as server-sent events, the event is the type and
+// the data the webhook payload. Events about a chore are only sent to the users who can view the chore. Clients resume with the Last-Event-ID header, or the lastEventId
+// query parameter, and get a reset event when that is no longer possible.
+func (h *Handler) Stream(c *gin.Context) {
+	log := logging.FromContext(c)
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("lastEventId")
+	}
+	sub, replay, reset := h.broker.Subscribe(currentUser.CircleID, currentUser.ID, lastEventID)
+	defer h.broker.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	rc := http.NewResponseController(c.Writer)
+	write := func(format string, args ...interface{}) bool {
+		if err := rc.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil && err != http.ErrNotSupported {
+			return false
+		}
+		if _, err := fmt.Fprintf(c.Writer, format, args...); err != nil {
+			return false
+		}
+		return rc.Flush() == nil
+	}
+
+	if !write("retry: %d\n\n", retryMillis) {
+		return
+	}
+	if reset && !write("event: %s\ndata: {}\n\n", resetEvent) {
+		return
+	}
+	for _, msg := range replay {
+		if !write("id: %s\nevent: %s\ndata: %s\n\n", msg.ID, msg.Type, msg.Data) {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case msg, ok := <-sub.C:
+			if !ok {
+				log.Debugw("Closing event stream", "circle", currentUser.CircleID, "user", currentUser.ID)
+				return
+			}
+			if !write("id: %s\nevent: %s\ndata: %s\n\n", msg.ID, msg.Type, msg.Data) {
+				return
+			}
+		case <-heartbeat.C:
+			if !write(": ping\n\n") {
+				return
+			}
+		}
+	}
+}
+
+func Routes(router *gin.Engine, h *Handler, auth *jwt.GinJWTMiddleware) {
+	streamRoutes := router.Group("api/v1/circles/stream")
+	streamRoutes.Use(auth.MiddlewareFunc())
+	{
+		streamRoutes.GET("", h.Stream)
+	}
+}
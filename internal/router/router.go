@@ -0,0 +1,36 @@
+// Package router has the routes of the server.
+package router
+
+import (
+	"donetick.com/core/frontend"
+	"donetick.com/core/internal/chore"
+	"donetick.com/core/internal/circle"
+	"donetick.com/core/internal/label"
+	"donetick.com/core/internal/offline"
+	"donetick.com/core/internal/openapi"
+	"donetick.com/core/internal/realtime"
+	"donetick.com/core/internal/resource"
+	"donetick.com/core/internal/storage"
+	"donetick.com/core/internal/thing"
+	"donetick.com/core/internal/user"
+)
+
+// Registrations returns the functions that register the routes of the server, main invokes them with fx.
+func Registrations() []interface{} {
+	return []interface{}{
+		chore.Routes,
+		chore.APIs,
+		user.Routes,
+		circle.Routes,
+		thing.Routes,
+		thing.APIs,
+		label.Routes,
+		label.APIs,
+		storage.Routes,
+		frontend.Routes,
+		resource.Routes,
+		openapi.Routes,
+		realtime.Routes,
+		offline.Routes,
+	}
+}
@@ -205,8 +205,7 @@ func handleEntityType(c *gin.Context, h *Handler, currentUser *user.UserDetails)
 			log.Error("failed to get circle users from db", "error", err)
 			return storageModel.EntityTypeChoreDescription, 0, false
 		}
-		now := time.Now().UTC()
-		if err := chore.CanEdit(currentUser.ID, circleUsers, &now); err != nil {
+		if err := chore.CanEdit(currentUser.ID, circleUsers); err != nil {
 			log.Error("user is not allowed to edit chore", "error", err)
 			c.JSON(http.StatusForbidden, gin.H{"error": "user is not allowed to edit chore"})
 			return storageModel.EntityTypeChoreDescription, 0, false
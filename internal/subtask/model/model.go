@@ -10,4 +10,5 @@ type SubTask struct {
 	CompletedAt *time.Time `json:"completedAt" gorm:"column:completed_at"`
 	CompletedBy int        `json:"completedBy" gorm:"column:completed_by"`
 	ParentId    *int       `json:"parentId" gorm:"column:parent_id"`
+	UpdatedAt   *time.Time `json:"updatedAt,omitempty" gorm:"column:updated_at"`
 }
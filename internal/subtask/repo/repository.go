@@ -6,6 +6,7 @@ import (
 	"log"
 	"time"
 
+	oModel "donetick.com/core/internal/offline/model"
 	stModel "donetick.com/core/internal/subtask/model"
 	"gorm.io/gorm"
 )
@@ -34,6 +35,9 @@ func (r *SubTasksRepository) UpdateSubtask(c context.Context, choreId int, toBeR
 					log.Printf("Error deleting subtasks for chore %d: %v", choreId, err)
 					return fmt.Errorf("failed to delete subtasks: %w", err) // Rollback
 				}
+				if err := createSubtaskTombstones(tx, choreId, idsToRemove); err != nil {
+					return fmt.Errorf("failed to record deleted subtasks: %w", err)
+				}
 			}
 		}
 
@@ -154,6 +158,42 @@ func (r *SubTasksRepository) UpdateSubtask(c context.Context, choreId int, toBeR
 		return nil // Commit
 	})
 }
+func createSubtaskTombstones(tx *gorm.DB, choreID int, ids []int) error {
+	var circleID int
+	if err := tx.Table("chores").Where("id = ?", choreID).Pluck("circle_id", &circleID).Error; err != nil {
+		return err
+	}
+	deletedAt := time.Now().UTC()
+	tombstones := make([]*oModel.Tombstone, 0, len(ids))
+	for _, id := range ids {
+		tombstones = append(tombstones, &oModel.Tombstone{
+			EntityType: oModel.EntityTypeSubtask,
+			EntityID:   id,
+			ChoreID:    &choreID,
+			CircleID:   &circleID,
+			DeletedAt:  deletedAt,
+		})
+	}
+	return tx.Create(&tombstones).Error
+}
+
+// GetSubtasksUpdatedSince returns the subtasks of the chores with the ids that changed after since, all of them
+// when since is nil.
+func (r *SubTasksRepository) GetSubtasksUpdatedSince(c context.Context, choreIDs []int, since *time.Time) ([]*stModel.SubTask, error) {
+	subtasks := []*stModel.SubTask{}
+	if len(choreIDs) == 0 {
+		return subtasks, nil
+	}
+	query := r.db.WithContext(c).Where("chore_id IN (?)", choreIDs)
+	if since != nil {
+		query = query.Where("updated_at > ?", *since)
+	}
+	if err := query.Order("id asc").Find(&subtasks).Error; err != nil {
+		return nil, err
+	}
+	return subtasks, nil
+}
+
 func (r *SubTasksRepository) UpdateSubTaskStatus(c context.Context, userID int, subtaskID int, completedAt *time.Time) error {
 	return r.db.Model(&stModel.SubTask{}).Where("id = ?", subtaskID).Updates(map[string]interface{}{
 		"completed_at": completedAt,
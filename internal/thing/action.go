@@ -0,0 +1,43 @@
+package thing
+
+import (
+	"context"
+	"fmt"
+
+	tModel "donetick.com/core/internal/thing/model"
+)
+
+// ChoreActions runs the actions of triggers on their chores, the chore package implements it as
+// completing a chore schedules its next due date and assignee.
+type ChoreActions interface {
+	CompleteChoreByThing(c context.Context, choreID int, thingID int) error
+	SkipChoreByThing(c context.Context, choreID int, thingID int) error
+	ResetSubtasksByThing(c context.Context, choreID int, thingID int) error
+}
+
+// runAction runs the action of a trigger that started to match, the thing of the trigger is recorded as
+// the source of the chore history.
+func runAction(c context.Context, actions ChoreActions, tc *tModel.ThingChore) error {
+	switch tc.Action {
+	case tModel.ThingActionComplete:
+		return actions.CompleteChoreByThing(c, tc.ChoreID, tc.ThingID)
+	case tModel.ThingActionSkip:
+		return actions.SkipChoreByThing(c, tc.ChoreID, tc.ThingID)
+	case tModel.ThingActionResetSubtasks:
+		return actions.ResetSubtasksByThing(c, tc.ChoreID, tc.ThingID)
+	}
+	return fmt.Errorf("unknown thing action %s", tc.Action)
+}
+
+// triggerRule returns the rule of a trigger, a trigger on the state of its thing is a rule with one
+// condition.
+func triggerRule(tc *tModel.ThingChore) *tModel.ThingRule {
+	if tc.Rule != nil {
+		return tc.Rule
+	}
+	condition := tc.Condition
+	if condition == "" {
+		condition = "eq"
+	}
+	return &tModel.ThingRule{ThingID: tc.ThingID, Condition: condition, Target: tc.TriggerState}
+}
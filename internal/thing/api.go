@@ -2,16 +2,17 @@ package thing
 
 import (
 	"strconv"
-	"time"
 
 	"donetick.com/core/config"
+	auth "donetick.com/core/internal/authorization"
 	chRepo "donetick.com/core/internal/chore/repo"
 	cRepo "donetick.com/core/internal/circle/repo"
+	"donetick.com/core/internal/events"
 	tModel "donetick.com/core/internal/thing/model"
 	tRepo "donetick.com/core/internal/thing/repo"
+	uModel "donetick.com/core/internal/user/model"
 	uRepo "donetick.com/core/internal/user/repo"
 	"donetick.com/core/internal/utils"
-	"donetick.com/core/logging"
 	jwt "github.com/appleboy/gin-jwt/v2"
 	"github.com/gin-gonic/gin"
 )
@@ -22,16 +23,21 @@ type API struct {
 	thingRepo  *tRepo.ThingRepository
 	userRepo   *uRepo.UserRepository
 	tRepo      *tRepo.ThingRepository
+
+	choreActions   ChoreActions
+	eventsProducer *events.EventsProducer
 }
 
 func NewAPI(cr *chRepo.ChoreRepository, circleRepo *cRepo.CircleRepository,
-	thingRepo *tRepo.ThingRepository, userRepo *uRepo.UserRepository, tRepo *tRepo.ThingRepository) *API {
+	thingRepo *tRepo.ThingRepository, userRepo *uRepo.UserRepository, tRepo *tRepo.ThingRepository, choreActions ChoreActions, eventsProducer *events.EventsProducer) *API {
 	return &API{
-		choreRepo:  cr,
-		circleRepo: circleRepo,
-		thingRepo:  thingRepo,
-		userRepo:   userRepo,
-		tRepo:      tRepo,
+		choreRepo:      cr,
+		circleRepo:     circleRepo,
+		thingRepo:      thingRepo,
+		userRepo:       userRepo,
+		tRepo:          tRepo,
+		choreActions:   choreActions,
+		eventsProducer: eventsProducer,
 	}
 }
 
@@ -47,8 +53,9 @@ func (h *API) UpdateThingState(c *gin.Context) {
 		return
 	}
 
+	oldState := thing.State
 	thing.State = state
-	if !isValidThingState(thing) {
+	if !IsValidThingState(thing) {
 		c.JSON(400, gin.H{"error": "Invalid state for thing"})
 		return
 	}
@@ -57,6 +64,11 @@ func (h *API) UpdateThingState(c *gin.Context) {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
+	if err := RunTriggeredChores(c, h.tRepo, h.choreRepo, h.choreActions, thing); err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	PublishThingChanged(c.Request.Context(), h.tRepo, h.eventsProducer, thing, oldState)
 	c.JSON(200, gin.H{})
 }
 
@@ -65,6 +77,7 @@ func (h *API) ChangeThingState(c *gin.Context) {
 	if shouldReturn {
 		return
 	}
+	oldState := thing.State
 	addRemoveRaw := c.Query("op")
 	setRaw := c.Query("set")
 
@@ -72,27 +85,25 @@ func (h *API) ChangeThingState(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "Invalid increment value"})
 		return
 	}
-	var xValue int
-	var err error
 	if addRemoveRaw != "" {
-		xValue, err = strconv.Atoi(addRemoveRaw)
+		xValue, err := strconv.ParseFloat(addRemoveRaw, 64)
 		if err != nil {
 			c.JSON(400, gin.H{"error": "Invalid increment value"})
 			return
 		}
-		currentState, err := strconv.Atoi(thing.State)
+		currentState, err := strconv.ParseFloat(thing.State, 64)
 		if err != nil {
 			c.JSON(400, gin.H{"error": "Invalid state for thing"})
 			return
 		}
 		newState := currentState + xValue
-		thing.State = strconv.Itoa(newState)
+		thing.State = strconv.FormatFloat(newState, 'f', -1, 64)
 	}
 	if setRaw != "" {
 		thing.State = setRaw
 	}
 
-	if !isValidThingState(thing) {
+	if !IsValidThingState(thing) {
 		c.JSON(400, gin.H{"error": "Invalid state for thing"})
 		return
 	}
@@ -101,75 +112,53 @@ func (h *API) ChangeThingState(c *gin.Context) {
 		return
 	}
 
-	shouldReturn1 := WebhookEvaluateTriggerAndScheduleDueDate(h, c, thing)
-	if shouldReturn1 {
+	if err := RunTriggeredChores(c, h.tRepo, h.choreRepo, h.choreActions, thing); err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
+	PublishThingChanged(c.Request.Context(), h.tRepo, h.eventsProducer, thing, oldState)
 
 	c.JSON(200, gin.H{"state": thing.State})
 }
 
-func WebhookEvaluateTriggerAndScheduleDueDate(h *API, c *gin.Context, thing *tModel.Thing) bool {
-	// handler should be interface to not duplicate both WebhookEvaluateTriggerAndScheduleDueDate and EvaluateTriggerAndScheduleDueDate
-	// this is bad code written Saturday at 2:25 AM
-
-	log := logging.FromContext(c)
-
-	thingChores, err := h.tRepo.GetThingChoresByThingId(c, thing.ID)
-	if err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
-		return true
-	}
-	for _, tc := range thingChores {
-		triggered := EvaluateThingChore(tc, thing.State)
-		if triggered {
-			errSave := h.choreRepo.SetDueDate(c, tc.ChoreID, time.Now().UTC())
-			if errSave != nil {
-				log.Error("Error setting due date for chore ", errSave)
-				log.Error("Chore ID ", tc.ChoreID, " Thing ID ", thing.ID, " State ", thing.State)
-			}
-		}
-
-	}
-	return false
-}
-
 func validateUserAndThing(c *gin.Context, h *API) (*tModel.Thing, bool) {
-	apiToken := c.GetHeader("secretkey")
-	if apiToken == "" {
-		c.JSON(401, gin.H{"error": "Unauthorized"})
-		return nil, true
-	}
 	thingID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return nil, true
 	}
-	user, err := h.userRepo.GetUserByToken(c, apiToken)
-	if err != nil {
+	user, ok := auth.CurrentUser(c)
+	if !ok {
 		c.JSON(401, gin.H{"error": "Unauthorized"})
 		return nil, true
 	}
-	thing, err := h.thingRepo.GetThingByID(c, thingID)
-	if err != nil {
-		c.JSON(400, gin.H{"error": "Invalid thing id"})
-		return nil, true
-	}
-	if thing.UserID != user.ID {
-		c.JSON(401, gin.H{"error": "Unauthorized"})
+	thing, ok := authorizeThing(c, h.thingRepo, &user.User, thingID, tModel.ThingRoleEditor)
+	if !ok {
 		return nil, true
 	}
 	return thing, false
 }
 
-func APIs(cfg *config.Config, w *API, r *gin.Engine, auth *jwt.GinJWTMiddleware) {
+// APIs exposes things to integrations authenticated with an API token. Apart from the state shortcuts, the
+// endpoints are the ones of the app and check the roles on things the same way.
+func APIs(cfg *config.Config, w *API, h *Handler, r *gin.Engine, jwtAuth *jwt.GinJWTMiddleware) {
 
 	thingsAPI := r.Group("eapi/v1/things")
 
-	thingsAPI.Use(utils.TimeoutMiddleware(cfg.Server.WriteTimeout))
+	thingsAPI.Use(utils.TimeoutMiddleware(cfg.Server.WriteTimeout), auth.APITokenMiddleware(w.userRepo))
 	{
-		thingsAPI.GET("/:id/state/change", w.ChangeThingState)
-		thingsAPI.GET("/:id/state", w.UpdateThingState)
+		read := auth.RequireAPIScope(uModel.APITokenScopeRead)
+		write := auth.RequireAPIScope(uModel.APITokenScopeThingsWrite)
+
+		thingsAPI.GET("/:id/state/change", write, w.ChangeThingState)
+		thingsAPI.GET("/:id/state", write, w.UpdateThingState)
+		thingsAPI.GET("", read, h.GetAllThings)
+		thingsAPI.GET("/:id/history", read, h.GetThingHistory)
+		thingsAPI.GET("/:id/history/aggregates", read, h.GetThingHistoryAggregates)
+		thingsAPI.POST("", write, h.CreateThing)
+		thingsAPI.PUT("", write, h.UpdateThing)
+		thingsAPI.PUT("/:id/state", write, h.UpdateThingState)
+		thingsAPI.DELETE("/:id", write, h.DeleteThing)
 	}
 
 }
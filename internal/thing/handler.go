@@ -1,6 +1,8 @@
 package thing
 
 import (
+	"context"
+	"fmt"
 	"strconv"
 	"time"
 
@@ -24,6 +26,7 @@ type Handler struct {
 	nRepo          *nRepo.NotificationRepository
 	tRepo          *tRepo.ThingRepository
 	eventsProducer *events.EventsProducer
+	choreActions   ChoreActions
 }
 
 type ThingRequest struct {
@@ -34,7 +37,8 @@ type ThingRequest struct {
 }
 
 func NewHandler(cr *chRepo.ChoreRepository, circleRepo *cRepo.CircleRepository,
-	np *nps.NotificationPlanner, nRepo *nRepo.NotificationRepository, tRepo *tRepo.ThingRepository, eventsProducer *events.EventsProducer) *Handler {
+	np *nps.NotificationPlanner, nRepo *nRepo.NotificationRepository, tRepo *tRepo.ThingRepository, eventsProducer *events.EventsProducer,
+	choreActions ChoreActions) *Handler {
 	return &Handler{
 		choreRepo:      cr,
 		circleRepo:     circleRepo,
@@ -42,6 +46,7 @@ func NewHandler(cr *chRepo.ChoreRepository, circleRepo *cRepo.CircleRepository,
 		nRepo:          nRepo,
 		tRepo:          tRepo,
 		eventsProducer: eventsProducer,
+		choreActions:   choreActions,
 	}
 }
 
@@ -59,12 +64,13 @@ func (h *Handler) CreateThing(c *gin.Context) {
 		return
 	}
 	thing := &tModel.Thing{
-		Name:   req.Name,
-		UserID: currentUser.ID,
-		Type:   req.Type,
-		State:  req.State,
+		Name:     req.Name,
+		UserID:   currentUser.ID,
+		CircleID: currentUser.CircleID,
+		Type:     req.Type,
+		State:    req.State,
 	}
-	if !isValidThingState(thing) {
+	if !IsValidThingState(thing) {
 		c.JSON(400, gin.H{"error": "Invalid state"})
 		return
 	}
@@ -97,18 +103,13 @@ func (h *Handler) UpdateThingState(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "state or increment query param is required"})
 		return
 	}
-	thing, err := h.tRepo.GetThingByID(c, thingID)
-	old_state := thing.State
-	if thing.UserID != currentUser.ID {
-		c.JSON(403, gin.H{"error": "Forbidden"})
-		return
-	}
-	if err != nil {
-		c.JSON(500, gin.H{"error": "Unable to find thing"})
+	thing, ok := authorizeThing(c, h.tRepo, &currentUser.User, thingID, tModel.ThingRoleEditor)
+	if !ok {
 		return
 	}
+	old_state := thing.State
 	thing.State = val
-	if !isValidThingState(thing) {
+	if !IsValidThingState(thing) {
 		c.JSON(400, gin.H{"error": "Invalid state"})
 		return
 	}
@@ -122,13 +123,7 @@ func (h *Handler) UpdateThingState(c *gin.Context) {
 	if shouldReturn {
 		return
 	}
-	h.eventsProducer.ThingsUpdated(c.Request.Context(), currentUser.WebhookURL, map[string]interface{}{
-		"id":         thing.ID,
-		"name":       thing.Name,
-		"type":       thing.Type,
-		"from_state": old_state,
-		"to_state":   val,
-	})
+	PublishThingChanged(c.Request.Context(), h.tRepo, h.eventsProducer, thing, old_state)
 
 	c.JSON(200, gin.H{
 		"res": thing,
@@ -136,18 +131,80 @@ func (h *Handler) UpdateThingState(c *gin.Context) {
 }
 
 func EvaluateTriggerAndScheduleDueDate(h *Handler, c *gin.Context, thing *tModel.Thing) bool {
-	thingChores, err := h.tRepo.GetThingChoresByThingId(c, thing.ID)
-	if err != nil {
+	if err := RunTriggeredChores(c, h.tRepo, h.choreRepo, h.choreActions, thing); err != nil {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return true
 	}
+	return false
+}
+
+// PublishThingChanged publishes the change of state of a thing to the circle it is in, which is not always
+// the circle of the user who changed it.
+func PublishThingChanged(c context.Context, tr *tRepo.ThingRepository, ep *events.EventsProducer, thing *tModel.Thing, oldState string) {
+	circleID, webhookURL, err := tr.GetThingCircle(c, thing.ID)
+	if err != nil {
+		logging.FromContext(c).Errorw("Failed to get circle of thing", "thing", thing.ID, "error", err)
+		return
+	}
+	ep.ThingsUpdated(c, circleID, webhookURL, map[string]interface{}{
+		"id":         thing.ID,
+		"name":       thing.Name,
+		"type":       thing.Type,
+		"from_state": oldState,
+		"to_state":   thing.State,
+	})
+}
+
+// RunTriggeredChores runs the triggers that match the state of the thing. Scheduling sets the due date,
+// chores that are already due keep theirs.
+func RunTriggeredChores(c context.Context, tr *tRepo.ThingRepository, cr *chRepo.ChoreRepository, actions ChoreActions, thing *tModel.Thing) error {
+	log := logging.FromContext(c)
+	thingChores, err := TriggeredChores(c, tr, cr, thing)
+	if err != nil {
+		return err
+	}
+	for _, tc := range thingChores {
+		if tc.Action == tModel.ThingActionSchedule {
+			cr.SetDueDateIfNotExisted(c, tc.ChoreID, time.Now().UTC())
+			continue
+		}
+		if err := runAction(c, actions, tc); err != nil {
+			log.Errorw("Failed to run thing action", "chore", tc.ChoreID, "thing", thing.ID, "action", tc.Action, "error", err)
+		}
+	}
+	return nil
+}
+
+// TriggeredChores returns the triggers of the thing that match after a change of it. Triggers that
+// schedule match whenever their condition, or rule, does, the ones with another action only when the
+// change made them match.
+func TriggeredChores(c context.Context, tr *tRepo.ThingRepository, cr *chRepo.ChoreRepository, thing *tModel.Thing) ([]*tModel.ThingChore, error) {
+	log := logging.FromContext(c)
+	thingChores, err := tr.GetThingChoresByThingId(c, thing.ID)
+	if err != nil {
+		return nil, err
+	}
+	evaluator := NewRuleEvaluator(tr, cr)
+	var triggered []*tModel.ThingChore
 	for _, tc := range thingChores {
-		triggered := EvaluateThingChore(tc, thing.State)
-		if triggered {
-			h.choreRepo.SetDueDateIfNotExisted(c, tc.ChoreID, time.Now().UTC())
+		var matches bool
+		switch {
+		case tc.Action != tModel.ThingActionSchedule:
+			matches, err = evaluator.Started(c, triggerRule(tc), tc.ChoreID)
+		case tc.Rule != nil:
+			matches, err = evaluator.Evaluate(c, tc.Rule, tc.ChoreID)
+		default:
+			matches = EvaluateThingChore(tc, thing.State)
+		}
+		if err != nil {
+			log.Errorw("Failed to evaluate thing rule", "chore", tc.ChoreID, "thing", thing.ID, "error", err)
+			continue
+		}
+		if matches {
+			triggered = append(triggered, tc)
 		}
 	}
-	return false
+	return triggered, nil
 }
 
 func (h *Handler) UpdateThing(c *gin.Context) {
@@ -163,21 +220,15 @@ func (h *Handler) UpdateThing(c *gin.Context) {
 		return
 	}
 
-	thing, err := h.tRepo.GetThingByID(c, req.ID)
-
-	if err != nil {
-		c.JSON(500, gin.H{"error": "Unable to find thing"})
-		return
-	}
-	if thing.UserID != currentUser.ID {
-		c.JSON(403, gin.H{"error": "Forbidden"})
+	thing, ok := authorizeThing(c, h.tRepo, &currentUser.User, req.ID, tModel.ThingRoleOwner)
+	if !ok {
 		return
 	}
 	thing.Name = req.Name
 	thing.Type = req.Type
 	if req.State != "" {
 		thing.State = req.State
-		if !isValidThingState(thing) {
+		if !IsValidThingState(thing) {
 			c.JSON(400, gin.H{"error": "Invalid state"})
 			return
 		}
@@ -199,13 +250,29 @@ func (h *Handler) GetAllThings(c *gin.Context) {
 		return
 	}
 
-	things, err := h.tRepo.GetUserThings(c, currentUser.ID)
+	things, err := h.tRepo.GetCircleThings(c, currentUser.CircleID, currentUser.ID)
 	if err != nil {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
+	members, err := h.tRepo.GetUserThingMembers(c, currentUser.ID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	memberOf := map[int]*tModel.ThingMember{}
+	for _, member := range members {
+		memberOf[member.ThingID] = member
+	}
+	visible := make([]*tModel.Thing, 0, len(things))
+	for _, thing := range things {
+		thing.Role = roleOf(thing, currentUser.ID, currentUser.CircleID, memberOf[thing.ID])
+		if thing.Role != tModel.ThingRoleNone {
+			visible = append(visible, thing)
+		}
+	}
 	c.JSON(200, gin.H{
-		"res": things,
+		"res": visible,
 	})
 }
 
@@ -223,13 +290,7 @@ func (h *Handler) GetThingHistory(c *gin.Context) {
 		return
 	}
 
-	thing, err := h.tRepo.GetThingByID(c, thingID)
-	if err != nil {
-		c.JSON(500, gin.H{"error": "Unable to find thing"})
-		return
-	}
-	if thing.UserID != currentUser.ID {
-		c.JSON(403, gin.H{"error": "Forbidden"})
+	if _, ok := authorizeThing(c, h.tRepo, &currentUser.User, thingID, tModel.ThingRoleViewer); !ok {
 		return
 	}
 	offsetRaw := c.Query("offset")
@@ -263,13 +324,8 @@ func (h *Handler) DeleteThing(c *gin.Context) {
 		return
 	}
 
-	thing, err := h.tRepo.GetThingByID(c, thingID)
-	if err != nil {
-		c.JSON(500, gin.H{"error": "Unable to find thing"})
-		return
-	}
-	if thing.UserID != currentUser.ID {
-		c.JSON(403, gin.H{"error": "Forbidden"})
+	thing, ok := authorizeThing(c, h.tRepo, &currentUser.User, thingID, tModel.ThingRoleOwner)
+	if !ok {
 		return
 	}
 	//  confirm there are no chores associated with the thing:
@@ -288,6 +344,285 @@ func (h *Handler) DeleteThing(c *gin.Context) {
 	}
 	c.JSON(200, gin.H{})
 }
+
+// DryRunRule replays a rule over the recent history of its things and reports when it would have
+// triggered, without saving anything.
+func (h *Handler) DryRunRule(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req struct {
+		Rule    *tModel.ThingRule `json:"rule" binding:"required"`
+		ChoreID int               `json:"choreId"` // completions of the chore are the baseline of changed_by
+		Since   *time.Time        `json:"since"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request"})
+		return
+	}
+	if err := ValidateRule(req.Rule); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	now := time.Now().UTC()
+	since := now.Add(-7 * 24 * time.Hour)
+	if req.Since != nil {
+		since = req.Since.UTC()
+	}
+	if since.After(now) || now.Sub(since) > 90*24*time.Hour {
+		c.JSON(400, gin.H{"error": "since has to be within the last 90 days"})
+		return
+	}
+
+	for _, thingID := range req.Rule.ThingIDs() {
+		if _, ok := authorizeThing(c, h.tRepo, &currentUser.User, thingID, tModel.ThingRoleViewer); !ok {
+			return
+		}
+	}
+	if req.ChoreID != 0 {
+		chore, err := h.choreRepo.GetChore(c, req.ChoreID)
+		if err != nil || chore.CircleID != currentUser.CircleID {
+			c.JSON(400, gin.H{"error": "Invalid chore id"})
+			return
+		}
+	}
+
+	result, err := NewRuleEvaluator(h.tRepo, h.choreRepo).DryRun(c, req.Rule, req.ChoreID, since)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{
+		"res": result,
+	})
+}
+
+// GetThingHistoryAggregates returns the hourly or daily min, max and average of a number thing over a
+// period, with the completions of its chores in that period to chart them together.
+func (h *Handler) GetThingHistoryAggregates(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	thingID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid thing id"})
+		return
+	}
+	thing, ok := authorizeThing(c, h.tRepo, &currentUser.User, thingID, tModel.ThingRoleViewer)
+	if !ok {
+		return
+	}
+	if thing.Type != "number" {
+		c.JSON(400, gin.H{"error": "Only the history of number things can be aggregated"})
+		return
+	}
+
+	resolution := tModel.TimeResolution(c.DefaultQuery("resolution", string(tModel.ResolutionHour)))
+	defaultRange, maxRange := 7*24*time.Hour, 31*24*time.Hour
+	switch resolution {
+	case tModel.ResolutionHour:
+	case tModel.ResolutionDay:
+		defaultRange, maxRange = 90*24*time.Hour, 3*366*24*time.Hour
+	default:
+		c.JSON(400, gin.H{"error": "resolution has to be hour or day"})
+		return
+	}
+	to := time.Now().UTC()
+	if rawTo := c.Query("to"); rawTo != "" {
+		if to, err = time.Parse(time.RFC3339, rawTo); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid to date"})
+			return
+		}
+	}
+	from := to.Add(-defaultRange)
+	if rawFrom := c.Query("from"); rawFrom != "" {
+		if from, err = time.Parse(time.RFC3339, rawFrom); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid from date"})
+			return
+		}
+	}
+	if !from.Before(to) || to.Sub(from) > maxRange {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("from has to be before to and at most %d days before it", int(maxRange.Hours()/24))})
+		return
+	}
+	// whole periods, the first and the last are not cut off
+	from, to = resolution.Truncate(from), resolution.Truncate(to.Add(-time.Nanosecond)).Add(resolutionLength(resolution))
+
+	history, err := h.tRepo.GetThingHistoryBetween(c, thingID, from, to)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	buckets, err := h.tRepo.GetThingHistoryBuckets(c, thingID, tModel.ResolutionHour, from, to)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if resolution == tModel.ResolutionDay {
+		daily, err := h.tRepo.GetThingHistoryBuckets(c, thingID, tModel.ResolutionDay, from, to)
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		buckets = append(buckets, daily...)
+	}
+
+	thingChores, err := h.tRepo.GetThingChoresByThingId(c, thingID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	choreIDs := make([]int, 0, len(thingChores))
+	for _, tc := range thingChores {
+		choreIDs = append(choreIDs, tc.ChoreID)
+	}
+	completions, err := h.choreRepo.GetChoresHistoryBetween(c, choreIDs, from, to)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"res": gin.H{
+			"thingId":     thingID,
+			"resolution":  resolution,
+			"from":        from,
+			"to":          to,
+			"points":      aggregateHistory(thingID, resolution, history, buckets),
+			"completions": completions,
+		},
+	})
+}
+
+func resolutionLength(resolution tModel.TimeResolution) time.Duration {
+	if resolution == tModel.ResolutionDay {
+		return 24 * time.Hour
+	}
+	return time.Hour
+}
+
+// GetThingMembers returns the roles set on a thing, members of its circle without one are editors.
+func (h *Handler) GetThingMembers(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+	thingID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid thing id"})
+		return
+	}
+	if _, ok := authorizeThing(c, h.tRepo, &currentUser.User, thingID, tModel.ThingRoleViewer); !ok {
+		return
+	}
+
+	members, err := h.tRepo.GetThingMembers(c, thingID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{
+		"res": members,
+	})
+}
+
+// SetThingMember sets the role of a member of the circle of a thing.
+func (h *Handler) SetThingMember(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+	thingID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid thing id"})
+		return
+	}
+	userID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid user id"})
+		return
+	}
+	var req struct {
+		Role tModel.ThingRole `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || !tModel.IsValidThingRole(req.Role) {
+		c.JSON(400, gin.H{"error": "role has to be owner, editor or viewer"})
+		return
+	}
+	thing, ok := authorizeThing(c, h.tRepo, &currentUser.User, thingID, tModel.ThingRoleOwner)
+	if !ok {
+		return
+	}
+	if userID == thing.UserID {
+		c.JSON(400, gin.H{"error": "The creator of a thing is always its owner"})
+		return
+	}
+	if !h.isCircleMember(c, thing.CircleID, userID) {
+		return
+	}
+
+	member := &tModel.ThingMember{ThingID: thingID, UserID: userID, Role: req.Role}
+	if err := h.tRepo.SetThingMember(c, member); err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{
+		"res": member,
+	})
+}
+
+// DeleteThingMember removes the role set for a member, who is an editor again.
+func (h *Handler) DeleteThingMember(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+	thingID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid thing id"})
+		return
+	}
+	userID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid user id"})
+		return
+	}
+	if _, ok := authorizeThing(c, h.tRepo, &currentUser.User, thingID, tModel.ThingRoleOwner); !ok {
+		return
+	}
+
+	if err := h.tRepo.DeleteThingMember(c, thingID, userID); err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{})
+}
+
+// isCircleMember checks a user is an active member of a circle, writing a 400 otherwise.
+func (h *Handler) isCircleMember(c *gin.Context, circleID int, userID int) bool {
+	circleUsers, err := h.circleRepo.GetCircleUsers(c, circleID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Error getting circle users"})
+		return false
+	}
+	for _, cu := range circleUsers {
+		if cu.UserID == userID && cu.IsActive {
+			return true
+		}
+	}
+	c.JSON(400, gin.H{"error": "User is not a member of the circle of the thing"})
+	return false
+}
+
 func Routes(r *gin.Engine, h *Handler, auth *jwt.GinJWTMiddleware) {
 
 	thingRoutes := r.Group("api/v1/things")
@@ -298,6 +633,11 @@ func Routes(r *gin.Engine, h *Handler, auth *jwt.GinJWTMiddleware) {
 		thingRoutes.PUT("", h.UpdateThing)
 		thingRoutes.GET("", h.GetAllThings)
 		thingRoutes.GET("/:id/history", h.GetThingHistory)
+		thingRoutes.GET("/:id/history/aggregates", h.GetThingHistoryAggregates)
 		thingRoutes.DELETE("/:id", h.DeleteThing)
+		thingRoutes.GET("/:id/members", h.GetThingMembers)
+		thingRoutes.PUT("/:id/members/:userId", h.SetThingMember)
+		thingRoutes.DELETE("/:id/members/:userId", h.DeleteThingMember)
+		thingRoutes.POST("/rules/dry-run", h.DryRunRule)
 	}
 }
@@ -6,10 +6,10 @@ import (
 	tModel "donetick.com/core/internal/thing/model"
 )
 
-func isValidThingState(thing *tModel.Thing) bool {
+func IsValidThingState(thing *tModel.Thing) bool {
 	switch thing.Type {
 	case "number":
-		_, err := strconv.Atoi(thing.State)
+		_, err := strconv.ParseFloat(thing.State, 64)
 		return err == nil
 	case "text":
 		return true
@@ -32,26 +32,6 @@ func EvaluateThingChore(tchore *tModel.ThingChore, newState string) bool {
 		return newState != tchore.TriggerState
 	}
 
-	newStateInt, err := strconv.Atoi(newState)
-	if err != nil {
-		return false
-	}
-	TargetStateInt, err := strconv.Atoi(tchore.TriggerState)
-	if err != nil {
-		return false
-	}
-
-	switch tchore.Condition {
-	case "gt":
-		return newStateInt > TargetStateInt
-	case "lt":
-		return newStateInt < TargetStateInt
-	case "gte":
-		return newStateInt >= TargetStateInt
-	case "lte":
-		return newStateInt <= TargetStateInt
-	default:
-		return newState == tchore.TriggerState
-	}
+	return compareState(tchore.Condition, newState, tchore.TriggerState)
 
 }
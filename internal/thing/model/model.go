@@ -1,6 +1,11 @@
 package model
 
-import "time"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
 
 type Thing struct {
 	ID          int          `json:"id" gorm:"primary_key"`
@@ -12,6 +17,47 @@ type Thing struct {
 	ThingChores []ThingChore `json:"thingChores" gorm:"foreignkey:ThingID;references:ID"`
 	UpdatedAt   *time.Time   `json:"updatedAt" gorm:"column:updated_at"`
 	CreatedAt   *time.Time   `json:"createdAt" gorm:"column:created_at"`
+	Role        ThingRole    `json:"role,omitempty" gorm:"-"` // role of the current user on the thing
+}
+
+// ThingMember sets the role of a member of the circle of a thing, members without one are editors.
+type ThingMember struct {
+	ThingID   int        `json:"thingId" gorm:"column:thing_id;primaryKey"`
+	UserID    int        `json:"userId" gorm:"column:user_id;primaryKey;index"`
+	Role      ThingRole  `json:"role" gorm:"column:role"`
+	UpdatedAt *time.Time `json:"updatedAt" gorm:"column:updated_at"`
+}
+
+// ThingRole is what a user can do with a thing: viewers see it and its history, editors also change its
+// state and trigger chores with it, owners also edit, share and delete it.
+type ThingRole string
+
+const (
+	ThingRoleNone   ThingRole = ""
+	ThingRoleViewer ThingRole = "viewer"
+	ThingRoleEditor ThingRole = "editor"
+	ThingRoleOwner  ThingRole = "owner"
+)
+
+func (r ThingRole) level() int {
+	switch r {
+	case ThingRoleViewer:
+		return 1
+	case ThingRoleEditor:
+		return 2
+	case ThingRoleOwner:
+		return 3
+	}
+	return 0
+}
+
+// Allows tells if the role can do what the required role can, having no role allows nothing.
+func (r ThingRole) Allows(required ThingRole) bool {
+	return r.level() > 0 && r.level() >= required.level()
+}
+
+func IsValidThingRole(role ThingRole) bool {
+	return role.level() > 0
 }
 
 type ThingHistory struct {
@@ -22,17 +68,171 @@ type ThingHistory struct {
 	CreatedAt *time.Time `json:"createdAt" gorm:"column:created_at"`
 }
 
+// ThingHistoryBucket summarizes the states of a number thing over an hour or a day, it replaces the
+// history of the period once the raw history is no longer kept.
+type ThingHistoryBucket struct {
+	ID         int            `json:"-" gorm:"primary_key"`
+	ThingID    int            `json:"thingId" gorm:"column:thing_id;uniqueIndex:idx_thing_bucket"`
+	Resolution TimeResolution `json:"resolution" gorm:"column:resolution;uniqueIndex:idx_thing_bucket"`
+	Start      time.Time      `json:"start" gorm:"column:start;uniqueIndex:idx_thing_bucket"`
+	Min        float64        `json:"min" gorm:"column:min"`
+	Max        float64        `json:"max" gorm:"column:max"`
+	Sum        float64        `json:"-" gorm:"column:sum"`
+	Count      int            `json:"count" gorm:"column:count"`
+}
+
+type TimeResolution string
+
+const (
+	ResolutionHour TimeResolution = "hour"
+	ResolutionDay  TimeResolution = "day"
+)
+
+// Truncate returns the start of the period a time is in, days are in UTC.
+func (r TimeResolution) Truncate(t time.Time) time.Time {
+	if r == ResolutionDay {
+		return t.UTC().Truncate(24 * time.Hour)
+	}
+	return t.UTC().Truncate(time.Hour)
+}
+
+func (b *ThingHistoryBucket) Avg() float64 {
+	if b.Count == 0 {
+		return 0
+	}
+	return b.Sum / float64(b.Count)
+}
+
+// Add counts a value in the bucket.
+func (b *ThingHistoryBucket) Add(value float64) {
+	b.Merge(&ThingHistoryBucket{Min: value, Max: value, Sum: value, Count: 1})
+}
+
+// Merge counts the values of another bucket in the bucket.
+func (b *ThingHistoryBucket) Merge(other *ThingHistoryBucket) {
+	if other.Count == 0 {
+		return
+	}
+	if b.Count == 0 || other.Min < b.Min {
+		b.Min = other.Min
+	}
+	if b.Count == 0 || other.Max > b.Max {
+		b.Max = other.Max
+	}
+	b.Sum += other.Sum
+	b.Count += other.Count
+}
+
 type ThingChore struct {
-	ThingID      int    `json:"thingId" gorm:"column:thing_id;primaryKey;uniqueIndex:idx_thing_user"`
-	ChoreID      int    `json:"choreId" gorm:"column:chore_id;primaryKey;uniqueIndex:idx_thing_user"`
-	TriggerState string `json:"triggerState" gorm:"column:trigger_state"`
-	Condition    string `json:"condition" gorm:"column:condition"`
+	ThingID      int         `json:"thingId" gorm:"column:thing_id;primaryKey;uniqueIndex:idx_thing_user"`
+	ChoreID      int         `json:"choreId" gorm:"column:chore_id;primaryKey;uniqueIndex:idx_thing_user"`
+	TriggerState string      `json:"triggerState" gorm:"column:trigger_state"`
+	Condition    string      `json:"condition" gorm:"column:condition"`
+	Rule         *ThingRule  `json:"rule,omitempty" gorm:"column:rule;type:json"` // replaces TriggerState and Condition when set
+	Action       ThingAction `json:"action" gorm:"column:action"`
 }
 
+// ThingAction is what a trigger does with its chore. Scheduling sets the due date whenever the trigger
+// matches, the other actions only run when it starts to match.
+type ThingAction string
+
+const (
+	ThingActionSchedule      ThingAction = ""
+	ThingActionComplete      ThingAction = "complete"
+	ThingActionSkip          ThingAction = "skip"
+	ThingActionResetSubtasks ThingAction = "reset_subtasks"
+)
+
+func IsValidThingAction(action ThingAction) bool {
+	switch action {
+	case ThingActionSchedule, ThingActionComplete, ThingActionSkip, ThingActionResetSubtasks:
+		return true
+	}
+	return false
+}
+
+// A chore with a rule has a ThingChore for every thing of the rule so a change of any of them evaluates
+// it, the ones for the other things than the trigger thing have this condition.
+const ThingChoreConditionRuleRef = "rule_ref"
+
 type ThingTrigger struct {
-	ID           int    `json:"thingID" binding:"required"`
-	TriggerState string `json:"triggerState" binding:"required"`
-	Condition    string `json:"condition"`
+	ID           int         `json:"thingID" binding:"required"`
+	TriggerState string      `json:"triggerState"`
+	Condition    string      `json:"condition"`
+	Rule         *ThingRule  `json:"rule"`
+	Action       ThingAction `json:"action"`
+}
+
+// ThingRule is a trigger condition over one or more things. A rule with an Op combines its Rules,
+// any other rule compares the state of ThingID:
+//
+//	{"op": "and", "rules": [
+//	  {"thingId": 1, "condition": "between", "min": 18, "max": 22},
+//	  {"thingId": 2, "condition": "eq", "value": "true", "forSeconds": 600},
+//	  {"thingId": 3, "condition": "changed_by", "value": "50"}
+//	]}
+type ThingRule struct {
+	Op    RuleOp      `json:"op,omitempty"`
+	Rules []ThingRule `json:"rules,omitempty"`
+
+	ThingID   int      `json:"thingId,omitempty"`
+	Condition string   `json:"condition,omitempty"` // eq, neq, gt, gte, lt, lte, between or changed_by
+	Target    string   `json:"value,omitempty"`     // state or number compared against, the change for changed_by
+	Min       *float64 `json:"min,omitempty"`       // bounds of between, inclusive
+	Max       *float64 `json:"max,omitempty"`
+	// the condition only matches once it held for this long
+	ForSeconds int `json:"forSeconds,omitempty"`
+}
+
+type RuleOp string
+
+const (
+	RuleOpAnd RuleOp = "and"
+	RuleOpOr  RuleOp = "or"
+)
+
+// ThingIDs returns the things the rule depends on.
+func (r *ThingRule) ThingIDs() []int {
+	seen := map[int]bool{}
+	var ids []int
+	r.Walk(func(leaf *ThingRule) {
+		if !seen[leaf.ThingID] {
+			seen[leaf.ThingID] = true
+			ids = append(ids, leaf.ThingID)
+		}
+	})
+	return ids
+}
+
+// Walk calls fn with every comparison of the rule.
+func (r *ThingRule) Walk(fn func(leaf *ThingRule)) {
+	if r.Op == "" {
+		fn(r)
+		return
+	}
+	for i := range r.Rules {
+		r.Rules[i].Walk(fn)
+	}
+}
+
+func (r ThingRule) Value() (driver.Value, error) {
+	return json.Marshal(r)
+}
+
+func (r *ThingRule) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(bytes, r)
 }
 
 type ThingType string
@@ -0,0 +1,186 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"donetick.com/core/config"
+	chRepo "donetick.com/core/internal/chore/repo"
+	"donetick.com/core/internal/events"
+	"donetick.com/core/internal/thing"
+	tRepo "donetick.com/core/internal/thing/repo"
+	"donetick.com/core/logging"
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultClientID    = "donetick"
+	defaultTopicPrefix = "donetick"
+	connectTimeout     = 10 * time.Second
+	publishTimeout     = 5 * time.Second
+)
+
+// Bridge connects things to an MQTT broker:
+//
+//	<prefix>/things/<id>/set                 sets the state of a thing, the payload is the new state
+//	<prefix>/things/<id>/state               the current state of a thing, retained
+//	<prefix>/circles/<id>/events/<type>      every event of a circle, with the webhook payload
+type Bridge struct {
+	cfg            config.MQTTConfig
+	client         paho.Client
+	thingRepo      *tRepo.ThingRepository
+	choreRepo      *chRepo.ChoreRepository
+	eventsProducer *events.EventsProducer
+	choreActions   thing.ChoreActions
+	logger         *zap.SugaredLogger
+}
+
+func NewBridge(cfg *config.Config, tr *tRepo.ThingRepository, cr *chRepo.ChoreRepository, ep *events.EventsProducer, choreActions thing.ChoreActions) *Bridge {
+	mqttCfg := cfg.MQTT
+	if mqttCfg.ClientID == "" {
+		mqttCfg.ClientID = defaultClientID
+	}
+	if mqttCfg.TopicPrefix == "" {
+		mqttCfg.TopicPrefix = defaultTopicPrefix
+	}
+	mqttCfg.TopicPrefix = strings.TrimSuffix(mqttCfg.TopicPrefix, "/")
+
+	b := &Bridge{
+		cfg:            mqttCfg,
+		thingRepo:      tr,
+		choreRepo:      cr,
+		eventsProducer: ep,
+		choreActions:   choreActions,
+		logger:         logging.DefaultLogger(),
+	}
+	if mqttCfg.Enabled {
+		ep.AddSink(b)
+	}
+	return b
+}
+
+// Start connects to the broker, the client reconnects and subscribes again on its own after that.
+func (b *Bridge) Start(ctx context.Context) error {
+	if !b.cfg.Enabled {
+		return nil
+	}
+	if b.cfg.Broker == "" {
+		return fmt.Errorf("mqtt.broker is not set")
+	}
+	b.logger = logging.FromContext(ctx)
+
+	opts := paho.NewClientOptions().
+		AddBroker(b.cfg.Broker).
+		SetClientID(b.cfg.ClientID).
+		SetUsername(b.cfg.Username).
+		SetPassword(b.cfg.Password).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetOnConnectHandler(func(client paho.Client) {
+			token := client.Subscribe(b.setTopic("+"), b.cfg.QoS, func(_ paho.Client, msg paho.Message) {
+				b.handleSetState(context.Background(), msg.Topic(), string(msg.Payload()))
+			})
+			if token.WaitTimeout(connectTimeout) && token.Error() != nil {
+				b.logger.Errorw("Failed to subscribe to thing topics", "error", token.Error())
+				return
+			}
+			b.logger.Infow("Connected to MQTT broker", "broker", b.cfg.Broker)
+		}).
+		SetConnectionLostHandler(func(_ paho.Client, err error) {
+			b.logger.Warnw("Lost connection to MQTT broker, reconnecting", "error", err)
+		})
+	b.client = paho.NewClient(opts)
+
+	// with connect retry the token only completes once connected, the first connect is not waited for
+	// so a broker that is down does not stop the server from starting
+	b.client.Connect()
+	return nil
+}
+
+func (b *Bridge) Stop() {
+	if b.client != nil {
+		b.client.Disconnect(250)
+	}
+}
+
+func (b *Bridge) setTopic(thingID string) string {
+	return fmt.Sprintf("%s/things/%s/set", b.cfg.TopicPrefix, thingID)
+}
+
+func (b *Bridge) stateTopic(thingID int) string {
+	return fmt.Sprintf("%s/things/%d/state", b.cfg.TopicPrefix, thingID)
+}
+
+func (b *Bridge) eventTopic(circleID int, eventType events.EventType) string {
+	return fmt.Sprintf("%s/circles/%d/events/%s", b.cfg.TopicPrefix, circleID, eventType)
+}
+
+// handleSetState stores the state published on the set topic of a thing, runs the triggers it matches
+// and publishes the change like an update through the API.
+func (b *Bridge) handleSetState(ctx context.Context, topic string, state string) {
+	rawID := strings.TrimSuffix(strings.TrimPrefix(topic, b.cfg.TopicPrefix+"/things/"), "/set")
+	thingID, err := strconv.Atoi(rawID)
+	if err != nil {
+		b.logger.Warnw("Ignoring MQTT message for invalid thing id", "topic", topic)
+		return
+	}
+	t, err := b.thingRepo.GetThingByID(ctx, thingID)
+	if err != nil {
+		b.logger.Warnw("Ignoring MQTT message for unknown thing", "thing", thingID, "error", err)
+		return
+	}
+
+	oldState := t.State
+	t.State = strings.TrimSpace(state)
+	if !thing.IsValidThingState(t) {
+		b.logger.Warnw("Ignoring invalid MQTT state for thing", "thing", thingID, "type", t.Type, "state", state)
+		return
+	}
+	if err := b.thingRepo.UpdateThingState(ctx, t); err != nil {
+		b.logger.Errorw("Failed to update thing state", "thing", thingID, "error", err)
+		return
+	}
+	if err := thing.RunTriggeredChores(ctx, b.thingRepo, b.choreRepo, b.choreActions, t); err != nil {
+		b.logger.Errorw("Failed to evaluate thing triggers", "thing", thingID, "error", err)
+	}
+
+	thing.PublishThingChanged(ctx, b.thingRepo, b.eventsProducer, t, oldState)
+}
+
+// PublishEvent publishes an event of a circle on its event topic, thing changes also update the
+// retained state of the thing.
+func (b *Bridge) PublishEvent(ctx context.Context, circleID int, eventType events.EventType, choreID int, payload []byte) {
+	if b.client == nil {
+		return
+	}
+	b.publish(b.eventTopic(circleID, eventType), false, payload)
+
+	if eventType != events.EventTypeThingChanged {
+		return
+	}
+	var event struct {
+		Data struct {
+			ID      int    `json:"id"`
+			ToState string `json:"to_state"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil || event.Data.ID == 0 {
+		return
+	}
+	b.publish(b.stateTopic(event.Data.ID), true, []byte(event.Data.ToState))
+}
+
+// publish does not wait for the broker, events are published from request handlers.
+func (b *Bridge) publish(topic string, retained bool, payload []byte) {
+	token := b.client.Publish(topic, b.cfg.QoS, retained, payload)
+	go func() {
+		if token.WaitTimeout(publishTimeout) && token.Error() != nil {
+			b.logger.Warnw("Failed to publish to MQTT broker", "topic", topic, "error", token.Error())
+		}
+	}()
+}
@@ -0,0 +1,191 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"donetick.com/core/config"
+	chModel "donetick.com/core/internal/chore/model"
+	chRepo "donetick.com/core/internal/chore/repo"
+	cModel "donetick.com/core/internal/circle/model"
+	"donetick.com/core/internal/database/dbtest"
+	"donetick.com/core/internal/events"
+	eRepo "donetick.com/core/internal/events/repo"
+	tModel "donetick.com/core/internal/thing/model"
+	tRepo "donetick.com/core/internal/thing/repo"
+	uModel "donetick.com/core/internal/user/model"
+	paho "github.com/eclipse/paho.mqtt.golang"
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"gorm.io/gorm"
+)
+
+// startBroker runs an embedded broker that accepts every client and returns its address.
+func startBroker(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to find a free port: %v", err)
+	}
+	address := l.Addr().String()
+	l.Close()
+
+	server := mochi.New(nil)
+	if err := server.AddHook(new(auth.AllowHook), nil); err != nil {
+		t.Fatalf("failed to add auth hook: %v", err)
+	}
+	if err := server.AddListener(listeners.NewTCP(listeners.Config{ID: "test", Address: address})); err != nil {
+		t.Fatalf("failed to add listener: %v", err)
+	}
+	go server.Serve()
+	t.Cleanup(func() { server.Close() })
+	return "tcp://" + address
+}
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := dbtest.Open(t)
+	for _, record := range []interface{}{
+		&cModel.Circle{ID: 1, Name: "Home"},
+		&uModel.User{ID: 1, Username: "alex", Email: "alex@example.com", CircleID: 1},
+		&chModel.Chore{ID: 5, Name: "Water the plants", CircleID: 1, CreatedBy: 1, IsActive: true},
+		&tModel.Thing{ID: 1, UserID: 1, Name: "Soil dryness", Type: "number", State: "0"},
+		&tModel.ThingChore{ThingID: 1, ChoreID: 5, TriggerState: "5", Condition: "gte"},
+		&chModel.Chore{ID: 6, Name: "Unload laundry", CircleID: 1, CreatedBy: 1, IsActive: true},
+		&tModel.Thing{ID: 2, UserID: 1, Name: "Washing machine done", Type: "boolean", State: "false"},
+		&tModel.ThingChore{ThingID: 2, ChoreID: 6, TriggerState: "true", Action: tModel.ThingActionComplete},
+	} {
+		if err := db.Create(record).Error; err != nil {
+			t.Fatalf("failed to create %T: %v", record, err)
+		}
+	}
+	return db
+}
+
+// choreActions records the actions the bridge runs.
+type choreActions struct {
+	mu        sync.Mutex
+	completed []int
+}
+
+func (a *choreActions) CompleteChoreByThing(_ context.Context, choreID int, thingID int) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.completed = append(a.completed, choreID)
+	return nil
+}
+
+func (a *choreActions) SkipChoreByThing(context.Context, int, int) error { return nil }
+
+func (a *choreActions) ResetSubtasksByThing(context.Context, int, int) error { return nil }
+
+func (a *choreActions) completedChores() []int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]int{}, a.completed...)
+}
+
+func TestBridge(t *testing.T) {
+	broker := startBroker(t)
+	db := newTestDB(t)
+	cfg := &config.Config{
+		WebhookConfig: config.WebhookConfig{Timeout: time.Second, QueueSize: 10},
+		MQTT:          config.MQTTConfig{Enabled: true, Broker: broker, ClientID: "donetick-test", TopicPrefix: "home"},
+	}
+	thingRepo := tRepo.NewThingRepository(db, cfg)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	producer := events.NewEventsProducer(cfg, eRepo.NewWebhookDeliveryRepository(db))
+	actions := &choreActions{}
+	bridge := NewBridge(cfg, thingRepo, chRepo.NewChoreRepository(db, cfg), producer, actions)
+	producer.Start(ctx)
+	if err := bridge.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer bridge.Stop()
+
+	device := paho.NewClient(paho.NewClientOptions().AddBroker(broker).SetClientID("device"))
+	if token := device.Connect(); !token.WaitTimeout(5*time.Second) || token.Error() != nil {
+		t.Fatalf("failed to connect test client: %v", token.Error())
+	}
+	defer device.Disconnect(100)
+	received := make(chan paho.Message, 10)
+	if token := device.Subscribe("home/#", 1, func(_ paho.Client, msg paho.Message) { received <- msg }); !token.WaitTimeout(5*time.Second) || token.Error() != nil {
+		t.Fatalf("failed to subscribe: %v", token.Error())
+	}
+	waitFor(t, "bridge to connect", func() bool { return bridge.client.IsConnectionOpen() })
+
+	// not a number, ignored
+	device.Publish("home/things/1/set", 1, false, "wet").WaitTimeout(5 * time.Second)
+	device.Publish("home/things/1/set", 1, false, "7").WaitTimeout(5 * time.Second)
+
+	var gotEvent, gotState bool
+	timeout := time.After(5 * time.Second)
+	for !gotEvent || !gotState {
+		select {
+		case msg := <-received:
+			switch msg.Topic() {
+			case "home/circles/1/events/thing.changed":
+				var event events.Event
+				if err := json.Unmarshal(msg.Payload(), &event); err != nil {
+					t.Fatalf("invalid event payload: %v", err)
+				}
+				data := event.Data.(map[string]interface{})
+				if data["from_state"] != "0" || data["to_state"] != "7" {
+					t.Errorf("event data = %v, want 0 -> 7", data)
+				}
+				gotEvent = true
+			case "home/things/1/state":
+				if string(msg.Payload()) != "7" {
+					t.Errorf("state = %s, want 7", msg.Payload())
+				}
+				gotState = true
+			}
+		case <-timeout:
+			t.Fatalf("timed out, got event %v, got state %v", gotEvent, gotState)
+		}
+	}
+
+	thing, err := thingRepo.GetThingByID(ctx, 1)
+	if err != nil || thing.State != "7" {
+		t.Fatalf("thing = %+v, %v, want state 7", thing, err)
+	}
+	var chore chModel.Chore
+	db.First(&chore, 5)
+	if chore.NextDueDate == nil {
+		t.Errorf("chore triggered by the thing has no due date")
+	}
+	var history int64
+	db.Model(&tModel.ThingHistory{}).Where("thing_id = ?", 1).Count(&history)
+	if history != 1 {
+		t.Errorf("thing has %d history records, want 1", history)
+	}
+
+	// the laundry is unloaded when the machine is done, a repeated message does not complete it again
+	device.Publish("home/things/2/set", 1, false, "true").WaitTimeout(5 * time.Second)
+	device.Publish("home/things/2/set", 1, false, "true").WaitTimeout(5 * time.Second)
+	waitFor(t, "both states to be stored", func() bool {
+		var count int64
+		db.Model(&tModel.ThingHistory{}).Where("thing_id = ?", 2).Count(&count)
+		return count == 2
+	})
+	if completed := actions.completedChores(); len(completed) != 1 || completed[0] != 6 {
+		t.Errorf("completed chores = %v, want [6]", completed)
+	}
+}
+
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
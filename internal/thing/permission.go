@@ -0,0 +1,62 @@
+package thing
+
+import (
+	"context"
+
+	tModel "donetick.com/core/internal/thing/model"
+	tRepo "donetick.com/core/internal/thing/repo"
+	uModel "donetick.com/core/internal/user/model"
+	"github.com/gin-gonic/gin"
+)
+
+// roleOf returns the role of a user on a thing: whoever created it owns it, members of its circle have the
+// role set for them or are editors, and everyone else has none.
+func roleOf(thing *tModel.Thing, userID int, circleID int, member *tModel.ThingMember) tModel.ThingRole {
+	if thing.UserID == userID {
+		return tModel.ThingRoleOwner
+	}
+	if thing.CircleID == 0 || thing.CircleID != circleID {
+		return tModel.ThingRoleNone
+	}
+	if member != nil {
+		return member.Role
+	}
+	return tModel.ThingRoleEditor
+}
+
+// UserThingRole returns the role of a user in a circle on a thing.
+func UserThingRole(c context.Context, tr *tRepo.ThingRepository, thing *tModel.Thing, userID int, circleID int) (tModel.ThingRole, error) {
+	if thing.UserID == userID || thing.CircleID == 0 || thing.CircleID != circleID {
+		return roleOf(thing, userID, circleID, nil), nil
+	}
+	member, err := tr.GetThingMember(c, thing.ID, userID)
+	if err != nil {
+		return tModel.ThingRoleNone, err
+	}
+	return roleOf(thing, userID, circleID, member), nil
+}
+
+// authorizeThing loads a thing and checks the user has at least the required role on it, it writes the error
+// response and returns false otherwise. Things the user has no role on are reported as not found.
+func authorizeThing(c *gin.Context, tr *tRepo.ThingRepository, user *uModel.User, thingID int, required tModel.ThingRole) (*tModel.Thing, bool) {
+	thing, err := tr.GetThingByID(c, thingID)
+	if err != nil {
+		c.JSON(404, gin.H{"error": "Unable to find thing"})
+		return nil, false
+	}
+	role, err := UserThingRole(c, tr, thing, user.ID, user.CircleID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Unable to check access to thing"})
+		return nil, false
+	}
+	if role == tModel.ThingRoleNone {
+		c.JSON(404, gin.H{"error": "Unable to find thing"})
+		return nil, false
+	}
+	if !role.Allows(required) {
+		c.JSON(403, gin.H{"error": "Forbidden"})
+		return nil, false
+	}
+	thing.Role = role
+	return thing, true
+}
@@ -0,0 +1,40 @@
+package thing
+
+import (
+	"testing"
+
+	tModel "donetick.com/core/internal/thing/model"
+)
+
+func TestRoleOf(t *testing.T) {
+	thing := &tModel.Thing{ID: 1, UserID: 1, CircleID: 1}
+	viewer := &tModel.ThingMember{ThingID: 1, UserID: 3, Role: tModel.ThingRoleViewer}
+	tests := []struct {
+		name     string
+		userID   int
+		circleID int
+		member   *tModel.ThingMember
+		want     tModel.ThingRole
+	}{
+		{"creator", 1, 1, nil, tModel.ThingRoleOwner},
+		{"creator in another circle", 1, 2, nil, tModel.ThingRoleOwner},
+		{"circle member", 2, 1, nil, tModel.ThingRoleEditor},
+		{"member with a role", 3, 1, viewer, tModel.ThingRoleViewer},
+		{"member who left the circle", 3, 2, viewer, tModel.ThingRoleNone},
+		{"outsider", 4, 2, nil, tModel.ThingRoleNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := roleOf(thing, tt.userID, tt.circleID, tt.member); got != tt.want {
+				t.Errorf("roleOf() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if tModel.ThingRoleViewer.Allows(tModel.ThingRoleEditor) || !tModel.ThingRoleOwner.Allows(tModel.ThingRoleEditor) {
+		t.Errorf("roles are not ordered viewer < editor < owner")
+	}
+	if tModel.ThingRoleNone.Allows(tModel.ThingRoleNone) {
+		t.Errorf("no role allows nothing")
+	}
+}
@@ -53,6 +53,24 @@ func (r *ThingRepository) GetThingByID(c context.Context, thingID int) (*tModel.
 	return &thing, nil
 }
 
+// GetThingCircle returns the circle a thing is shared in and the webhook URL of that circle, things without
+// one are in the circle of their owner.
+func (r *ThingRepository) GetThingCircle(c context.Context, thingID int) (int, *string, error) {
+	var row struct {
+		CircleID   int
+		WebhookURL *string
+	}
+	if err := r.db.WithContext(c).Table("things t").
+		Select("COALESCE(NULLIF(t.circle_id, 0), u.circle_id) as circle_id, c.webhook_url as webhook_url").
+		Joins("join users u on u.id = t.user_id").
+		Joins("left join circles c on c.id = COALESCE(NULLIF(t.circle_id, 0), u.circle_id)").
+		Where("t.id = ?", thingID).
+		Take(&row).Error; err != nil {
+		return 0, nil, err
+	}
+	return row.CircleID, row.WebhookURL, nil
+}
+
 func (r *ThingRepository) GetThingByChoreID(c context.Context, choreID int) (*tModel.Thing, error) {
 	var thing tModel.Thing
 	if err := r.db.WithContext(c).Model(&tModel.Thing{}).Joins("left join thing_chores on things.id = thing_chores.thing_id").First(&thing, "thing_chores.chore_id = ?", choreID).Error; err != nil {
@@ -61,9 +79,28 @@ func (r *ThingRepository) GetThingByChoreID(c context.Context, choreID int) (*tM
 	return &thing, nil
 }
 
-func (r *ThingRepository) AssociateThingWithChore(c context.Context, thingID int, choreID int, triggerState string, condition string) error {
+func (r *ThingRepository) AssociateThingWithChore(c context.Context, thingID int, choreID int, triggerState string, condition string, action tModel.ThingAction) error {
 
-	return r.db.WithContext(c).Save(&tModel.ThingChore{ThingID: thingID, ChoreID: choreID, TriggerState: triggerState, Condition: condition}).Error
+	return r.db.WithContext(c).Save(&tModel.ThingChore{ThingID: thingID, ChoreID: choreID, TriggerState: triggerState, Condition: condition, Action: action}).Error
+}
+
+// AssociateThingRuleWithChore links a chore to every thing of its rule, thingID is the thing the chore is shown
+// with and the others are linked so a change of any of them evaluates the rule.
+func (r *ThingRepository) AssociateThingRuleWithChore(c context.Context, thingID int, choreID int, rule *tModel.ThingRule, action tModel.ThingAction) error {
+	return r.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("chore_id = ?", choreID).Delete(&tModel.ThingChore{}).Error; err != nil {
+			return err
+		}
+		for _, ruleThingID := range rule.ThingIDs() {
+			if ruleThingID == thingID {
+				continue
+			}
+			if err := tx.Create(&tModel.ThingChore{ThingID: ruleThingID, ChoreID: choreID, Condition: tModel.ThingChoreConditionRuleRef, Rule: rule, Action: action}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(&tModel.ThingChore{ThingID: thingID, ChoreID: choreID, Rule: rule, Action: action}).Error
+	})
 }
 
 func (r *ThingRepository) DissociateThingWithChore(c context.Context, thingID int, choreID int) error {
@@ -82,31 +119,114 @@ func (r *ThingRepository) GetThingHistoryWithOffset(c context.Context, thingID i
 	return thingHistory, nil
 }
 
-func (r *ThingRepository) GetUserThings(c context.Context, userID int) ([]*tModel.Thing, error) {
+// GetThingHistorySince returns the changes of a thing since a time, oldest first, starting with the last
+// change before it so the state at that time is known. At most limit of the latest changes are returned.
+func (r *ThingRepository) GetThingHistorySince(c context.Context, thingID int, since time.Time, limit int) ([]*tModel.ThingHistory, error) {
+	var history []*tModel.ThingHistory
+	if err := r.db.WithContext(c).Where("thing_id = ? AND created_at >= ?", thingID, since).Order("created_at desc").Limit(limit).Find(&history).Error; err != nil {
+		return nil, err
+	}
+	var before []*tModel.ThingHistory
+	if err := r.db.WithContext(c).Where("thing_id = ? AND created_at < ?", thingID, since).Order("created_at desc").Limit(1).Find(&before).Error; err != nil {
+		return nil, err
+	}
+	history = append(history, before...)
+	for i, j := 0, len(history)-1; i < j; i, j = i+1, j-1 {
+		history[i], history[j] = history[j], history[i]
+	}
+	return history, nil
+}
+
+// GetIdleRuleTriggers returns the rules of active chores that can still act, the ones scheduling chores
+// without a due date and the ones with another action. Their rules are evaluated again over time as held
+// conditions can start to match without a thing changing.
+func (r *ThingRepository) GetIdleRuleTriggers(c context.Context) ([]*tModel.ThingChore, error) {
+	var thingChores []*tModel.ThingChore
+	if err := r.db.WithContext(c).Model(&tModel.ThingChore{}).
+		Joins("join chores on chores.id = thing_chores.chore_id").
+		Where("thing_chores.rule IS NOT NULL AND (thing_chores.condition IS NULL OR thing_chores.condition <> ?)", tModel.ThingChoreConditionRuleRef).
+		Where("chores.is_active = ? AND chores.deleted_at IS NULL", true).
+		Where("chores.next_due_date IS NULL OR (thing_chores.action IS NOT NULL AND thing_chores.action <> ?)", tModel.ThingActionSchedule).
+		Find(&thingChores).Error; err != nil {
+		return nil, err
+	}
+	return thingChores, nil
+}
+
+// GetCircleThings returns the things shared in a circle and the things the user created in other circles.
+func (r *ThingRepository) GetCircleThings(c context.Context, circleID int, userID int) ([]*tModel.Thing, error) {
 	var things []*tModel.Thing
-	if err := r.db.WithContext(c).Model(&tModel.Thing{}).Where("user_id = ?", userID).Find(&things).Error; err != nil {
+	if err := r.db.WithContext(c).Model(&tModel.Thing{}).Where("circle_id = ? OR user_id = ?", circleID, userID).Find(&things).Error; err != nil {
 		return nil, err
 	}
 	return things, nil
 }
 
+// GetThingMember returns the role set for a user on a thing, nil when none is set.
+func (r *ThingRepository) GetThingMember(c context.Context, thingID int, userID int) (*tModel.ThingMember, error) {
+	var member tModel.ThingMember
+	err := r.db.WithContext(c).Where("thing_id = ? AND user_id = ?", thingID, userID).Take(&member).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (r *ThingRepository) GetThingMembers(c context.Context, thingID int) ([]*tModel.ThingMember, error) {
+	members := []*tModel.ThingMember{}
+	if err := r.db.WithContext(c).Where("thing_id = ?", thingID).Find(&members).Error; err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// GetUserThingMembers returns the roles set for a user on things.
+func (r *ThingRepository) GetUserThingMembers(c context.Context, userID int) ([]*tModel.ThingMember, error) {
+	var members []*tModel.ThingMember
+	if err := r.db.WithContext(c).Where("user_id = ?", userID).Find(&members).Error; err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+func (r *ThingRepository) SetThingMember(c context.Context, member *tModel.ThingMember) error {
+	now := time.Now().UTC()
+	member.UpdatedAt = &now
+	return r.db.WithContext(c).Save(member).Error
+}
+
+func (r *ThingRepository) DeleteThingMember(c context.Context, thingID int, userID int) error {
+	return r.db.WithContext(c).Where("thing_id = ? AND user_id = ?", thingID, userID).Delete(&tModel.ThingMember{}).Error
+}
+
 func (r *ThingRepository) DeleteThing(c context.Context, thingID int) error {
 	//  one transaction to delete the thing and its history :
 	return r.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
-		if err := r.db.WithContext(c).Where("thing_id = ?", thingID).Delete(&tModel.ThingHistory{}).Error; err != nil {
+		if err := tx.Where("thing_id = ?", thingID).Delete(&tModel.ThingHistory{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("thing_id = ?", thingID).Delete(&tModel.ThingHistoryBucket{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("thing_id = ?", thingID).Delete(&tModel.ThingMember{}).Error; err != nil {
 			return err
 		}
-		if err := r.db.WithContext(c).Delete(&tModel.Thing{}, thingID).Error; err != nil {
+		if err := tx.Delete(&tModel.Thing{}, thingID).Error; err != nil {
 			return err
 		}
 		return nil
 	})
 }
 
-// get ThingChores by thingID:
+// get ThingChores by thingID, chores in the trash keep their triggers but are not triggered:
 func (r *ThingRepository) GetThingChoresByThingId(c context.Context, thingID int) ([]*tModel.ThingChore, error) {
 	var thingChores []*tModel.ThingChore
-	if err := r.db.WithContext(c).Model(&tModel.ThingChore{}).Where("thing_id = ?", thingID).Find(&thingChores).Error; err != nil {
+	if err := r.db.WithContext(c).Model(&tModel.ThingChore{}).
+		Joins("JOIN chores ON chores.id = thing_chores.chore_id AND chores.deleted_at IS NULL").
+		Where("thing_chores.thing_id = ?", thingID).Find(&thingChores).Error; err != nil {
 		return nil, err
 	}
 	return thingChores, nil
@@ -119,3 +239,128 @@ func (r *ThingRepository) GetThingChoresByThingId(c context.Context, thingID int
 // 	}
 // 	return chores, nil
 // }
+
+// GetThingHistoryBetween returns the changes of a thing in a period, oldest first.
+func (r *ThingRepository) GetThingHistoryBetween(c context.Context, thingID int, from time.Time, to time.Time) ([]*tModel.ThingHistory, error) {
+	var history []*tModel.ThingHistory
+	if err := r.db.WithContext(c).Select("id", "thing_id", "state", "created_at").
+		Where("thing_id = ? AND created_at >= ? AND created_at < ?", thingID, from, to).
+		Order("created_at asc").Find(&history).Error; err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+func (r *ThingRepository) GetThingHistoryBuckets(c context.Context, thingID int, resolution tModel.TimeResolution, from time.Time, to time.Time) ([]*tModel.ThingHistoryBucket, error) {
+	var buckets []*tModel.ThingHistoryBucket
+	if err := r.db.WithContext(c).Where("thing_id = ? AND resolution = ? AND start >= ? AND start < ?", thingID, resolution, from, to).
+		Order("start asc").Find(&buckets).Error; err != nil {
+		return nil, err
+	}
+	return buckets, nil
+}
+
+// GetThingsWithHistoryBefore returns the things with changes older than a time.
+func (r *ThingRepository) GetThingsWithHistoryBefore(c context.Context, before time.Time) ([]*tModel.Thing, error) {
+	var things []*tModel.Thing
+	if err := r.db.WithContext(c).Model(&tModel.Thing{}).
+		Where("id IN (?)", r.db.Model(&tModel.ThingHistory{}).Select("thing_id").Where("created_at < ?", before)).
+		Find(&things).Error; err != nil {
+		return nil, err
+	}
+	return things, nil
+}
+
+// GetThingsWithBucketsBefore returns the things with buckets of a resolution older than a time.
+func (r *ThingRepository) GetThingsWithBucketsBefore(c context.Context, resolution tModel.TimeResolution, before time.Time) ([]int, error) {
+	var thingIDs []int
+	if err := r.db.WithContext(c).Model(&tModel.ThingHistoryBucket{}).Distinct("thing_id").
+		Where("resolution = ? AND start < ?", resolution, before).
+		Pluck("thing_id", &thingIDs).Error; err != nil {
+		return nil, err
+	}
+	return thingIDs, nil
+}
+
+// GetExpiredThingHistory returns up to limit of the oldest changes of a thing before a time. The last change
+// before it is left out, it is the state of the thing at that time.
+func (r *ThingRepository) GetExpiredThingHistory(c context.Context, thingID int, before time.Time, limit int) ([]*tModel.ThingHistory, error) {
+	var last tModel.ThingHistory
+	err := r.db.WithContext(c).Where("thing_id = ? AND created_at < ?", thingID, before).Order("created_at desc, id desc").Take(&last).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var history []*tModel.ThingHistory
+	if err := r.db.WithContext(c).Where("thing_id = ? AND created_at < ? AND id <> ?", thingID, before, last.ID).
+		Order("created_at asc").Limit(limit).Find(&history).Error; err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// ReplaceThingHistory deletes changes of a thing and adds the buckets they were summarized into, to the
+// buckets already there for the same periods.
+func (r *ThingRepository) ReplaceThingHistory(c context.Context, thingID int, historyIDs []int, buckets []*tModel.ThingHistoryBucket) error {
+	return r.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := mergeBuckets(tx, buckets); err != nil {
+			return err
+		}
+		if len(historyIDs) == 0 {
+			return nil
+		}
+		return tx.Where("thing_id = ? AND id IN ?", thingID, historyIDs).Delete(&tModel.ThingHistory{}).Error
+	})
+}
+
+// RollUpThingHistoryBuckets replaces the buckets of a thing with a resolution before a time with the
+// buckets they were summarized into.
+func (r *ThingRepository) RollUpThingHistoryBuckets(c context.Context, thingID int, resolution tModel.TimeResolution, before time.Time, buckets []*tModel.ThingHistoryBucket) error {
+	return r.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := mergeBuckets(tx, buckets); err != nil {
+			return err
+		}
+		return tx.Where("thing_id = ? AND resolution = ? AND start < ?", thingID, resolution, before).Delete(&tModel.ThingHistoryBucket{}).Error
+	})
+}
+
+func (r *ThingRepository) DeleteThingHistoryBucketsBefore(c context.Context, resolution tModel.TimeResolution, before time.Time) error {
+	return r.db.WithContext(c).Where("resolution = ? AND start < ?", resolution, before).Delete(&tModel.ThingHistoryBucket{}).Error
+}
+
+// DeleteThingHistoryBefore deletes the changes of a thing before a time but the last one, it is the state
+// of the thing at that time.
+func (r *ThingRepository) DeleteThingHistoryBefore(c context.Context, thingID int, before time.Time) error {
+	var last tModel.ThingHistory
+	err := r.db.WithContext(c).Where("thing_id = ? AND created_at < ?", thingID, before).Order("created_at desc, id desc").Take(&last).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return r.db.WithContext(c).Where("thing_id = ? AND created_at < ? AND id <> ?", thingID, before, last.ID).Delete(&tModel.ThingHistory{}).Error
+}
+
+func mergeBuckets(tx *gorm.DB, buckets []*tModel.ThingHistoryBucket) error {
+	for _, bucket := range buckets {
+		var existing tModel.ThingHistoryBucket
+		err := tx.Where("thing_id = ? AND resolution = ? AND start = ?", bucket.ThingID, bucket.Resolution, bucket.Start).Take(&existing).Error
+		if err == gorm.ErrRecordNotFound {
+			if err := tx.Create(bucket).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		existing.Merge(bucket)
+		if err := tx.Save(&existing).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
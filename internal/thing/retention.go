@@ -0,0 +1,211 @@
+package thing
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"donetick.com/core/config"
+	tModel "donetick.com/core/internal/thing/model"
+	tRepo "donetick.com/core/internal/thing/repo"
+	"donetick.com/core/logging"
+)
+
+const (
+	defaultRetentionInterval = time.Hour
+	// changes summarized and deleted per query
+	retentionBatchSize = 5000
+)
+
+// HistoryRetention downsamples the history of things once it is older than the raw retention and
+// deletes what is older than the retention of each resolution.
+type HistoryRetention struct {
+	cfg       config.ThingHistoryConfig
+	thingRepo *tRepo.ThingRepository
+	ticker    *time.Ticker
+	done      chan bool
+}
+
+func NewHistoryRetention(cfg *config.Config, tr *tRepo.ThingRepository) *HistoryRetention {
+	interval := cfg.ThingHistory.Interval
+	if interval <= 0 {
+		interval = defaultRetentionInterval
+	}
+	return &HistoryRetention{
+		cfg:       cfg.ThingHistory,
+		thingRepo: tr,
+		ticker:    time.NewTicker(interval),
+		done:      make(chan bool),
+	}
+}
+
+func (s *HistoryRetention) Start(ctx context.Context) {
+	logger := logging.FromContext(ctx)
+
+	go func() {
+		for {
+			select {
+			case <-s.done:
+				return
+			case <-s.ticker.C:
+				if err := s.Run(ctx, time.Now().UTC()); err != nil {
+					logger.Errorw("Failed to apply thing history retention", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+func (s *HistoryRetention) Stop() {
+	s.ticker.Stop()
+	s.done <- true
+}
+
+// Run summarizes changes older than the raw retention into hourly buckets, hourly buckets older than the
+// hourly retention into daily ones, and deletes daily buckets older than the daily retention. Only the
+// states of number things are summarized, the history of other things is deleted.
+func (s *HistoryRetention) Run(ctx context.Context, now time.Time) error {
+	if s.cfg.RawRetention > 0 {
+		if err := s.downsampleHistory(ctx, tModel.ResolutionHour.Truncate(now.Add(-s.cfg.RawRetention))); err != nil {
+			return err
+		}
+	}
+	if s.cfg.HourlyRetention > 0 {
+		if err := s.rollUpHourly(ctx, tModel.ResolutionDay.Truncate(now.Add(-s.cfg.HourlyRetention))); err != nil {
+			return err
+		}
+	}
+	if s.cfg.DailyRetention > 0 {
+		return s.thingRepo.DeleteThingHistoryBucketsBefore(ctx, tModel.ResolutionDay, tModel.ResolutionDay.Truncate(now.Add(-s.cfg.DailyRetention)))
+	}
+	return nil
+}
+
+func (s *HistoryRetention) downsampleHistory(ctx context.Context, before time.Time) error {
+	things, err := s.thingRepo.GetThingsWithHistoryBefore(ctx, before)
+	if err != nil {
+		return err
+	}
+	for _, thing := range things {
+		if thing.Type != "number" {
+			if err := s.thingRepo.DeleteThingHistoryBefore(ctx, thing.ID, before); err != nil {
+				return err
+			}
+			continue
+		}
+		for {
+			history, err := s.thingRepo.GetExpiredThingHistory(ctx, thing.ID, before, retentionBatchSize)
+			if err != nil {
+				return err
+			}
+			if len(history) == 0 {
+				break
+			}
+			historyIDs := make([]int, 0, len(history))
+			for _, h := range history {
+				historyIDs = append(historyIDs, h.ID)
+			}
+			if err := s.thingRepo.ReplaceThingHistory(ctx, thing.ID, historyIDs, summarizeHistory(thing.ID, history, tModel.ResolutionHour)); err != nil {
+				return err
+			}
+			if len(history) < retentionBatchSize {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func (s *HistoryRetention) rollUpHourly(ctx context.Context, before time.Time) error {
+	thingIDs, err := s.thingRepo.GetThingsWithBucketsBefore(ctx, tModel.ResolutionHour, before)
+	if err != nil {
+		return err
+	}
+	for _, thingID := range thingIDs {
+		hourly, err := s.thingRepo.GetThingHistoryBuckets(ctx, thingID, tModel.ResolutionHour, time.Time{}, before)
+		if err != nil {
+			return err
+		}
+		if err := s.thingRepo.RollUpThingHistoryBuckets(ctx, thingID, tModel.ResolutionHour, before, rollUpBuckets(hourly, tModel.ResolutionDay)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// summarizeHistory returns the buckets of a resolution the numeric changes fall in, oldest first. Changes
+// that are not numbers are left out.
+func summarizeHistory(thingID int, history []*tModel.ThingHistory, resolution tModel.TimeResolution) []*tModel.ThingHistoryBucket {
+	buckets := map[time.Time]*tModel.ThingHistoryBucket{}
+	for _, h := range history {
+		value, err := strconv.ParseFloat(h.State, 64)
+		if err != nil || h.CreatedAt == nil {
+			continue
+		}
+		start := resolution.Truncate(*h.CreatedAt)
+		bucket, ok := buckets[start]
+		if !ok {
+			bucket = &tModel.ThingHistoryBucket{ThingID: thingID, Resolution: resolution, Start: start}
+			buckets[start] = bucket
+		}
+		bucket.Add(value)
+	}
+	return sortedBuckets(buckets)
+}
+
+// rollUpBuckets summarizes buckets into buckets of a coarser resolution, oldest first.
+func rollUpBuckets(buckets []*tModel.ThingHistoryBucket, resolution tModel.TimeResolution) []*tModel.ThingHistoryBucket {
+	rolledUp := map[time.Time]*tModel.ThingHistoryBucket{}
+	for _, b := range buckets {
+		start := resolution.Truncate(b.Start)
+		bucket, ok := rolledUp[start]
+		if !ok {
+			bucket = &tModel.ThingHistoryBucket{ThingID: b.ThingID, Resolution: resolution, Start: start}
+			rolledUp[start] = bucket
+		}
+		bucket.Merge(b)
+	}
+	return sortedBuckets(rolledUp)
+}
+
+func sortedBuckets(buckets map[time.Time]*tModel.ThingHistoryBucket) []*tModel.ThingHistoryBucket {
+	sorted := make([]*tModel.ThingHistoryBucket, 0, len(buckets))
+	for _, bucket := range buckets {
+		sorted = append(sorted, bucket)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start.Before(sorted[j].Start) })
+	return sorted
+}
+
+// HistoryPoint is the min, max and average of the numeric states of a thing over an hour or a day.
+type HistoryPoint struct {
+	Start time.Time `json:"start"`
+	Min   float64   `json:"min"`
+	Max   float64   `json:"max"`
+	Avg   float64   `json:"avg"`
+	Count int       `json:"count"` // states the point is summarized from
+}
+
+// aggregateHistory returns the points of a resolution from the raw changes of a thing and the buckets
+// older changes were summarized into. Daily buckets are only used for daily points.
+func aggregateHistory(thingID int, resolution tModel.TimeResolution, history []*tModel.ThingHistory, buckets []*tModel.ThingHistoryBucket) []HistoryPoint {
+	all := summarizeHistory(thingID, history, tModel.ResolutionHour)
+	for _, bucket := range buckets {
+		if bucket.Resolution == tModel.ResolutionDay && resolution != tModel.ResolutionDay {
+			continue
+		}
+		all = append(all, bucket)
+	}
+	points := []HistoryPoint{}
+	for _, bucket := range rollUpBuckets(all, resolution) {
+		points = append(points, HistoryPoint{
+			Start: bucket.Start,
+			Min:   bucket.Min,
+			Max:   bucket.Max,
+			Avg:   bucket.Avg(),
+			Count: bucket.Count,
+		})
+	}
+	return points
+}
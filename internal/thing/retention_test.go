@@ -0,0 +1,103 @@
+package thing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"donetick.com/core/config"
+	"donetick.com/core/internal/database/dbtest"
+	tModel "donetick.com/core/internal/thing/model"
+	tRepo "donetick.com/core/internal/thing/repo"
+)
+
+func TestAggregateHistory(t *testing.T) {
+	// 08:00 19.5, 08:30 23.5, 09:00 21.0, 09:40 not a number
+	raw := history(0, "19.5", 30, "23.5", 60, "21.0", 100, "unavailable")
+	older := []*tModel.ThingHistoryBucket{
+		{Resolution: tModel.ResolutionHour, Start: at(-60), Min: 18, Max: 20, Sum: 57, Count: 3},
+		{Resolution: tModel.ResolutionDay, Start: ruleStart.AddDate(0, 0, -1), Min: 15, Max: 25, Sum: 200, Count: 10},
+	}
+
+	hourly := aggregateHistory(1, tModel.ResolutionHour, raw, older)
+	if len(hourly) != 3 {
+		t.Fatalf("aggregateHistory(hour) = %+v, want 3 points", hourly)
+	}
+	if !hourly[0].Start.Equal(at(-60)) || hourly[0].Avg != 19 {
+		t.Errorf("first hour = %+v, want the hourly bucket", hourly[0])
+	}
+	if got := hourly[1]; !got.Start.Equal(at(0)) || got.Min != 19.5 || got.Max != 23.5 || got.Count != 2 || got.Avg != 21.5 {
+		t.Errorf("second hour = %+v, want 19.5..23.5 avg 21.5 of 2", got)
+	}
+	if got := hourly[2]; got.Count != 1 || got.Avg != 21 {
+		t.Errorf("third hour = %+v, want only the number", got)
+	}
+
+	daily := aggregateHistory(1, tModel.ResolutionDay, raw, older)
+	if len(daily) != 2 {
+		t.Fatalf("aggregateHistory(day) = %+v, want 2 points", daily)
+	}
+	if got := daily[1]; got.Min != 18 || got.Max != 23.5 || got.Count != 6 {
+		t.Errorf("day = %+v, want 18..23.5 of 6", got)
+	}
+}
+
+func TestHistoryRetention(t *testing.T) {
+	db := dbtest.Open(t)
+	now := time.Date(2025, 6, 10, 12, 20, 0, 0, time.UTC)
+	db.Create(&tModel.Thing{ID: 1, Name: "Temperature", Type: "number", State: "22"})
+	db.Create(&tModel.Thing{ID: 2, Name: "Door", Type: "boolean", State: "false"})
+	for i, state := range []string{"20", "24", "22", "21"} {
+		// two states three days ago, one two days ago and one an hour ago
+		createdAt := now.Add(-72 * time.Hour).Add(time.Duration(i) * 10 * time.Minute)
+		if i == 2 {
+			createdAt = now.Add(-48 * time.Hour)
+		}
+		if i == 3 {
+			createdAt = now.Add(-time.Hour)
+		}
+		db.Create(&tModel.ThingHistory{ThingID: 1, State: state, CreatedAt: &createdAt})
+		db.Create(&tModel.ThingHistory{ThingID: 2, State: "true", CreatedAt: &createdAt})
+	}
+
+	cfg := &config.Config{ThingHistory: config.ThingHistoryConfig{RawRetention: 24 * time.Hour, HourlyRetention: 36 * time.Hour}}
+	retention := NewHistoryRetention(cfg, tRepo.NewThingRepository(db, cfg))
+	defer retention.ticker.Stop()
+	if err := retention.Run(context.Background(), now); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	// the state two days ago is kept as the state at the cutoff
+	var raw []tModel.ThingHistory
+	db.Where("thing_id = ?", 1).Order("created_at").Find(&raw)
+	if len(raw) != 2 || raw[0].State != "22" || raw[1].State != "21" {
+		t.Errorf("raw history = %+v, want 22 and 21", raw)
+	}
+	var door int64
+	db.Model(&tModel.ThingHistory{}).Where("thing_id = ?", 2).Count(&door)
+	if door != 2 {
+		t.Errorf("door has %d history records, want 2", door)
+	}
+
+	// the first two states were summarized into an hour, and that hour into a day
+	var buckets []tModel.ThingHistoryBucket
+	db.Order("start").Find(&buckets)
+	if len(buckets) != 1 {
+		t.Fatalf("buckets = %+v, want one day", buckets)
+	}
+	if b := buckets[0]; b.Resolution != tModel.ResolutionDay || !b.Start.Equal(time.Date(2025, 6, 7, 0, 0, 0, 0, time.UTC)) ||
+		b.Min != 20 || b.Max != 24 || b.Count != 2 || b.Avg() != 22 {
+		t.Errorf("bucket = %+v, want 20..24 on June 7", b)
+	}
+
+	// running again changes nothing
+	if err := retention.Run(context.Background(), now); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	var count int64
+	db.Model(&tModel.ThingHistoryBucket{}).Count(&count)
+	db.Find(&buckets)
+	if count != 1 || buckets[0].Count != 2 {
+		t.Errorf("buckets after a second run = %+v, want the same day", buckets)
+	}
+}
@@ -0,0 +1,410 @@
+package thing
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	chModel "donetick.com/core/internal/chore/model"
+	chRepo "donetick.com/core/internal/chore/repo"
+	tModel "donetick.com/core/internal/thing/model"
+	tRepo "donetick.com/core/internal/thing/repo"
+)
+
+const (
+	maxRuleDepth      = 4
+	maxRuleConditions = 16
+	// history loaded per thing to replay a rule, older changes are not looked at
+	maxRuleHistory = 5000
+)
+
+// compareState compares a state with a target, numbers are compared as floats and eq/neq fall back
+// to comparing text. Unknown conditions compare for equality.
+func compareState(condition string, state string, target string) bool {
+	stateNum, stateErr := strconv.ParseFloat(state, 64)
+	targetNum, targetErr := strconv.ParseFloat(target, 64)
+	isNumber := stateErr == nil && targetErr == nil
+
+	switch condition {
+	case "neq":
+		if isNumber {
+			return stateNum != targetNum
+		}
+		return state != target
+	case "gt", "gte", "lt", "lte":
+		if !isNumber {
+			return false
+		}
+		switch condition {
+		case "gt":
+			return stateNum > targetNum
+		case "gte":
+			return stateNum >= targetNum
+		case "lt":
+			return stateNum < targetNum
+		default:
+			return stateNum <= targetNum
+		}
+	default:
+		if isNumber {
+			return stateNum == targetNum
+		}
+		return state == target
+	}
+}
+
+// ValidateRule checks the structure of a rule, the things it refers to are checked by the caller.
+func ValidateRule(rule *tModel.ThingRule) error {
+	conditions := 0
+	if err := validateRule(rule, 1, &conditions); err != nil {
+		return err
+	}
+	if conditions > maxRuleConditions {
+		return fmt.Errorf("a rule can have at most %d conditions", maxRuleConditions)
+	}
+	return nil
+}
+
+func validateRule(rule *tModel.ThingRule, depth int, conditions *int) error {
+	if depth > maxRuleDepth {
+		return fmt.Errorf("rules can be nested at most %d levels deep", maxRuleDepth)
+	}
+	switch rule.Op {
+	case tModel.RuleOpAnd, tModel.RuleOpOr:
+		if len(rule.Rules) == 0 {
+			return fmt.Errorf("%s rule without rules", rule.Op)
+		}
+		for i := range rule.Rules {
+			if err := validateRule(&rule.Rules[i], depth+1, conditions); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "":
+	default:
+		return fmt.Errorf("unknown rule op %s", rule.Op)
+	}
+
+	*conditions++
+	if rule.ThingID == 0 {
+		return fmt.Errorf("condition without a thing")
+	}
+	if rule.ForSeconds < 0 {
+		return fmt.Errorf("forSeconds can not be negative")
+	}
+	switch rule.Condition {
+	case "eq", "neq":
+	case "gt", "gte", "lt", "lte":
+		if _, err := strconv.ParseFloat(rule.Target, 64); err != nil {
+			return fmt.Errorf("%s needs a numeric value", rule.Condition)
+		}
+	case "between":
+		if rule.Min == nil || rule.Max == nil || *rule.Min > *rule.Max {
+			return fmt.Errorf("between needs a min that is not above its max")
+		}
+	case "changed_by":
+		change, err := strconv.ParseFloat(rule.Target, 64)
+		if err != nil || change < 0 {
+			return fmt.Errorf("changed_by needs a positive numeric value")
+		}
+		if rule.ForSeconds != 0 {
+			return fmt.Errorf("changed_by can not be combined with forSeconds")
+		}
+	default:
+		return fmt.Errorf("unknown condition %s", rule.Condition)
+	}
+	return nil
+}
+
+// ruleState is what a rule is evaluated against: the things of the rule with their history, oldest first,
+// and the completions of the chore.
+type ruleState struct {
+	things      map[int]*tModel.Thing
+	history     map[int][]*tModel.ThingHistory
+	completions []time.Time
+}
+
+// stateAt returns the state a thing had at a time.
+func (s *ruleState) stateAt(thingID int, at time.Time) (string, bool) {
+	history := s.history[thingID]
+	if len(history) == 0 {
+		// never changed, it has the state it was created with
+		thing, ok := s.things[thingID]
+		if !ok {
+			return "", false
+		}
+		return thing.State, thing.CreatedAt == nil || !thing.CreatedAt.After(at)
+	}
+	i := sort.Search(len(history), func(i int) bool { return history[i].CreatedAt.After(at) })
+	if i == 0 {
+		return "", false
+	}
+	return history[i-1].State, true
+}
+
+// heldSince returns since when a leaf matched without interruption at a time.
+func (s *ruleState) heldSince(leaf *tModel.ThingRule, at time.Time) (time.Time, bool) {
+	history := s.history[leaf.ThingID]
+	if len(history) == 0 {
+		thing, ok := s.things[leaf.ThingID]
+		if !ok || thing.CreatedAt == nil || thing.CreatedAt.After(at) || !s.matches(leaf, thing.State, at) {
+			return time.Time{}, false
+		}
+		return *thing.CreatedAt, true
+	}
+	i := sort.Search(len(history), func(i int) bool { return history[i].CreatedAt.After(at) })
+	if i == 0 || !s.matches(leaf, history[i-1].State, at) {
+		return time.Time{}, false
+	}
+	since := *history[i-1].CreatedAt
+	for j := i - 2; j >= 0 && s.matches(leaf, history[j].State, at); j-- {
+		since = *history[j].CreatedAt
+	}
+	return since, true
+}
+
+// lastChange returns the time of the latest change of any thing of the rule.
+func (s *ruleState) lastChange() (time.Time, bool) {
+	var last time.Time
+	for _, history := range s.history {
+		if len(history) > 0 && history[len(history)-1].CreatedAt.After(last) {
+			last = *history[len(history)-1].CreatedAt
+		}
+	}
+	return last, !last.IsZero()
+}
+
+// lastCompletion returns the last completion of the chore up to a time.
+func (s *ruleState) lastCompletion(at time.Time) (time.Time, bool) {
+	i := sort.Search(len(s.completions), func(i int) bool { return s.completions[i].After(at) })
+	if i == 0 {
+		return time.Time{}, false
+	}
+	return s.completions[i-1], true
+}
+
+func (s *ruleState) matches(leaf *tModel.ThingRule, state string, at time.Time) bool {
+	switch leaf.Condition {
+	case "between":
+		value, err := strconv.ParseFloat(state, 64)
+		return err == nil && value >= *leaf.Min && value <= *leaf.Max
+	case "changed_by":
+		// changed since the last completion, before the first completion since the first known state
+		var baseline string
+		var ok bool
+		if completedAt, completed := s.lastCompletion(at); completed {
+			baseline, ok = s.stateAt(leaf.ThingID, completedAt)
+		} else if history := s.history[leaf.ThingID]; len(history) > 0 {
+			baseline, ok = history[0].State, true
+		}
+		if !ok {
+			return false
+		}
+		from, errFrom := strconv.ParseFloat(baseline, 64)
+		to, errTo := strconv.ParseFloat(state, 64)
+		change, errChange := strconv.ParseFloat(leaf.Target, 64)
+		return errFrom == nil && errTo == nil && errChange == nil && math.Abs(to-from) > change
+	default:
+		return compareState(leaf.Condition, state, leaf.Target)
+	}
+}
+
+// evaluate tells if the rule matched at a time.
+func (s *ruleState) evaluate(rule *tModel.ThingRule, at time.Time) bool {
+	switch rule.Op {
+	case tModel.RuleOpAnd:
+		for i := range rule.Rules {
+			if !s.evaluate(&rule.Rules[i], at) {
+				return false
+			}
+		}
+		return true
+	case tModel.RuleOpOr:
+		for i := range rule.Rules {
+			if s.evaluate(&rule.Rules[i], at) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if rule.ForSeconds > 0 {
+		since, ok := s.heldSince(rule, at)
+		return ok && !since.Add(time.Duration(rule.ForSeconds)*time.Second).After(at)
+	}
+	state, ok := s.stateAt(rule.ThingID, at)
+	return ok && s.matches(rule, state, at)
+}
+
+// RuleEvaluator loads what rules are evaluated against.
+type RuleEvaluator struct {
+	thingRepo *tRepo.ThingRepository
+	choreRepo *chRepo.ChoreRepository
+}
+
+func NewRuleEvaluator(tr *tRepo.ThingRepository, cr *chRepo.ChoreRepository) *RuleEvaluator {
+	return &RuleEvaluator{thingRepo: tr, choreRepo: cr}
+}
+
+// load reads the things of the rule with their history since a time and the completions of the chore,
+// choreID is 0 for a rule that is not saved yet.
+func (e *RuleEvaluator) load(c context.Context, rule *tModel.ThingRule, choreID int, since time.Time) (*ruleState, error) {
+	state := &ruleState{things: map[int]*tModel.Thing{}, history: map[int][]*tModel.ThingHistory{}}
+	usesChange := false
+	var maxHold time.Duration
+	rule.Walk(func(leaf *tModel.ThingRule) {
+		if leaf.Condition == "changed_by" {
+			usesChange = true
+		}
+		if hold := time.Duration(leaf.ForSeconds) * time.Second; hold > maxHold {
+			maxHold = hold
+		}
+	})
+	since = since.Add(-maxHold)
+
+	if choreID != 0 {
+		history, err := e.choreRepo.GetChoreHistory(c, choreID)
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range history {
+			if h.Status == chModel.ChoreHistoryStatusCompleted && h.PerformedAt != nil {
+				state.completions = append(state.completions, *h.PerformedAt)
+			}
+		}
+		sort.Slice(state.completions, func(i, j int) bool { return state.completions[i].Before(state.completions[j]) })
+	}
+	if usesChange {
+		// the state at the last completion, or the first state, is the baseline
+		if completedAt, ok := state.lastCompletion(since); ok {
+			since = completedAt
+		} else {
+			since = time.Time{}
+		}
+	}
+
+	for _, thingID := range rule.ThingIDs() {
+		thing, err := e.thingRepo.GetThingByID(c, thingID)
+		if err != nil {
+			return nil, fmt.Errorf("thing %d: %w", thingID, err)
+		}
+		history, err := e.thingRepo.GetThingHistorySince(c, thingID, since, maxRuleHistory)
+		if err != nil {
+			return nil, err
+		}
+		state.things[thingID] = thing
+		state.history[thingID] = history
+	}
+	return state, nil
+}
+
+// Evaluate tells if the rule of a chore matches now.
+func (e *RuleEvaluator) Evaluate(c context.Context, rule *tModel.ThingRule, choreID int) (bool, error) {
+	now := time.Now().UTC()
+	state, err := e.load(c, rule, choreID, now)
+	if err != nil {
+		return false, err
+	}
+	return state.evaluate(rule, now), nil
+}
+
+// Started tells if the rule of a chore matches now but did not right before the last change of its
+// things, actions that should only run once run when a change makes the rule match.
+func (e *RuleEvaluator) Started(c context.Context, rule *tModel.ThingRule, choreID int) (bool, error) {
+	now := time.Now().UTC()
+	state, err := e.load(c, rule, choreID, now)
+	if err != nil {
+		return false, err
+	}
+	changedAt, ok := state.lastChange()
+	if !ok || !state.evaluate(rule, now) {
+		return false, nil
+	}
+	// loaded again from the change on, for the states right before it
+	state, err = e.load(c, rule, choreID, changedAt)
+	if err != nil {
+		return false, err
+	}
+	return !state.evaluate(rule, changedAt.Add(-time.Nanosecond)), nil
+}
+
+// StartedSince tells if the rule of a chore matches now but did not at a time, or at the last change of
+// its things when that is later, so a rule a change already made match is not counted again.
+func (e *RuleEvaluator) StartedSince(c context.Context, rule *tModel.ThingRule, choreID int, since time.Time) (bool, error) {
+	now := time.Now().UTC()
+	state, err := e.load(c, rule, choreID, since)
+	if err != nil {
+		return false, err
+	}
+	if changedAt, ok := state.lastChange(); ok && changedAt.After(since) {
+		since = changedAt
+	}
+	return !state.evaluate(rule, since) && state.evaluate(rule, now), nil
+}
+
+// DryRunResult is the outcome of replaying a rule over the history of its things.
+type DryRunResult struct {
+	MatchesNow  bool        `json:"matchesNow"`
+	Triggers    []time.Time `json:"triggers"`    // when the rule started to match
+	Evaluations int         `json:"evaluations"` // points in time the rule was evaluated at
+}
+
+// DryRun evaluates the rule at every change of its things since a time, and when holds would run out,
+// and reports when it would have triggered.
+func (e *RuleEvaluator) DryRun(c context.Context, rule *tModel.ThingRule, choreID int, since time.Time) (*DryRunResult, error) {
+	now := time.Now().UTC()
+	state, err := e.load(c, rule, choreID, since)
+	if err != nil {
+		return nil, err
+	}
+	return state.replay(rule, since, now), nil
+}
+
+func (s *ruleState) replay(rule *tModel.ThingRule, since time.Time, now time.Time) *DryRunResult {
+	var holds []time.Duration
+	rule.Walk(func(leaf *tModel.ThingRule) {
+		if leaf.ForSeconds > 0 {
+			holds = append(holds, time.Duration(leaf.ForSeconds)*time.Second)
+		}
+	})
+	points := []time.Time{since}
+	addPoint := func(at time.Time) {
+		if !at.Before(since) && !at.After(now) {
+			points = append(points, at)
+		}
+	}
+	for _, history := range s.history {
+		for _, h := range history {
+			addPoint(*h.CreatedAt)
+			for _, hold := range holds {
+				addPoint(h.CreatedAt.Add(hold))
+			}
+		}
+	}
+	for _, completedAt := range s.completions {
+		addPoint(completedAt)
+	}
+	points = append(points, now)
+	sort.Slice(points, func(i, j int) bool { return points[i].Before(points[j]) })
+
+	result := &DryRunResult{Triggers: []time.Time{}}
+	matched := false
+	var last time.Time
+	for i, at := range points {
+		if i > 0 && at.Equal(last) {
+			continue
+		}
+		last = at
+		result.Evaluations++
+		matches := s.evaluate(rule, at)
+		if matches && !matched && i > 0 {
+			result.Triggers = append(result.Triggers, at)
+		}
+		matched = matches
+	}
+	result.MatchesNow = matched
+	return result
+}
@@ -0,0 +1,143 @@
+package thing
+
+import (
+	"testing"
+	"time"
+
+	tModel "donetick.com/core/internal/thing/model"
+)
+
+var ruleStart = time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+
+// at returns the time minutes after the start of the test history.
+func at(minutes int) time.Time {
+	return ruleStart.Add(time.Duration(minutes) * time.Minute)
+}
+
+func history(changes ...interface{}) []*tModel.ThingHistory {
+	var entries []*tModel.ThingHistory
+	for i := 0; i < len(changes); i += 2 {
+		createdAt := at(changes[i].(int))
+		entries = append(entries, &tModel.ThingHistory{State: changes[i+1].(string), CreatedAt: &createdAt})
+	}
+	return entries
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+func TestCompareState(t *testing.T) {
+	tests := []struct {
+		condition, state, target string
+		want                     bool
+	}{
+		{"eq", "on", "on", true},
+		{"eq", "1.50", "1.5", true},
+		{"neq", "off", "on", true},
+		{"gt", "21.7", "21.5", true},
+		{"gt", "21.5", "21.5", false},
+		{"gte", "21.5", "21.5", true},
+		{"lt", "-0.5", "0", true},
+		{"lte", "abc", "1", false},
+		{"", "5", "5.0", true},
+	}
+	for _, tt := range tests {
+		if got := compareState(tt.condition, tt.state, tt.target); got != tt.want {
+			t.Errorf("compareState(%s, %s, %s) = %v, want %v", tt.condition, tt.state, tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestEvaluateRule(t *testing.T) {
+	state := &ruleState{
+		things: map[int]*tModel.Thing{1: {ID: 1}, 2: {ID: 2}, 3: {ID: 3}},
+		history: map[int][]*tModel.ThingHistory{
+			1: history(0, "19.5", 30, "23.1", 60, "21.0"),   // temperature
+			2: history(0, "false", 10, "true", 50, "false"), // window open
+			3: history(0, "100", 40, "160", 90, "175"),      // water meter
+		},
+		completions: []time.Time{at(45)},
+	}
+	temperatureOK := tModel.ThingRule{ThingID: 1, Condition: "between", Min: floatPtr(18), Max: floatPtr(22)}
+	windowOpen := tModel.ThingRule{ThingID: 2, Condition: "eq", Target: "true", ForSeconds: 30 * 60}
+	usedWater := tModel.ThingRule{ThingID: 3, Condition: "changed_by", Target: "50"}
+
+	tests := []struct {
+		name    string
+		rule    tModel.ThingRule
+		minutes int
+		want    bool
+	}{
+		{"between", temperatureOK, 5, true},
+		{"between above max", temperatureOK, 35, false},
+		{"not held long enough", windowOpen, 30, false},
+		{"held", windowOpen, 40, true},
+		{"hold broken", windowOpen, 55, false},
+		// the first state is the baseline until the first completion
+		{"changed since first state", usedWater, 42, true},
+		{"changed since completion", usedWater, 95, false},
+		{"before history", temperatureOK, -5, false},
+		{"and", tModel.ThingRule{Op: tModel.RuleOpAnd, Rules: []tModel.ThingRule{temperatureOK, windowOpen}}, 40, false},
+		{"or", tModel.ThingRule{Op: tModel.RuleOpOr, Rules: []tModel.ThingRule{temperatureOK, windowOpen}}, 40, true},
+		{"nested", tModel.ThingRule{Op: tModel.RuleOpAnd, Rules: []tModel.ThingRule{
+			temperatureOK,
+			{Op: tModel.RuleOpOr, Rules: []tModel.ThingRule{windowOpen, usedWater}},
+		}}, 65, false},
+	}
+	for _, tt := range tests {
+		if got := state.evaluate(&tt.rule, at(tt.minutes)); got != tt.want {
+			t.Errorf("%s: evaluate() at %d minutes = %v, want %v", tt.name, tt.minutes, got, tt.want)
+		}
+	}
+}
+
+func TestReplayRule(t *testing.T) {
+	state := &ruleState{
+		things: map[int]*tModel.Thing{2: {ID: 2}},
+		history: map[int][]*tModel.ThingHistory{
+			2: history(0, "false", 10, "true", 50, "false", 70, "true"),
+		},
+	}
+	rule := tModel.ThingRule{ThingID: 2, Condition: "eq", Target: "true", ForSeconds: 30 * 60}
+
+	result := state.replay(&rule, at(0), at(120))
+	// the hold ran out 30 minutes after each opening
+	if len(result.Triggers) != 2 || !result.Triggers[0].Equal(at(40)) || !result.Triggers[1].Equal(at(100)) {
+		t.Errorf("replay() triggers = %v, want 40 and 100 minutes", result.Triggers)
+	}
+	if !result.MatchesNow {
+		t.Errorf("replay() should match at the end")
+	}
+}
+
+func TestValidateRule(t *testing.T) {
+	valid := []tModel.ThingRule{
+		{ThingID: 1, Condition: "eq", Target: "on"},
+		{ThingID: 1, Condition: "gte", Target: "2.5", ForSeconds: 60},
+		{Op: tModel.RuleOpOr, Rules: []tModel.ThingRule{
+			{ThingID: 1, Condition: "between", Min: floatPtr(1), Max: floatPtr(2)},
+			{ThingID: 2, Condition: "changed_by", Target: "10"},
+		}},
+	}
+	for _, rule := range valid {
+		if err := ValidateRule(&rule); err != nil {
+			t.Errorf("ValidateRule(%+v) error = %v", rule, err)
+		}
+	}
+
+	invalid := []tModel.ThingRule{
+		{Condition: "eq", Target: "on"},
+		{ThingID: 1, Condition: "gt", Target: "warm"},
+		{ThingID: 1, Condition: "between", Min: floatPtr(3), Max: floatPtr(2)},
+		{ThingID: 1, Condition: "changed_by", Target: "5", ForSeconds: 60},
+		{ThingID: 1, Condition: "matches", Target: "x"},
+		{Op: "xor", Rules: []tModel.ThingRule{{ThingID: 1, Condition: "eq"}}},
+		{Op: tModel.RuleOpAnd},
+	}
+	for _, rule := range invalid {
+		if err := ValidateRule(&rule); err == nil {
+			t.Errorf("ValidateRule(%+v) should fail", rule)
+		}
+	}
+}
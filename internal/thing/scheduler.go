@@ -0,0 +1,111 @@
+package thing
+
+import (
+	"context"
+	"time"
+
+	chRepo "donetick.com/core/internal/chore/repo"
+	tModel "donetick.com/core/internal/thing/model"
+	tRepo "donetick.com/core/internal/thing/repo"
+	"donetick.com/core/logging"
+)
+
+// RuleScheduler evaluates rules with held conditions every minute, they can start to match while none
+// of their things change.
+type RuleScheduler struct {
+	thingRepo    *tRepo.ThingRepository
+	choreRepo    *chRepo.ChoreRepository
+	evaluator    *RuleEvaluator
+	choreActions ChoreActions
+	ticker       *time.Ticker
+	done         chan bool
+	// rules that started to match before this were acted on already
+	lastRun time.Time
+}
+
+func NewRuleScheduler(tr *tRepo.ThingRepository, cr *chRepo.ChoreRepository, choreActions ChoreActions) *RuleScheduler {
+	return &RuleScheduler{
+		thingRepo:    tr,
+		choreRepo:    cr,
+		evaluator:    NewRuleEvaluator(tr, cr),
+		choreActions: choreActions,
+		ticker:       time.NewTicker(time.Minute),
+		done:         make(chan bool),
+	}
+}
+
+func (s *RuleScheduler) Start(ctx context.Context) {
+	logger := logging.FromContext(ctx)
+
+	go func() {
+		for {
+			select {
+			case <-s.done:
+				return
+			case <-s.ticker.C:
+				if err := s.evaluateHeldRules(ctx); err != nil {
+					logger.Errorw("Failed to evaluate thing rules", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+func (s *RuleScheduler) Stop() {
+	s.ticker.Stop()
+	s.done <- true
+}
+
+func (s *RuleScheduler) evaluateHeldRules(ctx context.Context) error {
+	logger := logging.FromContext(ctx)
+	now := time.Now().UTC()
+	since := s.lastRun
+	if since.IsZero() {
+		since = now.Add(-time.Minute)
+	}
+	s.lastRun = now
+
+	thingChores, err := s.thingRepo.GetIdleRuleTriggers(ctx)
+	if err != nil {
+		return err
+	}
+	for _, tc := range thingChores {
+		if !hasHold(tc.Rule) {
+			continue
+		}
+		if tc.Action != tModel.ThingActionSchedule {
+			started, err := s.evaluator.StartedSince(ctx, tc.Rule, tc.ChoreID, since)
+			if err != nil {
+				logger.Errorw("Failed to evaluate thing rule", "chore", tc.ChoreID, "error", err)
+				continue
+			}
+			if started {
+				if err := runAction(ctx, s.choreActions, tc); err != nil {
+					logger.Errorw("Failed to run thing action", "chore", tc.ChoreID, "action", tc.Action, "error", err)
+				}
+			}
+			continue
+		}
+		matches, err := s.evaluator.Evaluate(ctx, tc.Rule, tc.ChoreID)
+		if err != nil {
+			logger.Errorw("Failed to evaluate thing rule", "chore", tc.ChoreID, "error", err)
+			continue
+		}
+		if matches {
+			if err := s.choreRepo.SetDueDateIfNotExisted(ctx, tc.ChoreID, time.Now().UTC()); err != nil {
+				logger.Errorw("Failed to schedule chore triggered by thing rule", "chore", tc.ChoreID, "error", err)
+			}
+		}
+	}
+	return nil
+}
+
+func hasHold(rule *tModel.ThingRule) bool {
+	held := false
+	rule.Walk(func(leaf *tModel.ThingRule) {
+		if leaf.ForSeconds > 0 {
+			held = true
+		}
+	})
+	return held
+}
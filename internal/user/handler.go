@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"html"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
@@ -15,7 +16,9 @@ import (
 	cModel "donetick.com/core/internal/circle/model"
 	cRepo "donetick.com/core/internal/circle/repo"
 	"donetick.com/core/internal/email"
+	"donetick.com/core/internal/events"
 	"donetick.com/core/internal/mfa"
+	"donetick.com/core/internal/notifier"
 	nModel "donetick.com/core/internal/notifier/model"
 	storage "donetick.com/core/internal/storage"
 	storageRepo "donetick.com/core/internal/storage/repo"
@@ -42,13 +45,15 @@ type Handler struct {
 	storage                *storage.S3Storage
 	storageRepo            *storageRepo.StorageRepository
 	signer                 *storage.URLSignerS3
+	notifier               *notifier.Notifier
+	egress                 *utils.EgressPolicy
 }
 
 func NewHandler(ur *uRepo.UserRepository, cr *cRepo.CircleRepository,
 	jwtAuth *jwt.GinJWTMiddleware, email *email.EmailSender,
 	idp *auth.IdentityProvider, storage *storage.S3Storage,
 	signer *storage.URLSignerS3, storageRepo *storageRepo.StorageRepository,
-	config *config.Config) *Handler {
+	nt *notifier.Notifier, config *config.Config) *Handler {
 	return &Handler{
 		userRepo:               ur,
 		circleRepo:             cr,
@@ -61,6 +66,8 @@ func NewHandler(ur *uRepo.UserRepository, cr *cRepo.CircleRepository,
 		storage:                storage,
 		storageRepo:            storageRepo,
 		signer:                 signer,
+		notifier:               nt,
+		egress:                 utils.NewEgressPolicy(config),
 	}
 }
 
@@ -642,14 +649,30 @@ func (h *Handler) CreateLongLivedToken(c *gin.Context) {
 	}
 
 	type TokenRequest struct {
-		Name    string `json:"name" binding:"required"`
-		MFACode string `json:"mfaCode"` // Optional MFA code for enhanced security
+		Name      string                `json:"name" binding:"required"`
+		MFACode   string                `json:"mfaCode"`   // Optional MFA code for enhanced security
+		Scopes    uModel.APITokenScopes `json:"scopes"`    // Optional, the token can do everything without scopes
+		ExpiresAt *time.Time            `json:"expiresAt"` // Optional, the token never expires without it
 	}
 	var req TokenRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
 		return
 	}
+	for _, scope := range req.Scopes {
+		if !uModel.IsValidAPITokenScope(scope) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid scope %q", scope)})
+			return
+		}
+	}
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(time.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Expiration date must be in the future"})
+			return
+		}
+		expiresAt := req.ExpiresAt.UTC()
+		req.ExpiresAt = &expiresAt
+	}
 
 	// If user has MFA enabled and provides an MFA code, verify it
 	if currentUser.MFAEnabled && req.MFACode != "" {
@@ -692,7 +715,7 @@ func (h *Handler) CreateLongLivedToken(c *gin.Context) {
 	hash := sha256.Sum256([]byte(hashInput))
 	token := hex.EncodeToString(hash[:])
 
-	tokenModel, err := h.userRepo.StoreAPIToken(c, currentUser.ID, req.Name, token)
+	tokenModel, err := h.userRepo.StoreAPIToken(c, currentUser.ID, req.Name, token, req.Scopes, req.ExpiresAt)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store the token"})
 		return
@@ -769,6 +792,19 @@ func (h *Handler) UpdateNotificationTarget(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{})
 		return
 	}
+	if err := h.notifier.ValidateTarget(req.Type, req.Target); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Type == nModel.NotificationPlatformEmail && !h.isConfirmedEmail(c, currentUser, req.Target) {
+		if err := h.email.SendTargetConfirmationEmail(c, currentUser.ID, req.Target); err != nil {
+			logging.FromContext(c).Errorw("user.handler.UpdateNotificationTarget failed to send confirmation email", "err", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to send email"})
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{"message": "Confirmation email sent, reminders go to the address once it is confirmed"})
+		return
+	}
 
 	err := h.userRepo.UpdateNotificationTarget(c, currentUser.ID, req.Target, req.Type)
 	if err != nil {
@@ -785,6 +821,110 @@ func (h *Handler) UpdateNotificationTarget(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{})
 }
 
+// testNotificationTarget sends a sample notification to a target, it does not have to be saved yet.
+func (h *Handler) testNotificationTarget(c *gin.Context) {
+	logger := logging.FromContext(c)
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get current user"})
+		return
+	}
+
+	type Request struct {
+		Type   nModel.NotificationPlatform `json:"type"`
+		Target string                      `json:"target"`
+	}
+
+	var req Request
+	if err := c.ShouldBindJSON(&req); err != nil || req.Type == nModel.NotificationPlatformNone {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if err := h.notifier.ValidateTarget(req.Type, req.Target); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Type == nModel.NotificationPlatformEmail && !h.isConfirmedEmail(c, currentUser, req.Target) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Confirm the email address before sending a test to it"})
+		return
+	}
+
+	assignee := currentUser.DisplayName
+	if assignee == "" {
+		assignee = currentUser.Username
+	}
+	if err := h.notifier.SendTestNotification(c, req.Type, req.Target, currentUser.ID, assignee, currentUser.Username); err != nil {
+		logger.Debugw("Failed to send test notification", "type", req.Type, "err", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to send test notification, check the target"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{})
+}
+
+// isConfirmedEmail tells if the address is known to belong to the user, the confirmed notification target or
+// the account email of a user who logged in through a provider that verified it.
+func (h *Handler) isConfirmedEmail(c *gin.Context, user *uModel.UserDetails, address string) bool {
+	if user.Provider != uModel.AuthProviderDonetick && strings.EqualFold(user.Email, address) {
+		return true
+	}
+	target, err := h.userRepo.GetNotificationTarget(c, user.ID)
+	return err == nil && target.Type == nModel.NotificationPlatformEmail && target.TargetID == address
+}
+
+// confirmEmailTarget sets the email notification target from the link sent to the address.
+func (h *Handler) confirmEmailTarget(c *gin.Context) {
+	logger := logging.FromContext(c)
+	userID, address, err := h.email.DecodeTargetConfirmationToken(c.Query("t"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired confirmation link"})
+		return
+	}
+	if err := h.userRepo.UpdateNotificationTarget(c, userID, address, nModel.NotificationPlatformEmail); err != nil {
+		logger.Errorw("user.handler.confirmEmailTarget failed to update notification target", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification target"})
+		return
+	}
+	if err := h.userRepo.UpdateNotificationTargetForAllNotifications(c, userID, address, nModel.NotificationPlatformEmail); err != nil {
+		logger.Errorw("user.handler.confirmEmailTarget failed to update pending notifications", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification target for all notifications"})
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte("<!DOCTYPE html><html><body><p>Your Donetick reminders are now sent to this email address.</p></body></html>"))
+}
+
+// unsubscribeEmail turns off email notifications from the link in a reminder email, the GET is the link
+// a user clicks and the POST is the RFC 8058 one-click unsubscribe done by the mail client.
+func (h *Handler) unsubscribeEmail(c *gin.Context) {
+	logger := logging.FromContext(c)
+	userID, address, err := h.email.DecodeUnsubscribeToken(c.Query("t"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid unsubscribe link"})
+		return
+	}
+
+	target, err := h.userRepo.GetNotificationTarget(c, userID)
+	// the user might have moved to another platform or address since the email was sent
+	if err == nil && target.Type == nModel.NotificationPlatformEmail && target.TargetID == address {
+		if err := h.userRepo.DeleteNotificationTarget(c, userID); err != nil {
+			logger.Errorw("user.handler.unsubscribeEmail failed to delete notification target", "err", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unsubscribe"})
+			return
+		}
+		if err := h.userRepo.UpdateNotificationTargetForAllNotifications(c, userID, "", nModel.NotificationPlatformNone); err != nil {
+			logger.Errorw("user.handler.unsubscribeEmail failed to update pending notifications", "err", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unsubscribe"})
+			return
+		}
+	}
+
+	if c.Request.Method == http.MethodPost {
+		c.JSON(http.StatusOK, gin.H{})
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte("<!DOCTYPE html><html><body><p>You have been unsubscribed from Donetick email notifications.</p></body></html>"))
+}
+
 func (h *Handler) updateUserPasswordLoggedInOnly(c *gin.Context) {
 	if h.isDonetickDotCom {
 		// only enable this feature for self-hosted instances
@@ -837,7 +977,9 @@ func (h *Handler) setWebhook(c *gin.Context) {
 	}
 
 	type Request struct {
-		URL *string `json:"url"`
+		URL          *string   `json:"url"`
+		RotateSecret bool      `json:"rotateSecret"`
+		Events       *[]string `json:"events"` // event types to send, all when empty and unchanged when missing
 	}
 
 	var req Request
@@ -845,6 +987,21 @@ func (h *Handler) setWebhook(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
 		return
 	}
+	if req.URL != nil && *req.URL != "" {
+		webhookURL, err := url.Parse(*req.URL)
+		if err != nil || h.egress.CheckHost(webhookURL.Hostname()) != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Webhook URL must not be a loopback or private address"})
+			return
+		}
+	}
+	if req.Events != nil {
+		for _, eventType := range *req.Events {
+			if !events.IsValidEventType(events.EventType(eventType)) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Unknown event type %s", eventType)})
+				return
+			}
+		}
+	}
 
 	// get circle admins
 	admins, err := h.circleRepo.GetCircleAdmins(c, currentUser.CircleID)
@@ -871,7 +1028,41 @@ func (h *Handler) setWebhook(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set webhook URL"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{})
+	if req.Events != nil {
+		if err := h.circleRepo.SetWebhookEvents(c, currentUser.CircleID, strings.Join(*req.Events, ",")); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set webhook events"})
+			return
+		}
+	}
+	if req.URL == nil {
+		c.JSON(http.StatusOK, gin.H{})
+		return
+	}
+
+	// deliveries are signed with the circle secret, it is created with the first webhook and kept until rotated
+	circle, err := h.circleRepo.GetCircleByID(c, currentUser.CircleID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get circle details"})
+		return
+	}
+	secret := ""
+	if circle.WebhookSecret != nil {
+		secret = *circle.WebhookSecret
+	}
+	if secret == "" || req.RotateSecret {
+		secret, err = utils.GenerateWebhookSecret()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate webhook secret"})
+			return
+		}
+		if err := h.circleRepo.SetWebhookSecret(c, currentUser.CircleID, secret); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set webhook secret"})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"res": gin.H{"url": req.URL, "secret": secret, "events": circle.WebhookEvents},
+	})
 }
 
 func (h *Handler) updateProfilePhoto(c *gin.Context) {
@@ -972,6 +1163,7 @@ func Routes(router *gin.Engine, h *Handler, auth *jwt.GinJWTMiddleware, limiter
 		userRoutes.DELETE("/tokens/:id", h.DeleteUserToken)
 		userRoutes.PUT("/webhook", h.setWebhook)
 		userRoutes.PUT("/targets", h.UpdateNotificationTarget)
+		userRoutes.POST("/targets/test", h.testNotificationTarget)
 		userRoutes.PUT("change_password", h.updateUserPasswordLoggedInOnly)
 		userRoutes.POST("profile_photo", h.updateProfilePhoto)
 		userRoutes.GET("storage", h.getStorageUsage)
@@ -994,5 +1186,8 @@ func Routes(router *gin.Engine, h *Handler, auth *jwt.GinJWTMiddleware, limiter
 		authRoutes.POST("reset", h.resetPassword)
 		authRoutes.POST("password", h.updateUserPassword)
 		authRoutes.POST("mfa/verify", h.verifyMFA) // Add MFA verification endpoint
+		authRoutes.GET("unsubscribe", h.unsubscribeEmail)
+		authRoutes.GET("confirm_email_target", h.confirmEmailTarget)
+		authRoutes.POST("unsubscribe", h.unsubscribeEmail)
 	}
 }
@@ -1,6 +1,9 @@
 package user
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"time"
 
 	nModel "donetick.com/core/internal/notifier/model"
@@ -45,11 +48,75 @@ type UserPasswordReset struct {
 }
 
 type APIToken struct {
-	ID        int       `json:"id" gorm:"primary_key"`              // Unique identifier
-	Name      string    `json:"name" gorm:"column:name;unique"`     // Name (unique)
-	UserID    int       `json:"userId" gorm:"column:user_id;index"` // Index on userID
-	Token     string    `json:"token" gorm:"column:token;index"`    // Index on token
-	CreatedAt time.Time `json:"createdAt" gorm:"column:created_at"`
+	ID        int            `json:"id" gorm:"primary_key"`                        // Unique identifier
+	Name      string         `json:"name" gorm:"column:name;unique"`               // Name (unique)
+	UserID    int            `json:"userId" gorm:"column:user_id;index"`           // Index on userID
+	Token     string         `json:"token" gorm:"column:token;index"`              // Index on token
+	Scopes    APITokenScopes `json:"scopes" gorm:"column:scopes;type:json"`        // What the token can do, everything when empty
+	ExpiresAt *time.Time     `json:"expiresAt,omitempty" gorm:"column:expires_at"` // Never expires when nil
+	CreatedAt time.Time      `json:"createdAt" gorm:"column:created_at"`
+}
+
+// IsExpired tells if the token can no longer be used at the given time.
+func (t *APIToken) IsExpired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// APITokenScope is a part of the external API a token can be used for.
+type APITokenScope string
+
+const (
+	APITokenScopeRead        APITokenScope = "read"         // read chores, labels and things
+	APITokenScopeChoresWrite APITokenScope = "chores:write" // change chores, their subtasks and labels
+	APITokenScopeThingsWrite APITokenScope = "things:write" // change things and their states
+)
+
+func IsValidAPITokenScope(scope APITokenScope) bool {
+	switch scope {
+	case APITokenScopeRead, APITokenScopeChoresWrite, APITokenScopeThingsWrite:
+		return true
+	}
+	return false
+}
+
+type APITokenScopes []APITokenScope
+
+// Allows tells if the scopes cover the required one. Tokens without scopes were created before scopes
+// existed and can do everything, and any scope allows reading.
+func (s APITokenScopes) Allows(required APITokenScope) bool {
+	if len(s) == 0 {
+		return true
+	}
+	for _, scope := range s {
+		if scope == required {
+			return true
+		}
+	}
+	return required == APITokenScopeRead
+}
+
+func (s APITokenScopes) Value() (driver.Value, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(s)
+}
+
+func (s *APITokenScopes) Scan(value interface{}) error {
+	if value == nil {
+		*s = nil
+		return nil
+	}
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(bytes, s)
 }
 
 type UserNotificationTarget struct {
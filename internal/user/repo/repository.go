@@ -151,11 +151,13 @@ func (r *UserRepository) UpdatePasswordByToken(ctx context.Context, email string
 	return nil
 }
 
-func (r *UserRepository) StoreAPIToken(c context.Context, userID int, name string, tokenCode string) (*uModel.APIToken, error) {
+func (r *UserRepository) StoreAPIToken(c context.Context, userID int, name string, tokenCode string, scopes uModel.APITokenScopes, expiresAt *time.Time) (*uModel.APIToken, error) {
 	token := &uModel.APIToken{
 		UserID:    userID,
 		Name:      name,
 		Token:     tokenCode,
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
 		CreatedAt: time.Now().UTC(),
 	}
 	if err := r.db.WithContext(c).Model(&uModel.APIToken{}).Save(
@@ -169,12 +171,20 @@ func (r *UserRepository) StoreAPIToken(c context.Context, userID int, name strin
 func (r *UserRepository) GetUserByToken(c context.Context, token string) (*uModel.UserDetails, error) {
 	var user *uModel.UserDetails
 
-	if err := r.db.WithContext(c).Table("users u").Select("u.*, c.webhook_url as webhook_url").Joins("left join api_tokens at on at.user_id = u.id").Joins("left join circles c on c.id = u.circle_id").Where("at.token = ?", token).First(&user).Error; err != nil {
+	if err := r.db.WithContext(c).Table("users u").Select("u.*, c.webhook_url as webhook_url").Joins("left join api_tokens at on at.user_id = u.id").Joins("left join circles c on c.id = u.circle_id").Where("at.token = ? AND (at.expires_at IS NULL OR at.expires_at > ?)", token, time.Now().UTC()).First(&user).Error; err != nil {
 		return nil, err
 	}
 	return user, nil
 }
 
+func (r *UserRepository) GetAPIToken(c context.Context, token string) (*uModel.APIToken, error) {
+	var apiToken *uModel.APIToken
+	if err := r.db.WithContext(c).Where("token = ?", token).First(&apiToken).Error; err != nil {
+		return nil, err
+	}
+	return apiToken, nil
+}
+
 func (r *UserRepository) GetAllUserTokens(c context.Context, userID int) ([]*uModel.APIToken, error) {
 	var tokens []*uModel.APIToken
 	if err := r.db.WithContext(c).Where("user_id = ?", userID).Find(&tokens).Error; err != nil {
@@ -196,6 +206,14 @@ func (r *UserRepository) UpdateNotificationTarget(c context.Context, userID int,
 	}).Error
 }
 
+func (r *UserRepository) GetNotificationTarget(c context.Context, userID int) (*uModel.UserNotificationTarget, error) {
+	var target uModel.UserNotificationTarget
+	if err := r.db.WithContext(c).Where("user_id = ?", userID).First(&target).Error; err != nil {
+		return nil, err
+	}
+	return &target, nil
+}
+
 func (r *UserRepository) DeleteNotificationTarget(c context.Context, userID int) error {
 	return r.db.WithContext(c).Where("user_id = ?", userID).Delete(&uModel.UserNotificationTarget{}).Error
 }
@@ -26,3 +26,12 @@ func GenerateInviteCode(c *gin.Context) string {
 
 	return token
 }
+
+// GenerateWebhookSecret returns a random key for signing webhook deliveries.
+func GenerateWebhookSecret() (string, error) {
+	secretBytes := make([]byte, 32)
+	if _, err := crand.Read(secretBytes); err != nil {
+		return "", err
+	}
+	return "whsec_" + base64.RawURLEncoding.EncodeToString(secretBytes), nil
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+
+	"donetick.com/core/config"
+	"donetick.com/core/logging"
+)
+
+var ErrPrivateAddress = errors.New("address is loopback or private")
+
+// IsPublicIP reports whether the IP can be reached on the internet.
+func IsPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast())
+}
+
+// EgressPolicy decides which addresses the server sends webhooks and notifications to: public addresses and
+// the private networks of WebhookConfig.AllowedPrivateNetworks, so users can't point the server at the
+// network it runs in unless the operator allows it.
+type EgressPolicy struct {
+	allowed []*net.IPNet
+}
+
+func NewEgressPolicy(cfg *config.Config) *EgressPolicy {
+	p := &EgressPolicy{}
+	for _, network := range cfg.WebhookConfig.AllowedPrivateNetworks {
+		_, ipNet, err := net.ParseCIDR(strings.TrimSpace(network))
+		if err != nil {
+			logging.DefaultLogger().Warnw("Ignoring invalid allowed private network of webhooks", "network", network, "error", err)
+			continue
+		}
+		p.allowed = append(p.allowed, ipNet)
+	}
+	return p
+}
+
+// AllowsIP reports whether the IP is public or in an allowed private network.
+func (p *EgressPolicy) AllowsIP(ip net.IP) bool {
+	if IsPublicIP(ip) {
+		return true
+	}
+	for _, network := range p.allowed {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckHost rejects localhost and IP literals the policy doesn't allow. Host names are checked again when
+// they are dialed by a client from NewHTTPClient.
+func (p *EgressPolicy) CheckHost(host string) error {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		host = "127.0.0.1"
+	}
+	if ip := net.ParseIP(strings.Trim(host, "[]")); ip != nil && !p.AllowsIP(ip) {
+		return ErrPrivateAddress
+	}
+	return nil
+}
+
+// NewHTTPClient returns a client that refuses to connect to addresses the policy doesn't allow, the address
+// is checked after the host name is resolved. Requests go through the proxy of the environment like other
+// clients, the proxy then resolves the host so only CheckHost guards the destination, and a proxy on a
+// private network has to be in the allowed networks.
+func (p *EgressPolicy) NewHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !p.AllowsIP(ip) {
+				return ErrPrivateAddress
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
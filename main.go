@@ -23,10 +23,16 @@ import (
 	"donetick.com/core/internal/database"
 	"donetick.com/core/internal/email"
 	"donetick.com/core/internal/events"
+	eRepo "donetick.com/core/internal/events/repo"
 	label "donetick.com/core/internal/label"
 	lRepo "donetick.com/core/internal/label/repo"
 	"donetick.com/core/internal/mfa"
+	"donetick.com/core/internal/offline"
+	oRepo "donetick.com/core/internal/offline/repo"
+	"donetick.com/core/internal/openapi"
+	"donetick.com/core/internal/realtime"
 	"donetick.com/core/internal/resource"
+	"donetick.com/core/internal/router"
 	"donetick.com/core/internal/storage"
 	storageRepo "donetick.com/core/internal/storage/repo"
 	spRepo "donetick.com/core/internal/subtask/repo"
@@ -35,10 +41,15 @@ import (
 	nRepo "donetick.com/core/internal/notifier/repo"
 	nps "donetick.com/core/internal/notifier/service"
 	discord "donetick.com/core/internal/notifier/service/discord"
+	"donetick.com/core/internal/notifier/service/gotify"
+	"donetick.com/core/internal/notifier/service/matrix"
+	"donetick.com/core/internal/notifier/service/ntfy"
 	"donetick.com/core/internal/notifier/service/pushover"
 	telegram "donetick.com/core/internal/notifier/service/telegram"
+	"donetick.com/core/internal/notifier/service/webhook"
 	pRepo "donetick.com/core/internal/points/repo"
 	"donetick.com/core/internal/thing"
+	thingMQTT "donetick.com/core/internal/thing/mqtt"
 	tRepo "donetick.com/core/internal/thing/repo"
 	"donetick.com/core/internal/user"
 	uRepo "donetick.com/core/internal/user/repo"
@@ -66,6 +77,7 @@ func main() {
 		fx.Provide(database.NewDatabase),
 		fx.Provide(chRepo.NewChoreRepository),
 		fx.Provide(chore.NewHandler),
+		fx.Provide(chore.NewTrashPurger),
 		fx.Provide(uRepo.NewUserRepository),
 		fx.Provide(user.NewHandler),
 		fx.Provide(cRepo.NewCircleRepository),
@@ -75,11 +87,22 @@ func main() {
 		fx.Provide(nps.NewNotificationPlanner),
 
 		// add notifier
-		fx.Provide(pushover.NewPushover),
-		fx.Provide(telegram.NewTelegramNotifier),
-		fx.Provide(discord.NewDiscordNotifier),
+		fx.Provide(notifier.AsProvider(pushover.NewPushover)),
+		fx.Provide(notifier.AsProvider(telegram.NewTelegramNotifier)),
+		fx.Provide(notifier.AsProvider(discord.NewDiscordNotifier)),
+		fx.Provide(notifier.AsProvider(ntfy.NewNtfy)),
+		fx.Provide(notifier.AsProvider(gotify.NewGotify)),
+		fx.Provide(notifier.AsProvider(email.NewEmailNotifier)),
+		fx.Provide(notifier.AsProvider(webhook.NewWebhook)),
+		fx.Provide(notifier.AsProvider(matrix.NewMatrix)),
 		fx.Provide(notifier.NewNotifier),
+		fx.Provide(eRepo.NewWebhookDeliveryRepository),
 		fx.Provide(events.NewEventsProducer),
+		fx.Provide(realtime.NewBroker),
+		fx.Provide(realtime.NewHandler),
+		fx.Provide(oRepo.NewOfflineRepository),
+		fx.Provide(offline.NewHandler),
+		fx.Provide(offline.NewRetention),
 
 		// Rate limiter
 		fx.Provide(utils.NewRateLimiter),
@@ -108,10 +131,15 @@ func main() {
 
 		fx.Provide(thing.NewAPI),
 		fx.Provide(thing.NewHandler),
+		fx.Provide(thingMQTT.NewBridge),
+		fx.Provide(thing.NewRuleScheduler),
+		fx.Provide(thing.NewHistoryRetention),
 
 		fx.Provide(chore.NewAPI),
+		fx.Provide(fx.Annotate(chore.NewThingActions, fx.As(new(thing.ChoreActions)))),
 
 		fx.Provide(frontend.NewHandler),
+		fx.Provide(openapi.NewHandler),
 
 		// storage :
 		// is storage local or remote?
@@ -124,20 +152,8 @@ func main() {
 		fx.Provide(storageRepo.NewStorageRepository),
 
 		// fx.Invoke(RunApp),
-		fx.Invoke(
-			chore.Routes,
-			chore.APIs,
-			user.Routes,
-			circle.Routes,
-			thing.Routes,
-			thing.APIs,
-			label.Routes,
-			storage.Routes,
-			frontend.Routes,
-			resource.Routes,
-
-			func(r *gin.Engine) {},
-		),
+		fx.Invoke(router.Registrations()...),
+		fx.Invoke(func(r *gin.Engine) {}),
 	)
 
 	if err := app.Err(); err != nil {
@@ -148,7 +164,7 @@ func main() {
 
 }
 
-func newServer(lc fx.Lifecycle, cfg *config.Config, db *gorm.DB, notifier *notifier.Scheduler, eventProducer *events.EventsProducer, mfaCleanup *mfa.CleanupService) *gin.Engine {
+func newServer(lc fx.Lifecycle, cfg *config.Config, db *gorm.DB, notifier *notifier.Scheduler, eventProducer *events.EventsProducer, mfaCleanup *mfa.CleanupService, mqttBridge *thingMQTT.Bridge, ruleScheduler *thing.RuleScheduler, historyRetention *thing.HistoryRetention, broker *realtime.Broker, offlineRetention *offline.Retention, trashPurger *chore.TrashPurger) *gin.Engine {
 	gin.SetMode(gin.DebugMode)
 	// log when http request is made:
 
@@ -183,7 +199,14 @@ func newServer(lc fx.Lifecycle, cfg *config.Config, db *gorm.DB, notifier *notif
 			}
 			notifier.Start(context.Background())
 			eventProducer.Start(context.Background())
+			if err := mqttBridge.Start(context.Background()); err != nil {
+				return err
+			}
 			mfaCleanup.Start(context.Background())
+			ruleScheduler.Start(context.Background())
+			historyRetention.Start(context.Background())
+			offlineRetention.Start(context.Background())
+			trashPurger.Start(context.Background())
 			go func() {
 				if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 					log.Fatalf("listen: %s\n", err)
@@ -193,6 +216,12 @@ func newServer(lc fx.Lifecycle, cfg *config.Config, db *gorm.DB, notifier *notif
 		},
 		OnStop: func(context.Context) error {
 			mfaCleanup.Stop()
+			ruleScheduler.Stop()
+			historyRetention.Stop()
+			offlineRetention.Stop()
+			trashPurger.Stop()
+			mqttBridge.Stop()
+			broker.Stop()
 			if err := srv.Shutdown(context.Background()); err != nil {
 				log.Fatalf("Server Shutdown: %s", err)
 			}
@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"context"
+
+	"donetick.com/core/logging"
+	"gorm.io/gorm"
+)
+
+type ShareThingsInCircles20261018 struct{}
+
+func (m ShareThingsInCircles20261018) ID() string {
+	return "20261018_share_things_in_circles"
+}
+
+func (m ShareThingsInCircles20261018) Description() string {
+	return "Set the circle of things created before they were shared with their circle"
+}
+
+func (m ShareThingsInCircles20261018) Down(ctx context.Context, db *gorm.DB) error {
+	// things stay in the circle of their creator, which is where they were looked up before
+	return nil
+}
+
+func (m ShareThingsInCircles20261018) Up(ctx context.Context, db *gorm.DB) error {
+	log := logging.FromContext(ctx)
+
+	if err := db.Exec(`UPDATE things SET circle_id = (SELECT users.circle_id FROM users WHERE users.id = things.user_id)
+		WHERE circle_id IS NULL OR circle_id = 0`).Error; err != nil {
+		log.Errorf("Failed to set the circle of things: %v", err)
+		return err
+	}
+	return nil
+}
+
+func init() {
+	Register(ShareThingsInCircles20261018{})
+}